@@ -0,0 +1,131 @@
+package convtree
+
+import "math"
+
+// Activity holds the weight inserted into a leaf per hour of day and per day of week,
+// as reported by the tree clock. Day 0 is Sunday.
+type Activity struct {
+	Hours [24]float64
+	Days  [7]float64
+}
+
+// Profile is the normalized activity of a leaf. Hours and Days sum to 1 unless the leaf has no
+// recorded activity.
+type Profile struct {
+	Hours [24]float64
+	Days  [7]float64
+	Raw   Activity
+}
+
+// WithActivityProfiles makes leaves record the hour and the day of week of inserted weight.
+// When a leaf is split, its activity is distributed over the children in proportion to their
+// weight, and merged leaves sum their activity.
+func WithActivityProfiles() Option {
+	return func(config *treeConfig) {
+		config.activity = true
+	}
+}
+
+func (config *treeConfig) recordsActivity() bool {
+	return config != nil && config.activity
+}
+
+// ActivityProfile returns the activity profile of the leaf with the given ID. The profile is
+// empty for unknown leaves or when activity profiles are disabled.
+func (tree *ConvTree) ActivityProfile(leafID string) Profile {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	profile := Profile{}
+	tree.walkNodes(func(node *ConvTree) bool {
+		if node.ID != leafID {
+			return true
+		}
+		if node.IsLeaf {
+			profile = node.Activity.profile()
+		}
+		return false
+	})
+	return profile
+}
+
+// ProfileDistance returns the mean of the Jensen-Shannon divergences of the hour and the day
+// distributions of the profiles. The distance is between 0 for identical and 1 for disjoint
+// patterns; an empty profile is at distance 1 from any non-empty one.
+func ProfileDistance(a, b Profile) float64 {
+	return (jensenShannon(a.Hours[:], b.Hours[:]) + jensenShannon(a.Days[:], b.Days[:])) / 2
+}
+
+func (tree *ConvTree) recordActivity(weight int) {
+	if !tree.config.recordsActivity() {
+		return
+	}
+	now := tree.config.now()
+	tree.Activity.Hours[now.Hour()] += float64(weight)
+	tree.Activity.Days[now.Weekday()] += float64(weight)
+}
+
+func (activity Activity) scaled(factor float64) Activity {
+	for i := range activity.Hours {
+		activity.Hours[i] *= factor
+	}
+	for i := range activity.Days {
+		activity.Days[i] *= factor
+	}
+	return activity
+}
+
+func (activity *Activity) add(other Activity) {
+	for i := range activity.Hours {
+		activity.Hours[i] += other.Hours[i]
+	}
+	for i := range activity.Days {
+		activity.Days[i] += other.Days[i]
+	}
+}
+
+func (activity Activity) profile() Profile {
+	profile := Profile{Raw: activity}
+	hours, days := 0.0, 0.0
+	for _, weight := range activity.Hours {
+		hours += weight
+	}
+	for _, weight := range activity.Days {
+		days += weight
+	}
+	for i, weight := range activity.Hours {
+		if hours > 0 {
+			profile.Hours[i] = weight / hours
+		}
+	}
+	for i, weight := range activity.Days {
+		if days > 0 {
+			profile.Days[i] = weight / days
+		}
+	}
+	return profile
+}
+
+func jensenShannon(p, q []float64) float64 {
+	pEmpty, qEmpty := true, true
+	for i := range p {
+		pEmpty = pEmpty && p[i] == 0
+		qEmpty = qEmpty && q[i] == 0
+	}
+	if pEmpty || qEmpty {
+		if pEmpty && qEmpty {
+			return 0
+		}
+		return 1
+	}
+	divergence := 0.0
+	for i := range p {
+		m := (p[i] + q[i]) / 2
+		if p[i] > 0 {
+			divergence += p[i] * math.Log2(p[i]/m) / 2
+		}
+		if q[i] > 0 {
+			divergence += q[i] * math.Log2(q[i]/m) / 2
+		}
+	}
+	return divergence
+}
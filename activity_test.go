@@ -0,0 +1,89 @@
+package convtree
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestActivityProfilesWithClock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC) // Monday
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil,
+		WithActivityProfiles(), WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.divide(50, 50)
+	inserts := []struct {
+		at     time.Time
+		point  Point
+		weight int
+	}{
+		{at: now, point: Point{X: 25, Y: 75}, weight: 3},
+		{at: now.Add(30 * time.Minute), point: Point{X: 25, Y: 75}, weight: 1},
+		{at: time.Date(2024, 1, 3, 18, 15, 0, 0, time.UTC), point: Point{X: 25, Y: 75}, weight: 4}, // Wednesday
+		{at: time.Date(2024, 1, 7, 9, 45, 0, 0, time.UTC), point: Point{X: 75, Y: 25}, weight: 2},  // Sunday
+	}
+	for _, insert := range inserts {
+		now = insert.at
+		insert.point.Weight = insert.weight
+		if err := tree.Insert(insert.point, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	weekly := tree.ActivityProfile(tree.ChildTopLeft.ID)
+	if weekly.Raw.Hours[9] != 4 || weekly.Raw.Hours[18] != 4 || weekly.Raw.Days[1] != 4 || weekly.Raw.Days[3] != 4 {
+		t.Fatalf("unexpected raw activity %+v", weekly.Raw)
+	}
+	for i, share := range weekly.Hours {
+		if expected := map[int]float64{9: 0.5, 18: 0.5}[i]; share != expected {
+			t.Fatalf("hour %d has a share of %v instead of %v", i, share, expected)
+		}
+	}
+	for i, share := range weekly.Days {
+		if expected := map[int]float64{1: 0.5, 3: 0.5}[i]; share != expected {
+			t.Fatalf("day %d has a share of %v instead of %v", i, share, expected)
+		}
+	}
+	sunday := tree.ActivityProfile(tree.ChildBottomRight.ID)
+	if sunday.Hours[9] != 1 || sunday.Days[0] != 1 {
+		t.Fatalf("unexpected Sunday profile %+v", sunday)
+	}
+
+	// The hour distributions {0.5, 0.5} and {1, 0} have a divergence of 1.5 - 0.75 log2(3), and
+	// the days are disjoint.
+	expected := (1.5 - 0.75*math.Log2(3) + 1) / 2
+	if distance := ProfileDistance(weekly, sunday); math.Abs(distance-expected) > 1e-12 {
+		t.Fatalf("expected a distance of %v, got %v", expected, distance)
+	}
+	if distance := ProfileDistance(weekly, weekly); distance != 0 {
+		t.Fatalf("a profile is at distance %v from itself", distance)
+	}
+	empty := tree.ActivityProfile(tree.ChildTopRight.ID)
+	if empty != (Profile{}) || ProfileDistance(empty, weekly) != 1 || ProfileDistance(empty, empty) != 0 {
+		t.Fatalf("unexpected empty profile %+v", empty)
+	}
+	if profile := tree.ActivityProfile("unknown"); profile != (Profile{}) {
+		t.Fatalf("unknown leaf has profile %+v", profile)
+	}
+
+	// Merged leaves sum their activity.
+	tree.Merge(1000)
+	if merged := tree.ActivityProfile(tree.ID); merged.Raw.Hours[9] != 6 || merged.Raw.Days[0] != 2 ||
+		merged.Hours[9] != 0.6 {
+		t.Fatalf("unexpected merged profile %+v", merged)
+	}
+}
+
+func TestActivityProfilesDisabled(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Insert(Point{X: 10, Y: 10, Weight: 5}, false); err != nil {
+		t.Fatal(err)
+	}
+	if profile := tree.ActivityProfile(tree.ID); profile != (Profile{}) {
+		t.Fatalf("activity was recorded without WithActivityProfiles: %+v", profile)
+	}
+}
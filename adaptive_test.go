@@ -0,0 +1,85 @@
+package convtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAdaptiveConvolutionStopsOnStableArgmax(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 100, 4, 1, 9, nil, nil,
+		WithAdaptiveConvolution(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A single spike is the largest cell at first, but the smoothed plateau overtakes it.
+	values := make([][]float64, 9)
+	for i := range values {
+		values[i] = make([]float64, 9)
+	}
+	values[1][1] = 1
+	for i := 5; i <= 7; i++ {
+		for j := 5; j <= 7; j++ {
+			values[i][j] = 0.6
+		}
+	}
+	smoothed, iterations, err := tree.smoothGrid(newGrid(values))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reference := newGrid(values)
+	previousX, previousY := gridArgmax(reference)
+	moves, stable, passes := 0, 0, 0
+	for stable < 2 {
+		next, err := convolve(reference, tree.config.kernelGrid(), 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reference = normalizeGrid(next)
+		passes++
+		x, y := gridArgmax(reference)
+		if x == previousX && y == previousY {
+			stable++
+		} else {
+			moves++
+			stable = 0
+		}
+		previousX, previousY = x, y
+	}
+	if moves == 0 || iterations != passes || iterations >= 10 {
+		t.Fatalf("expected the argmax to move and %d passes, got %d passes and %d moves", passes, iterations, moves)
+	}
+	if x, y := gridArgmax(smoothed); x != 6 || y != 6 {
+		t.Fatalf("expected the plateau center to be the largest cell, got %d %d", x, y)
+	}
+	for i, value := range reference.data {
+		if smoothed.data[i] != value {
+			t.Fatalf("cell %d is %v instead of %v", i, smoothed.data[i], value)
+		}
+	}
+}
+
+func TestAdaptiveConvolutionSplit(t *testing.T) {
+	r := rand.New(rand.NewSource(51))
+	points := uniformPoints(r, 200, 100)
+	for i := 0; i < 300; i++ {
+		points = append(points, Point{X: 70 + r.NormFloat64()*2, Y: 30 + r.NormFloat64()*2, Weight: 1})
+	}
+	fixed, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 100, 1, 5, 16, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adaptive, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 100, 1, 5, 16, nil, points,
+		WithAdaptiveConvolution(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed.Trace.ConvIterations != 5 || adaptive.Trace.ConvIterations != 2 {
+		t.Fatalf("expected 5 fixed and 2 adaptive passes, got %d and %d", fixed.Trace.ConvIterations,
+			adaptive.Trace.ConvIterations)
+	}
+	if adaptive.Trace.X != fixed.Trace.X || adaptive.Trace.Y != fixed.Trace.Y {
+		t.Fatalf("adaptive split at %v %v differs from the full split at %v %v", adaptive.Trace.X,
+			adaptive.Trace.Y, fixed.Trace.X, fixed.Trace.Y)
+	}
+}
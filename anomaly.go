@@ -0,0 +1,77 @@
+package convtree
+
+import "sort"
+
+// WithAnomalyRatio makes DetectAnomalies also report leaves where candidate points carry a
+// baseline tag more than ratio times as often as the points stored in the leaf. A ratio of 0,
+// the default, disables the check.
+func WithAnomalyRatio(ratio float64) Option {
+	return func(config *treeConfig) {
+		config.anomalyRatio = ratio
+	}
+}
+
+// DetectAnomalies routes the candidate points to leaves without inserting them and returns
+// statistics of the leaves that received tagged points with tags outside their BaselineTags, or
+// baseline tags in excess of the ratio set with WithAnomalyRatio. Leaves are sorted by the number
+// of received points carrying non-baseline tags in descending order and then by ID. Points outside
+// the tree bounds and points whose Content is not []string are skipped.
+func (tree *ConvTree) DetectAnomalies(points []Point) []CellStats {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	routed := map[*ConvTree][]Point{}
+	for _, point := range points {
+		if _, ok := pointTags(point); !ok || !tree.contains(point.X, point.Y) {
+			continue
+		}
+		leaf := tree.findLeaf(point.X, point.Y)
+		routed[leaf] = append(routed[leaf], point)
+	}
+	leaves := []*ConvTree{}
+	novel := map[*ConvTree]int{}
+	for leaf, candidates := range routed {
+		baseline := make(map[string]bool, len(leaf.BaselineTags))
+		for _, tag := range leaf.BaselineTags {
+			baseline[tag] = true
+		}
+		for _, point := range candidates {
+			tags, _ := pointTags(point)
+			for _, tag := range tags {
+				if !baseline[tag] {
+					novel[leaf]++
+					break
+				}
+			}
+		}
+		if novel[leaf] > 0 || leaf.exceedsBaseline(candidates) {
+			leaves = append(leaves, leaf)
+		}
+	}
+	sort.Slice(leaves, func(i, j int) bool {
+		if novel[leaves[i]] != novel[leaves[j]] {
+			return novel[leaves[i]] > novel[leaves[j]]
+		}
+		return leaves[i].ID < leaves[j].ID
+	})
+	result := make([]CellStats, 0, len(leaves))
+	for _, leaf := range leaves {
+		result = append(result, leaf.cellStats())
+	}
+	return result
+}
+
+// exceedsBaseline reports whether any baseline tag is counted among the candidates more than
+// the anomaly ratio times its count among the leaf points.
+func (tree *ConvTree) exceedsBaseline(candidates []Point) bool {
+	if tree.config == nil || tree.config.anomalyRatio <= 0 {
+		return false
+	}
+	stored, _ := countTags(tree.Points)
+	received, _ := countTags(candidates)
+	for _, tag := range tree.BaselineTags {
+		if received[tag] > tree.config.anomalyRatio*stored[tag] {
+			return true
+		}
+	}
+	return false
+}
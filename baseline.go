@@ -0,0 +1,118 @@
+package convtree
+
+import (
+	"math"
+	"sort"
+)
+
+// getBaseline returns the tags that are frequent among the node points. Every tag is counted
+// once per point, and points whose Content is not []string are skipped.
+func (tree *ConvTree) getBaseline() []string {
+	return filterTags(tree.tagCounts())
+}
+
+func (tree *ConvTree) tagCounts() map[string]float64 {
+	counts, nonTagged := countTags(tree.Points)
+	for i := 0; i < nonTagged; i++ {
+		tree.config.report(DiagBaselineNonTagData)
+	}
+	return counts
+}
+
+// TagCounts returns the number of points carrying every tag, keyed by leaf ID. Tags are counted
+// the same way as for the baseline, so leaves without tagged points get an empty map.
+func (tree *ConvTree) TagCounts() map[string]map[string]int {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := map[string]map[string]int{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		counts, _ := countTags(leaf.Points)
+		leafCounts := make(map[string]int, len(counts))
+		for tag, count := range counts {
+			leafCounts[tag] = int(count)
+		}
+		result[leaf.ID] = leafCounts
+	})
+	return result
+}
+
+// TagEntropy returns the Shannon entropy in bits of the normalized tag counts of every leaf,
+// keyed by leaf ID. It is 0 when a single tag dominates and grows for a uniform mix of tags.
+// Leaves without tagged points are omitted.
+func (tree *ConvTree) TagEntropy() map[string]float64 {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := map[string]float64{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		counts, _ := countTags(leaf.Points)
+		if len(counts) == 0 {
+			return
+		}
+		total := 0.0
+		for _, count := range counts {
+			total += count
+		}
+		entropy := 0.0
+		for _, count := range counts {
+			p := count / total
+			entropy -= p * math.Log2(p)
+		}
+		result[leaf.ID] = entropy
+	})
+	return result
+}
+
+// countTags counts every tag once per point. It also returns the number of skipped points that
+// have non-nil Content other than []string.
+func countTags(points []Point) (map[string]float64, int) {
+	counts := map[string]float64{}
+	nonTagged := 0
+	for _, point := range points {
+		tags, ok := pointTags(point)
+		if !ok {
+			if point.Content != nil {
+				nonTagged++
+			}
+			continue
+		}
+		seen := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			if !seen[tag] {
+				seen[tag] = true
+				counts[tag]++
+			}
+		}
+	}
+	return counts, nonTagged
+}
+
+// filterTags keeps the tags whose count is at least one standard deviation above the mean
+// count and sorts them by count in descending order.
+func filterTags(counts map[string]float64) []string {
+	result := []string{}
+	if len(counts) == 0 {
+		return result
+	}
+	values := make([]float64, 0, len(counts))
+	for _, count := range counts {
+		values = append(values, count)
+	}
+	average := mean(values)
+	variance := 0.0
+	for _, value := range values {
+		variance += (value - average) * (value - average)
+	}
+	threshold := average + math.Sqrt(variance/float64(len(values)))
+	for tag, count := range counts {
+		if count >= threshold {
+			result = append(result, tag)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if counts[result[i]] != counts[result[j]] {
+			return counts[result[i]] > counts[result[j]]
+		}
+		return result[i] < result[j]
+	})
+	return result
+}
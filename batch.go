@@ -0,0 +1,54 @@
+package convtree
+
+import "fmt"
+
+// InsertBatch routes all points to their leaves first and then runs a single split pass over
+// every leaf, which is much faster than inserting the points one by one with splitting allowed.
+// Points outside the tree bounds are handled as in NewConvTree. It returns the structure change
+// caused by the splits.
+func (tree *ConvTree) InsertBatch(points []Point) (StructureChange, error) {
+	tree.config.lock()
+	defer tree.config.unlock()
+	points, err := tree.validatePoints(points)
+	if err != nil {
+		return StructureChange{}, err
+	}
+	tree.config.recordChanges()
+	tree.insertBatch(points)
+	replay := append([]Point{}, points...)
+	tree.config.recordRoutedMutation(tree, func(node *ConvTree) error {
+		node.insertBatch(replay)
+		return nil
+	})
+	return tree.config.finishChanges(), nil
+}
+
+func (tree *ConvTree) insertBatch(points []Point) {
+	for _, point := range points {
+		tree.insert(point, false)
+	}
+	tree.splitLeaves()
+}
+
+// validatePoints returns the points lying inside the node bounds. In strict mode the first point
+// outside the bounds is returned as an error; otherwise such points are dropped and counted
+// as DiagPointOutOfBounds.
+func (tree *ConvTree) validatePoints(points []Point) ([]Point, error) {
+	for i, point := range points {
+		if tree.contains(point.X, point.Y) {
+			continue
+		}
+		if tree.config != nil && tree.config.strict {
+			return nil, fmt.Errorf("%w: point %d (%v, %v)", ErrOutOfBounds, i, point.X, point.Y)
+		}
+		valid := append([]Point{}, points[:i]...)
+		for _, point := range points[i:] {
+			if tree.contains(point.X, point.Y) {
+				valid = append(valid, point)
+			}
+		}
+		tree.config.count(DiagPointOutOfBounds, int64(len(points)-len(valid)))
+		return valid, nil
+	}
+	return points, nil
+}
@@ -0,0 +1,111 @@
+package convtree
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestInsertBatchSplitsEveryLeaf(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 1}, Point{X: 1, Y: 0}, 0.001, 0.001, 100, 12, 2, 16, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := uniformPoints(rand.New(rand.NewSource(23)), 10000, 1)
+	if _, err := tree.InsertBatch(points); err != nil {
+		t.Fatal(err)
+	}
+	stats := tree.Summary()
+	if stats.Points != len(points) {
+		t.Fatalf("tree has %d points instead of %d", stats.Points, len(points))
+	}
+	for _, leaf := range tree.Leaves() {
+		if leaf.checkSplit() {
+			t.Fatalf("leaf %s with %d points was left unsplit", leaf.ID, len(leaf.Points))
+		}
+	}
+	checkLeafCount(t, &tree)
+}
+
+func TestOutOfBoundsPoints(t *testing.T) {
+	points := []Point{
+		{X: 10, Y: 10, Weight: 1},
+		{X: 0, Y: 100, Weight: 1},
+		{X: 101, Y: 50, Weight: 1},
+		{X: 50, Y: 50, Weight: 1},
+		{X: 50, Y: -0.1, Weight: 1},
+		{X: 100, Y: 0, Weight: 1},
+	}
+	original := append([]Point{}, points...)
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(tree.Points); got != 4 {
+		t.Fatalf("expected the 4 points inside the bounds to be kept, got %d", got)
+	}
+	if got := tree.Diagnostics()[DiagPointOutOfBounds]; got != 2 {
+		t.Fatalf("expected 2 dropped initial points, got %d", got)
+	}
+	if _, err := tree.InsertBatch(points); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.Summary().Points; got != 8 {
+		t.Fatalf("expected 8 points after the batch, got %d", got)
+	}
+	if got := tree.Diagnostics()[DiagPointOutOfBounds]; got != 4 {
+		t.Fatalf("expected 4 dropped points after the batch, got %d", got)
+	}
+	for i := range points {
+		if points[i] != original[i] {
+			t.Fatalf("point %d of the caller's slice was changed", i)
+		}
+	}
+
+	_, err = NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil, points, WithStrictMode())
+	if !errors.Is(err, ErrOutOfBounds) || !strings.Contains(err.Error(), "point 2 ") {
+		t.Fatalf("expected the third initial point to be reported, got %v", err)
+	}
+	strict, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil, points[:2],
+		WithStrictMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = strict.InsertBatch(points[3:])
+	if !errors.Is(err, ErrOutOfBounds) || !strings.Contains(err.Error(), "point 1 ") {
+		t.Fatalf("expected the second batch point to be reported, got %v", err)
+	}
+	if got := len(strict.Points); got != 2 {
+		t.Fatalf("a rejected batch inserted %d points", got-2)
+	}
+}
+
+func BenchmarkInsert100k(b *testing.B) {
+	points := uniformPoints(rand.New(rand.NewSource(1)), 100000, 1)
+	newTree := func(b *testing.B) ConvTree {
+		tree, err := NewConvTree(Point{X: 0, Y: 1}, Point{X: 1, Y: 0}, 0.001, 0.001, 100, 12, 2, 16, nil, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return tree
+	}
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree := newTree(b)
+			if _, err := tree.InsertBatch(points); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("single", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			tree := newTree(b)
+			for _, point := range points {
+				if err := tree.Insert(point, true); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
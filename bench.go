@@ -0,0 +1,176 @@
+package convtree
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+type BenchOptions struct {
+	Bounds         Bounds
+	SyntheticCount int
+	Seed           int64
+	Inserts        int
+	Queries        int
+	QuerySizes     []float64
+	Workers        int
+}
+
+type QueryBench struct {
+	Size          float64
+	Queries       int
+	PerSecond     float64
+	AvgReturned   float64
+	TotalDuration time.Duration
+}
+
+type BenchReport struct {
+	Points          int
+	Leaves          int
+	BuildTime       time.Duration
+	BuildAllocBytes uint64
+	ParallelBuild   time.Duration
+	HeapBytes       uint64
+	BulkPerSecond   float64
+	InsertP50       time.Duration
+	InsertP95       time.Duration
+	InsertP99       time.Duration
+	Queries         []QueryBench
+}
+
+// RunBenchmarks measures tree build time and memory, bulk and single insert performance and
+// query throughput for rectangles of opts.QuerySizes times the extent of the bounds.
+// Without points, opts.SyntheticCount clustered points are generated with opts.Seed. A non-zero
+// opts.Workers also measures the build time with WithParallelSplit(opts.Workers). It returns the
+// error of NewConvTree if the tree cannot be built with cfg.
+func RunBenchmarks(points []Point, cfg Config, opts BenchOptions) (BenchReport, error) {
+	bounds := opts.Bounds
+	if bounds.TopLeft.X >= bounds.BottomRight.X || bounds.TopLeft.Y <= bounds.BottomRight.Y {
+		bounds = Bounds{TopLeft: Point{X: 0, Y: 1}, BottomRight: Point{X: 1, Y: 0}}
+	}
+	if len(points) == 0 {
+		count := opts.SyntheticCount
+		if count <= 0 {
+			count = 10000
+		}
+		points = GeneratePoints(bounds, SyntheticConfig{Count: count, Clusters: 8, Seed: opts.Seed})
+	}
+	inserts := opts.Inserts
+	if inserts <= 0 {
+		inserts = 1000
+	}
+	queries := opts.Queries
+	if queries <= 0 {
+		queries = 1000
+	}
+	querySizes := opts.QuerySizes
+	if len(querySizes) == 0 {
+		querySizes = []float64{0.01, 0.1, 0.5}
+	}
+	report := BenchReport{Points: len(points)}
+	newTree := func(initPoints []Point, opts ...Option) (ConvTree, error) {
+		return NewConvTree(bounds.TopLeft, bounds.BottomRight, cfg.MinXLength, cfg.MinYLength, cfg.MaxPoints,
+			cfg.MaxDepth, cfg.ConvNum, cfg.GridSize, cfg.Kernel, initPoints, opts...)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	tree, err := newTree(append([]Point{}, points...))
+	if err != nil {
+		return BenchReport{}, err
+	}
+	report.BuildTime = time.Since(start)
+	runtime.ReadMemStats(&after)
+	report.BuildAllocBytes = after.TotalAlloc - before.TotalAlloc
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	if after.HeapAlloc > before.HeapAlloc {
+		report.HeapBytes = after.HeapAlloc - before.HeapAlloc
+	}
+	_, report.Leaves = tree.NodeCount()
+
+	if opts.Workers != 0 {
+		start = time.Now()
+		if _, err := newTree(append([]Point{}, points...), WithParallelSplit(opts.Workers)); err != nil {
+			return BenchReport{}, err
+		}
+		report.ParallelBuild = time.Since(start)
+	}
+
+	bulk, err := newTree(nil)
+	if err != nil {
+		return BenchReport{}, err
+	}
+	start = time.Now()
+	if _, err := bulk.InsertBatch(points); err != nil {
+		return BenchReport{}, err
+	}
+	if elapsed := time.Since(start); elapsed > 0 {
+		report.BulkPerSecond = float64(len(points)) / elapsed.Seconds()
+	}
+
+	random := rand.New(rand.NewSource(opts.Seed))
+	width := bounds.BottomRight.X - bounds.TopLeft.X
+	height := bounds.TopLeft.Y - bounds.BottomRight.Y
+	latencies := make([]time.Duration, inserts)
+	for i := range latencies {
+		point := Point{
+			X:      bounds.TopLeft.X + random.Float64()*width,
+			Y:      bounds.BottomRight.Y + random.Float64()*height,
+			Weight: 1,
+		}
+		start = time.Now()
+		tree.Insert(point, true)
+		latencies[i] = time.Since(start)
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	report.InsertP50 = latencies[len(latencies)*50/100]
+	report.InsertP95 = latencies[len(latencies)*95/100]
+	report.InsertP99 = latencies[len(latencies)*99/100]
+
+	for _, size := range querySizes {
+		bench := QueryBench{Size: size, Queries: queries}
+		returned := 0
+		start = time.Now()
+		for i := 0; i < queries; i++ {
+			left := bounds.TopLeft.X + random.Float64()*width*(1-size)
+			bottom := bounds.BottomRight.Y + random.Float64()*height*(1-size)
+			returned += len(tree.QueryRange(Point{X: left, Y: bottom + size*height}, Point{X: left + size*width, Y: bottom}))
+		}
+		bench.TotalDuration = time.Since(start)
+		if bench.TotalDuration > 0 {
+			bench.PerSecond = float64(queries) / bench.TotalDuration.Seconds()
+		}
+		bench.AvgReturned = float64(returned) / float64(queries)
+		report.Queries = append(report.Queries, bench)
+	}
+	return report, nil
+}
+
+func (report BenchReport) String() string {
+	buf := &bytes.Buffer{}
+	writer := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(writer, "points\t%d\n", report.Points)
+	fmt.Fprintf(writer, "leaves\t%d\n", report.Leaves)
+	fmt.Fprintf(writer, "build time\t%s\n", report.BuildTime)
+	fmt.Fprintf(writer, "build allocations\t%d B\n", report.BuildAllocBytes)
+	if report.ParallelBuild > 0 {
+		fmt.Fprintf(writer, "parallel build time\t%s\n", report.ParallelBuild)
+	}
+	fmt.Fprintf(writer, "tree heap\t%d B\n", report.HeapBytes)
+	fmt.Fprintf(writer, "bulk insert\t%.0f points/s\n", report.BulkPerSecond)
+	fmt.Fprintf(writer, "insert p50/p95/p99\t%s / %s / %s\n", report.InsertP50, report.InsertP95, report.InsertP99)
+	for _, query := range report.Queries {
+		fmt.Fprintf(writer, "query %.3f\t%.0f queries/s, %.1f points per query\n", query.Size, query.PerSecond, query.AvgReturned)
+	}
+	writer.Flush()
+	return buf.String()
+}
@@ -0,0 +1,45 @@
+package convtree
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunBenchmarks(t *testing.T) {
+	cfg := Config{MinXLength: 0.01, MinYLength: 0.01, MaxPoints: 50, MaxDepth: 8, ConvNum: 1, GridSize: 8}
+	report, err := RunBenchmarks(nil, cfg, BenchOptions{SyntheticCount: 2000, Seed: 1, Inserts: 100, Queries: 50,
+		QuerySizes: []float64{0.1, 0.5}, Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Points != 2000 || report.Leaves < 2 {
+		t.Fatalf("unexpected tree size: %d points, %d leaves", report.Points, report.Leaves)
+	}
+	if report.BuildTime <= 0 || report.ParallelBuild <= 0 || report.BuildAllocBytes == 0 || report.BulkPerSecond <= 0 {
+		t.Fatalf("build measurements are missing: %+v", report)
+	}
+	if report.InsertP50 > report.InsertP95 || report.InsertP95 > report.InsertP99 || report.InsertP99 <= 0 {
+		t.Fatalf("insert percentiles are not ordered: %s %s %s", report.InsertP50, report.InsertP95, report.InsertP99)
+	}
+	if len(report.Queries) != 2 {
+		t.Fatalf("expected 2 query sizes, got %d", len(report.Queries))
+	}
+	for _, query := range report.Queries {
+		if query.Queries != 50 || query.PerSecond <= 0 || query.AvgReturned <= 0 {
+			t.Fatalf("query measurements are missing: %+v", query)
+		}
+	}
+	if report.Queries[1].AvgReturned <= report.Queries[0].AvgReturned {
+		t.Fatal("larger queries return fewer points")
+	}
+	if text := report.String(); !strings.Contains(text, "insert p50/p95/p99") {
+		t.Fatalf("unexpected report:\n%s", text)
+	}
+}
+
+func TestRunBenchmarksInvalidConfig(t *testing.T) {
+	cfg := Config{MinXLength: 0.01, MinYLength: 0.01, MaxPoints: 50, MaxDepth: 8, ConvNum: -1, GridSize: 8}
+	if _, err := RunBenchmarks(nil, cfg, BenchOptions{SyntheticCount: 100}); err == nil {
+		t.Fatal("expected an error for a negative number of convolutions")
+	}
+}
@@ -0,0 +1,58 @@
+package convtree
+
+// BoundaryLeaf is a leaf touching at least one edge of the root.
+type BoundaryLeaf struct {
+	ID     string
+	Top    bool
+	Right  bool
+	Bottom bool
+	Left   bool
+	Weight int
+}
+
+// BoundaryStats describes the leaves touching the root edges, where zero padding of the
+// convolution and clamped split positions affect the partition the most.
+type BoundaryStats struct {
+	Leaves         []BoundaryLeaf
+	Weight         int
+	TotalWeight    int
+	WeightFraction float64
+}
+
+// BoundaryReport lists the leaves touching the edges of the node the method is called on, in the
+// same order as Leaves, together with the share of the total weight they hold.
+func (tree *ConvTree) BoundaryReport() BoundaryStats {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	return tree.boundaryReport()
+}
+
+func (tree *ConvTree) boundaryReport() BoundaryStats {
+	stats := BoundaryStats{Leaves: []BoundaryLeaf{}}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		weight := leaf.totalWeight()
+		stats.TotalWeight += weight
+		edges := tree.boundaryEdges(leaf)
+		if !edges.Top && !edges.Right && !edges.Bottom && !edges.Left {
+			return
+		}
+		edges.ID = leaf.ID
+		edges.Weight = weight
+		stats.Leaves = append(stats.Leaves, edges)
+		stats.Weight += weight
+	})
+	if stats.TotalWeight > 0 {
+		stats.WeightFraction = float64(stats.Weight) / float64(stats.TotalWeight)
+	}
+	return stats
+}
+
+// boundaryEdges reports which edges of the node the leaf touches.
+func (tree *ConvTree) boundaryEdges(leaf *ConvTree) BoundaryLeaf {
+	return BoundaryLeaf{
+		Top:    leaf.TopLeft.Y == tree.TopLeft.Y,
+		Right:  leaf.BottomRight.X == tree.BottomRight.X,
+		Bottom: leaf.BottomRight.Y == tree.BottomRight.Y,
+		Left:   leaf.TopLeft.X == tree.TopLeft.X,
+	}
+}
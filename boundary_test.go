@@ -0,0 +1,64 @@
+package convtree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBoundaryReport(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.divide(50, 50)
+	tree.ChildTopRight.divide(75, 75)
+	for _, point := range []Point{
+		{X: 25, Y: 75, Weight: 1},
+		{X: 90, Y: 90, Weight: 2},
+		{X: 60, Y: 60, Weight: 4},
+		{X: 75, Y: 25, Weight: 3},
+	} {
+		if err := tree.Insert(point, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	quarter := tree.ChildTopRight
+	want := BoundaryStats{
+		Leaves: []BoundaryLeaf{
+			{ID: tree.ChildTopLeft.ID, Top: true, Left: true, Weight: 1},
+			{ID: quarter.ChildTopLeft.ID, Top: true},
+			{ID: quarter.ChildTopRight.ID, Top: true, Right: true, Weight: 2},
+			{ID: quarter.ChildBottomRight.ID, Right: true},
+			{ID: tree.ChildBottomLeft.ID, Bottom: true, Left: true},
+			{ID: tree.ChildBottomRight.ID, Right: true, Bottom: true, Weight: 3},
+		},
+		Weight:         6,
+		TotalWeight:    10,
+		WeightFraction: 0.6,
+	}
+	if got := tree.BoundaryReport(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if got := tree.Summary().Boundary; !reflect.DeepEqual(got, want) {
+		t.Fatalf("summary reports %+v instead of %+v", got, want)
+	}
+
+	// On a subtree the edges are those of the subtree, so every leaf of the quarter is reported.
+	got := quarter.BoundaryReport()
+	if len(got.Leaves) != 4 || got.Weight != 6 || got.TotalWeight != 6 || got.WeightFraction != 1 {
+		t.Fatalf("unexpected subtree report %+v", got)
+	}
+	if inner := got.Leaves[2]; inner.ID != quarter.ChildBottomLeft.ID || !inner.Left || !inner.Bottom ||
+		inner.Top || inner.Right {
+		t.Fatalf("unexpected edges %+v of the bottom left leaf of the quarter", inner)
+	}
+
+	empty, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := empty.BoundaryReport()
+	if len(report.Leaves) != 1 || report.WeightFraction != 0 || !report.Leaves[0].Top || !report.Leaves[0].Bottom {
+		t.Fatalf("unexpected report %+v for a single empty leaf", report)
+	}
+}
@@ -0,0 +1,131 @@
+package convtree
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// CellDef is a minimal language-neutral description of a leaf cell. Path lists the child indices
+// from the root to the leaf: 0 - top left, 1 - top right, 2 - bottom left, 3 - bottom right.
+// Bounds holds left X, top Y, right X and bottom Y.
+type CellDef struct {
+	ID     string     `json:"id"`
+	Path   string     `json:"path"`
+	Depth  int        `json:"depth"`
+	Bounds [4]float64 `json:"bounds"`
+}
+
+// ExportCells returns the definitions of all leaves in depth-first order.
+func (tree *ConvTree) ExportCells() []CellDef {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := []CellDef{}
+	tree.exportCells("", 0, &result)
+	return result
+}
+
+func (tree *ConvTree) exportCells(path string, depth int, result *[]CellDef) {
+	if tree.IsLeaf {
+		*result = append(*result, CellDef{
+			ID:     tree.ID,
+			Path:   path,
+			Depth:  depth,
+			Bounds: [4]float64{tree.TopLeft.X, tree.TopLeft.Y, tree.BottomRight.X, tree.BottomRight.Y},
+		})
+		return
+	}
+	tree.ChildTopLeft.exportCells(path+"0", depth+1, result)
+	tree.ChildTopRight.exportCells(path+"1", depth+1, result)
+	tree.ChildBottomLeft.exportCells(path+"2", depth+1, result)
+	tree.ChildBottomRight.exportCells(path+"3", depth+1, result)
+}
+
+// ImportCells reconstructs a frozen tree from cell definitions. The cells must tile the root
+// without gaps or overlaps. The resulting tree routes points via Insert and FindLeaf but never splits.
+func ImportCells(defs []CellDef) (*ConvTree, error) {
+	if len(defs) == 0 {
+		return nil, errors.New("no cells to import")
+	}
+	sorted := make([]CellDef, len(defs))
+	copy(sorted, defs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+	config := newTreeConfig(nil)
+	config.frozen = true
+	root := &ConvTree{IsLeaf: true, config: config}
+	for i, def := range sorted {
+		if i > 0 && sorted[i-1].Path == def.Path {
+			return nil, fmt.Errorf("cells %s and %s have the same path %q", sorted[i-1].ID, def.ID, def.Path)
+		}
+		if def.Depth != len(def.Path) {
+			return nil, fmt.Errorf("cell %s has depth %d but path of length %d", def.ID, def.Depth, len(def.Path))
+		}
+		node := root
+		for j, step := range def.Path {
+			if step < '0' || step > '3' {
+				return nil, fmt.Errorf("cell %s has invalid path %q", def.ID, def.Path)
+			}
+			if node.IsLeaf {
+				if node.Points != nil {
+					return nil, fmt.Errorf("cell %s is nested in another cell", def.ID)
+				}
+				node.IsLeaf = false
+				node.ChildTopLeft = &ConvTree{IsLeaf: true, Depth: j + 1, config: config}
+				node.ChildTopRight = &ConvTree{IsLeaf: true, Depth: j + 1, config: config}
+				node.ChildBottomLeft = &ConvTree{IsLeaf: true, Depth: j + 1, config: config}
+				node.ChildBottomRight = &ConvTree{IsLeaf: true, Depth: j + 1, config: config}
+			}
+			node = []*ConvTree{node.ChildTopLeft, node.ChildTopRight, node.ChildBottomLeft, node.ChildBottomRight}[step-'0']
+		}
+		if !node.IsLeaf || node.Points != nil {
+			return nil, fmt.Errorf("cell %s overlaps other cells", def.ID)
+		}
+		node.ID = def.ID
+		node.Depth = def.Depth
+		node.TopLeft = Point{X: def.Bounds[0], Y: def.Bounds[1]}
+		node.BottomRight = Point{X: def.Bounds[2], Y: def.Bounds[3]}
+		node.Points = []Point{}
+	}
+	if err := root.resolveImportedBounds(); err != nil {
+		return nil, err
+	}
+	root.resetLeafCount()
+	return root, nil
+}
+
+// resolveImportedBounds derives the bounds of internal nodes from their children and checks
+// that the four children of every node share a single split point.
+func (tree *ConvTree) resolveImportedBounds() error {
+	if tree.IsLeaf {
+		if tree.Points == nil {
+			return errors.New("cells leave a gap in the partition")
+		}
+		if tree.TopLeft.X >= tree.BottomRight.X || tree.TopLeft.Y <= tree.BottomRight.Y {
+			return fmt.Errorf("cell %s has empty bounds", tree.ID)
+		}
+		return nil
+	}
+	children := []*ConvTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight}
+	for _, child := range children {
+		if err := child.resolveImportedBounds(); err != nil {
+			return err
+		}
+	}
+	topLeft, topRight, bottomLeft, bottomRight := children[0], children[1], children[2], children[3]
+	split := topLeft.BottomRight
+	if topRight.TopLeft.X != split.X || topRight.BottomRight.Y != split.Y || topRight.TopLeft.Y != topLeft.TopLeft.Y ||
+		bottomLeft.TopLeft.Y != split.Y || bottomLeft.BottomRight.X != split.X || bottomLeft.TopLeft.X != topLeft.TopLeft.X ||
+		bottomRight.TopLeft != split || bottomRight.BottomRight.X != topRight.BottomRight.X ||
+		bottomRight.BottomRight.Y != bottomLeft.BottomRight.Y {
+		return fmt.Errorf("cells under %s leave a gap or overlap", topLeft.ID)
+	}
+	if tree.ID == "" {
+		tree.ID = tree.config.newID()
+	}
+	tree.TopLeft = topLeft.TopLeft
+	tree.BottomRight = bottomRight.BottomRight
+	tree.Points = nil
+	return nil
+}
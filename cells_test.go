@@ -0,0 +1,88 @@
+package convtree
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestCellsRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(16))
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil,
+		uniformPoints(r, 1500, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs := tree.ExportCells()
+	data, err := json.Marshal(defs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := []CellDef{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := ImportCells(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(imported.ExportCells(), defs) {
+		t.Fatal("imported tree exports different cells")
+	}
+	checkTiling(t, imported)
+	leaves := imported.Summary().Leaves
+	for _, point := range uniformPoints(r, 1000, 100) {
+		expected, _ := tree.FindLeaf(point.X, point.Y)
+		actual, ok := imported.FindLeaf(point.X, point.Y)
+		if !ok || actual.ID != expected.ID {
+			t.Fatalf("point %v is routed to cell %s instead of %s", point, actual.ID, expected.ID)
+		}
+		if err := imported.Insert(point, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := imported.Summary(); got.Leaves != leaves || got.Points != 1000 {
+		t.Fatalf("imported tree has %d leaves and %d points after inserting", got.Leaves, got.Points)
+	}
+}
+
+func TestImportCellsRejectsInvalidPartitions(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(17)), 500, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs := tree.ExportCells()
+	tests := map[string]func(defs []CellDef) []CellDef{
+		"missing cell": func(defs []CellDef) []CellDef {
+			return defs[1:]
+		},
+		"shifted bounds": func(defs []CellDef) []CellDef {
+			defs[0].Bounds[2] -= 0.5
+			return defs
+		},
+		"duplicate path": func(defs []CellDef) []CellDef {
+			return append(defs, defs[0])
+		},
+		"nested cell": func(defs []CellDef) []CellDef {
+			nested := defs[0]
+			nested.Path += "0"
+			nested.Depth++
+			return append(defs, nested)
+		},
+		"invalid path": func(defs []CellDef) []CellDef {
+			defs[0].Path = defs[0].Path[:len(defs[0].Path)-1] + "4"
+			return defs
+		},
+		"no cells": func(defs []CellDef) []CellDef {
+			return nil
+		},
+	}
+	for name, modify := range tests {
+		input := modify(append([]CellDef{}, defs...))
+		if _, err := ImportCells(input); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package convtree
+
+// Churn returns the number of points inserted into and removed from the node with the given ID
+// since the last checkpoint. Nodes that were split keep the counters they had at the moment
+// of the split, while their children start from zero.
+func (tree *ConvTree) Churn(leafID string) (inserts, removes int64) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	tree.walkNodes(func(node *ConvTree) bool {
+		if node.ID == leafID {
+			inserts, removes = node.InsertCount, node.RemoveCount
+			return false
+		}
+		return true
+	})
+	return inserts, removes
+}
+
+// Checkpoint resets insert and remove counters of every node.
+func (tree *ConvTree) Checkpoint() {
+	tree.config.lock()
+	defer tree.config.unlock()
+	tree.resetChurn()
+	tree.config.recordMutation(tree, func(node *ConvTree) error {
+		node.resetChurn()
+		return nil
+	})
+}
+
+func (tree *ConvTree) resetChurn() {
+	tree.walkNodes(func(node *ConvTree) bool {
+		node.InsertCount = 0
+		node.RemoveCount = 0
+		return true
+	})
+}
@@ -0,0 +1,115 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// steadyTree returns a tree that went through rounds of inserting a point and removing the
+// oldest one after a checkpoint, so that its weight did not change.
+func steadyTree(t *testing.T, rounds int) *ConvTree {
+	t.Helper()
+	r := rand.New(rand.NewSource(21))
+	points := uniformPoints(r, 500, 100)
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Checkpoint()
+	queue := append([]Point{}, points...)
+	for i := 0; i < rounds; i++ {
+		point := Point{X: r.Float64() * 100, Y: r.Float64() * 100, Weight: 1}
+		if err := tree.Insert(point, false); err != nil {
+			t.Fatal(err)
+		}
+		queue = append(queue, point)
+		if !tree.Remove(queue[0], 0) {
+			t.Fatalf("point %v was not removed", queue[0])
+		}
+		queue = queue[1:]
+	}
+	return &tree
+}
+
+func TestChurnSteadyState(t *testing.T) {
+	const rounds = 300
+	tree := steadyTree(t, rounds)
+	stats := tree.Summary()
+	if stats.TotalWeight != 500 || stats.Points != 500 {
+		t.Fatalf("expected the weight to stay at 500, got %d in %d points", stats.TotalWeight, stats.Points)
+	}
+	if stats.Inserts != rounds || stats.Removes != rounds {
+		t.Fatalf("expected %d inserts and removes, got %d and %d", rounds, stats.Inserts, stats.Removes)
+	}
+	active := 0
+	for _, leaf := range tree.Leaves() {
+		inserts, removes := tree.Churn(leaf.ID)
+		if inserts != leaf.InsertCount || removes != leaf.RemoveCount {
+			t.Fatalf("Churn of leaf %s is %d/%d instead of %d/%d", leaf.ID, inserts, removes,
+				leaf.InsertCount, leaf.RemoveCount)
+		}
+		if inserts > 0 && removes > 0 {
+			active++
+		}
+	}
+	if active == 0 {
+		t.Fatal("expected leaves with both inserts and removes")
+	}
+	tree.Checkpoint()
+	if stats := tree.Summary(); stats.Inserts != 0 || stats.Removes != 0 {
+		t.Fatalf("Checkpoint left %d inserts and %d removes", stats.Inserts, stats.Removes)
+	}
+}
+
+func TestWriteCSVChurnColumns(t *testing.T) {
+	tree := steadyTree(t, 200)
+	buf := &bytes.Buffer{}
+	if err := tree.WriteCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.String()
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(records[0], ","); got != "x,y,weight,tags,leaf_inserts,leaf_removes,leaf_value_min,leaf_value_max,leaf_value_mean,leaf_last_insert_at" {
+		t.Fatalf("unexpected header %q", got)
+	}
+	if len(records) != 501 {
+		t.Fatalf("expected 500 point rows, got %d", len(records)-1)
+	}
+	for _, record := range records[1:] {
+		x, _ := strconv.ParseFloat(record[0], 64)
+		y, _ := strconv.ParseFloat(record[1], 64)
+		leaf := tree.findLeaf(x, y)
+		if record[4] != strconv.FormatInt(leaf.InsertCount, 10) || record[5] != strconv.FormatInt(leaf.RemoveCount, 10) {
+			t.Fatalf("row %v has churn %s/%s, but its leaf has %d/%d", record, record[4], record[5],
+				leaf.InsertCount, leaf.RemoveCount)
+		}
+	}
+
+	params := *tree.config.parameters()
+	inputs := map[string]struct {
+		data   string
+		points int
+	}{
+		"with churn":    {data: data, points: 500},
+		"without churn": {data: "1,2,3,a|b\n4,5,6,\n", points: 2},
+	}
+	for name, input := range inputs {
+		imported, err := NewConvTreeFromCSV(strings.NewReader(input.data), tree.TopLeft, tree.BottomRight, params)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := imported.Summary().Points; got != input.points {
+			t.Fatalf("%s: imported %d points instead of %d", name, got, input.points)
+		}
+	}
+	if _, err := NewConvTreeFromCSV(strings.NewReader("1,2,3,a,4\n"), tree.TopLeft, tree.BottomRight, params); err == nil {
+		t.Fatal("expected an error for a row with 5 fields")
+	}
+}
@@ -0,0 +1,75 @@
+package convtree
+
+import "sync/atomic"
+
+// Clone returns a deep copy of the node and its descendants that shares no mutable state with the
+// tree: nodes, points, traces, tags and histories are copied, and the clone has its own split
+// parameters, lock, pinned regions, diagnostics and product cache. Point Content and payloads are copied
+// shallowly, as the package treats them as read-only. The options of the tree apply to the clone.
+func (tree *ConvTree) Clone() *ConvTree {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	var config *treeConfig
+	if tree.config != nil {
+		config = tree.config.cloned()
+		config.rootDepth = tree.Depth
+	}
+	clone := tree.clone(config)
+	clone.resetLeafCount()
+	if config != nil {
+		clone.syncParams()
+	}
+	return clone
+}
+
+func (tree *ConvTree) clone(config *treeConfig) *ConvTree {
+	clone := *tree
+	clone.config = config
+	if tree.Points != nil {
+		clone.Points = append(make([]Point, 0, len(tree.Points)), tree.Points...)
+	}
+	if tree.Trace != nil {
+		trace := *tree.Trace
+		clone.Trace = &trace
+	}
+	if tree.BaselineTags != nil {
+		clone.BaselineTags = append(make([]string, 0, len(tree.BaselineTags)), tree.BaselineTags...)
+	}
+	if tree.History != nil {
+		clone.History = append(make([]WeightSample, 0, len(tree.History)), tree.History...)
+	}
+	if !tree.IsLeaf {
+		clone.ChildTopLeft = tree.ChildTopLeft.clone(config)
+		clone.ChildTopRight = tree.ChildTopRight.clone(config)
+		clone.ChildBottomLeft = tree.ChildBottomLeft.clone(config)
+		clone.ChildBottomRight = tree.ChildBottomRight.clone(config)
+	}
+	return &clone
+}
+
+// cloned returns a config with the options and the state of config, sharing none of its mutable
+// state. A rebuild in progress is not carried over.
+func (config *treeConfig) cloned() *treeConfig {
+	clone := config.detached()
+	if config.params.Kernel != nil {
+		clone.params.Kernel = make([][]float64, len(config.params.Kernel))
+		for i, row := range config.params.Kernel {
+			clone.params.Kernel[i] = append([]float64(nil), row...)
+		}
+		clone.useKernel(clone.params.Kernel)
+	}
+	clone.samples = config.samples
+	clone.generation = config.generation
+	clone.pins = append([]pinnedRegion(nil), config.pins...)
+	clone.diagnostics = newDiagnostics()
+	for name, counter := range config.diagnostics {
+		*clone.diagnostics[name] = atomic.LoadInt64(counter)
+	}
+	if config.workers != nil {
+		clone.workers = make(chan struct{}, cap(config.workers))
+	}
+	if config.products != nil {
+		WithProductCache(config.products.budget)(clone)
+	}
+	return clone
+}
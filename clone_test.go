@@ -0,0 +1,84 @@
+package convtree
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestCloneIsIndependent(t *testing.T) {
+	r := rand.New(rand.NewSource(30))
+	points := uniformPoints(r, 1000, 100)
+	for i := range points {
+		points[i].Content = []string{"tag"}
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := tree.Clone()
+	original := mustMarshal(t, &tree)
+	if !bytes.Equal(mustMarshal(t, clone), original) {
+		t.Fatal("clone differs from the tree")
+	}
+	compareStructure(t, &tree, clone)
+
+	// Mutations of the tree must not leak into the clone.
+	for _, point := range uniformPoints(r, 500, 100) {
+		if err := tree.Insert(point, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !tree.Remove(points[0], 0) {
+		t.Fatal("point was not removed")
+	}
+	tree.SetMaxPoints(20, true)
+	if _, err := tree.SetKernel([][]float64{{0, 1, 0}, {1, 4, 1}, {0, 1, 0}}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(mustMarshal(t, clone), original) {
+		t.Fatal("mutations of the tree changed the clone")
+	}
+	if clone.MaxPoints != 40 || clone.config.kernelGrid().At(1, 1) != 1 {
+		t.Fatal("parameters of the tree leaked into the clone")
+	}
+	checkLeafCount(t, clone)
+
+	// Mutations of the clone must not leak into the tree.
+	mutated := mustMarshal(t, &tree)
+	for _, point := range uniformPoints(r, 500, 100) {
+		if err := clone.Insert(point, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clone.RemoveFunc(func(point Point) bool {
+		return point.X < 50
+	})
+	clone.Checkpoint()
+	if !bytes.Equal(mustMarshal(t, &tree), mutated) {
+		t.Fatal("mutations of the clone changed the tree")
+	}
+	checkLeafCount(t, &tree)
+	checkLeafCount(t, clone)
+}
+
+func TestCloneOfSubtree(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(31)), 1000, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := tree.ChildTopLeft.Clone()
+	compareStructure(t, tree.ChildTopLeft, clone)
+	checkLeafCount(t, clone)
+	leaves := tree.Summary().Leaves
+	for i := 0; i < 200; i++ {
+		point := Point{X: clone.TopLeft.X + 1, Y: clone.TopLeft.Y - 1, Weight: 1}
+		if err := clone.Insert(point, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tree.Summary().Leaves != leaves {
+		t.Fatal("splits of the cloned subtree changed the tree")
+	}
+}
@@ -0,0 +1,230 @@
+package convtree
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"text/tabwriter"
+	"time"
+)
+
+// Bounds is a rectangle in ConvTree orientation, i.e. TopLeft.Y is larger than BottomRight.Y.
+type Bounds struct {
+	TopLeft     Point
+	BottomRight Point
+}
+
+type Config struct {
+	MinXLength float64
+	MinYLength float64
+	MaxPoints  int
+	MaxDepth   int
+	ConvNum    int
+	GridSize   int
+	Kernel     [][]float64
+}
+
+type QuadConfig struct {
+	MinXLength float64
+	MinYLength float64
+	MaxPoints  int
+	MaxDepth   int
+}
+
+type PartitionMetrics struct {
+	Leaves       int
+	EmptyLeaves  int
+	BuildTime    time.Duration
+	MinWeight    int
+	MaxWeight    int
+	MeanWeight   float64
+	WeightStdDev float64
+	WeightCV     float64
+	MaxToMean    float64
+	Purity       float64
+}
+
+type RegionMatch struct {
+	ConvLeafID  string
+	QuadLeafID  string
+	OverlapArea float64
+	Overlap     float64
+}
+
+type PartitionComparison struct {
+	Conv     PartitionMetrics
+	Quad     PartitionMetrics
+	Overlay  float64
+	Matches  []RegionMatch
+	Category bool
+}
+
+type partitionCell struct {
+	id          string
+	topLeft     Point
+	bottomRight Point
+	points      []Point
+}
+
+// ComparePartitions builds a ConvTree and a QuadTree over the same points and bounds and reports
+// how the two partitions differ. Every ConvTree leaf is matched with the QuadTree leaf it overlaps
+// most, and Overlay is the share of the root area covered by these best matches.
+// Purity is computed only when points carry a string Content, which is treated as the point category.
+func ComparePartitions(points []Point, bounds Bounds, convCfg Config, quadCfg QuadConfig) (PartitionComparison, error) {
+	result := PartitionComparison{}
+	convPoints := make([]Point, len(points))
+	copy(convPoints, points)
+	start := time.Now()
+	convTree, err := NewConvTree(bounds.TopLeft, bounds.BottomRight, convCfg.MinXLength, convCfg.MinYLength,
+		convCfg.MaxPoints, convCfg.MaxDepth, convCfg.ConvNum, convCfg.GridSize, convCfg.Kernel, convPoints)
+	if err != nil {
+		return result, err
+	}
+	convBuild := time.Since(start)
+
+	quadPoints := make([]Point, len(points))
+	copy(quadPoints, points)
+	quadTopLeft := Point{X: bounds.TopLeft.X, Y: bounds.BottomRight.Y}
+	quadBottomRight := Point{X: bounds.BottomRight.X, Y: bounds.TopLeft.Y}
+	start = time.Now()
+	quadTree, err := NewQuadTree(quadTopLeft, quadBottomRight, quadCfg.MinXLength, quadCfg.MinYLength,
+		quadCfg.MaxPoints, quadCfg.MaxDepth, quadPoints)
+	if err != nil {
+		return result, err
+	}
+	quadBuild := time.Since(start)
+
+	convCells := []partitionCell{}
+	convTree.walkLeaves(func(leaf *ConvTree) {
+		convCells = append(convCells, partitionCell{
+			id:          leaf.ID,
+			topLeft:     leaf.TopLeft,
+			bottomRight: leaf.BottomRight,
+			points:      leaf.Points,
+		})
+	})
+	quadCells := []partitionCell{}
+	quadTree.walkLeaves(func(leaf *QuadTree) {
+		quadCells = append(quadCells, partitionCell{
+			id:          leaf.ID,
+			topLeft:     Point{X: leaf.TopLeft.X, Y: leaf.BottomRight.Y},
+			bottomRight: Point{X: leaf.BottomRight.X, Y: leaf.TopLeft.Y},
+			points:      leaf.Points,
+		})
+	})
+
+	result.Conv, result.Category = partitionMetrics(convCells)
+	result.Conv.BuildTime = convBuild
+	result.Quad, _ = partitionMetrics(quadCells)
+	result.Quad.BuildTime = quadBuild
+
+	totalArea := cellArea(bounds.TopLeft, bounds.BottomRight)
+	matchedArea := 0.0
+	for _, convCell := range convCells {
+		match := RegionMatch{ConvLeafID: convCell.id}
+		for _, quadCell := range quadCells {
+			overlap := overlapArea(convCell, quadCell)
+			if overlap > match.OverlapArea {
+				match.QuadLeafID = quadCell.id
+				match.OverlapArea = overlap
+			}
+		}
+		area := cellArea(convCell.topLeft, convCell.bottomRight)
+		if area > 0 {
+			match.Overlap = match.OverlapArea / area
+		}
+		matchedArea += match.OverlapArea
+		result.Matches = append(result.Matches, match)
+	}
+	if totalArea > 0 {
+		result.Overlay = matchedArea / totalArea
+	}
+	return result, nil
+}
+
+func partitionMetrics(cells []partitionCell) (PartitionMetrics, bool) {
+	metrics := PartitionMetrics{
+		Leaves: len(cells),
+	}
+	if len(cells) == 0 {
+		return metrics, false
+	}
+	weights := make([]float64, len(cells))
+	categorized, dominant := 0, 0
+	for i, cell := range cells {
+		weight := 0
+		categories := map[string]int{}
+		for _, point := range cell.points {
+			weight += point.Weight
+			if category, ok := point.Content.(string); ok {
+				categories[category] += point.Weight
+				categorized += point.Weight
+			}
+		}
+		cellMax := 0
+		for _, categoryWeight := range categories {
+			if categoryWeight > cellMax {
+				cellMax = categoryWeight
+			}
+		}
+		dominant += cellMax
+		if weight == 0 {
+			metrics.EmptyLeaves++
+		}
+		if i == 0 || weight < metrics.MinWeight {
+			metrics.MinWeight = weight
+		}
+		if weight > metrics.MaxWeight {
+			metrics.MaxWeight = weight
+		}
+		weights[i] = float64(weight)
+	}
+	metrics.MeanWeight = mean(weights)
+	variance := 0.0
+	for _, weight := range weights {
+		variance += (weight - metrics.MeanWeight) * (weight - metrics.MeanWeight)
+	}
+	metrics.WeightStdDev = math.Sqrt(variance / float64(len(weights)))
+	if metrics.MeanWeight > 0 {
+		metrics.WeightCV = metrics.WeightStdDev / metrics.MeanWeight
+		metrics.MaxToMean = float64(metrics.MaxWeight) / metrics.MeanWeight
+	}
+	if categorized > 0 {
+		metrics.Purity = float64(dominant) / float64(categorized)
+	}
+	return metrics, categorized > 0
+}
+
+func cellArea(topLeft, bottomRight Point) float64 {
+	return (bottomRight.X - topLeft.X) * (topLeft.Y - bottomRight.Y)
+}
+
+func overlapArea(a, b partitionCell) float64 {
+	width := math.Min(a.bottomRight.X, b.bottomRight.X) - math.Max(a.topLeft.X, b.topLeft.X)
+	height := math.Min(a.topLeft.Y, b.topLeft.Y) - math.Max(a.bottomRight.Y, b.bottomRight.Y)
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+	return width * height
+}
+
+func (comparison PartitionComparison) String() string {
+	buf := &bytes.Buffer{}
+	writer := tabwriter.NewWriter(buf, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "metric\tconv\tquad")
+	fmt.Fprintf(writer, "leaves\t%d\t%d\n", comparison.Conv.Leaves, comparison.Quad.Leaves)
+	fmt.Fprintf(writer, "empty leaves\t%d\t%d\n", comparison.Conv.EmptyLeaves, comparison.Quad.EmptyLeaves)
+	fmt.Fprintf(writer, "build time\t%s\t%s\n", comparison.Conv.BuildTime, comparison.Quad.BuildTime)
+	fmt.Fprintf(writer, "min weight\t%d\t%d\n", comparison.Conv.MinWeight, comparison.Quad.MinWeight)
+	fmt.Fprintf(writer, "max weight\t%d\t%d\n", comparison.Conv.MaxWeight, comparison.Quad.MaxWeight)
+	fmt.Fprintf(writer, "mean weight\t%.3f\t%.3f\n", comparison.Conv.MeanWeight, comparison.Quad.MeanWeight)
+	fmt.Fprintf(writer, "weight std dev\t%.3f\t%.3f\n", comparison.Conv.WeightStdDev, comparison.Quad.WeightStdDev)
+	fmt.Fprintf(writer, "weight CV\t%.3f\t%.3f\n", comparison.Conv.WeightCV, comparison.Quad.WeightCV)
+	fmt.Fprintf(writer, "max/mean\t%.3f\t%.3f\n", comparison.Conv.MaxToMean, comparison.Quad.MaxToMean)
+	if comparison.Category {
+		fmt.Fprintf(writer, "purity\t%.3f\t%.3f\n", comparison.Conv.Purity, comparison.Quad.Purity)
+	}
+	fmt.Fprintf(writer, "overlay\t%.3f\t\n", comparison.Overlay)
+	writer.Flush()
+	return buf.String()
+}
@@ -0,0 +1,91 @@
+// Package compat provides the original conv-tree API on top of the current implementation,
+// so that existing callers can upgrade the module first and migrate call sites gradually.
+// Every function and method logs a deprecation notice the first time it is used.
+package compat
+
+import (
+	"log"
+	"sync"
+
+	convtree "github.com/visheratin/conv-tree"
+)
+
+type Point = convtree.Point
+
+// ConvTree wraps a tree and restores the original method signatures. Fields of the tree, such as
+// IsLeaf, Points and the child pointers, are accessible through the embedded pointer.
+type ConvTree struct {
+	*convtree.ConvTree
+}
+
+// QuadTree wraps a quad tree and restores the original method signatures.
+type QuadTree struct {
+	*convtree.QuadTree
+}
+
+var (
+	loggerMu sync.Mutex
+	logger   = func(message string) {
+		log.Println(message)
+	}
+	notified = map[string]bool{}
+)
+
+// SetLogger replaces log.Println as the destination of deprecation notices. A nil logger
+// disables the notices.
+func SetLogger(fn func(message string)) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	logger = fn
+}
+
+func deprecated(name, replacement string) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	if notified[name] || logger == nil {
+		return
+	}
+	notified[name] = true
+	logger("conv-tree: " + name + " is deprecated, use " + replacement)
+}
+
+// NewConvTree creates a tree with the original constructor signature.
+func NewConvTree(topLeft Point, bottomRight Point, minXLength float64, minYLength float64, maxPoints int, maxDepth int,
+	convNumber int, gridSize int, kernel [][]float64, initPoints []Point) (ConvTree, error) {
+	deprecated("compat.NewConvTree", "convtree.NewConvTree")
+	tree, err := convtree.NewConvTree(topLeft, bottomRight, minXLength, minYLength, maxPoints, maxDepth, convNumber,
+		gridSize, kernel, initPoints)
+	if err != nil {
+		return ConvTree{}, err
+	}
+	return ConvTree{&tree}, nil
+}
+
+// Insert adds the point and silently drops points outside the tree bounds.
+func (tree ConvTree) Insert(point Point, allowSplit bool) {
+	deprecated("compat.ConvTree.Insert", "convtree.ConvTree.Insert, which reports points outside the bounds")
+	tree.ConvTree.Insert(point, allowSplit)
+}
+
+// Check splits the tree if it exceeds its capacity.
+func (tree ConvTree) Check() {
+	deprecated("compat.ConvTree.Check", "convtree.ConvTree.Check, which reports the structure change")
+	tree.ConvTree.Check()
+}
+
+// NewQuadTree creates a quad tree with the original constructor signature.
+func NewQuadTree(topLeft Point, bottomRight Point, minXLength float64, minYLength float64, maxPoints int,
+	maxDepth int, initPoints []Point) (QuadTree, error) {
+	deprecated("compat.NewQuadTree", "convtree.NewQuadTree")
+	tree, err := convtree.NewQuadTree(topLeft, bottomRight, minXLength, minYLength, maxPoints, maxDepth, initPoints)
+	if err != nil {
+		return QuadTree{}, err
+	}
+	return QuadTree{&tree}, nil
+}
+
+// Insert adds the point and splits the leaf receiving it when needed.
+func (tree QuadTree) Insert(point Point) {
+	deprecated("compat.QuadTree.Insert", "convtree.QuadTree.Insert with allowSplit")
+	tree.QuadTree.Insert(point, true)
+}
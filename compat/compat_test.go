@@ -0,0 +1,171 @@
+package compat
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	convtree "github.com/visheratin/conv-tree"
+)
+
+// clusteredPoints returns n points of weight 1 around three centers inside [0, 100] x [0, 100].
+func clusteredPoints(r *rand.Rand, n int) []Point {
+	centers := []Point{{X: 20, Y: 30}, {X: 70, Y: 75}, {X: 60, Y: 15}}
+	points := make([]Point, n)
+	for i := range points {
+		center := centers[i%len(centers)]
+		points[i] = Point{
+			X:      math.Max(0, math.Min(100, center.X+r.NormFloat64()*8)),
+			Y:      math.Max(0, math.Min(100, center.Y+r.NormFloat64()*8)),
+			Weight: 1,
+		}
+	}
+	return points
+}
+
+// sameLeaves fails the test unless both trees have leaves with the same bounds and points.
+func sameLeaves(t *testing.T, expected, actual *convtree.ConvTree) {
+	t.Helper()
+	expectedLeaves, actualLeaves := expected.Leaves(), actual.Leaves()
+	if len(expectedLeaves) != len(actualLeaves) {
+		t.Fatalf("compat tree has %d leaves instead of %d", len(actualLeaves), len(expectedLeaves))
+	}
+	for i := range expectedLeaves {
+		e, a := expectedLeaves[i], actualLeaves[i]
+		if e.TopLeft != a.TopLeft || e.BottomRight != a.BottomRight || e.Depth != a.Depth {
+			t.Fatalf("leaf %d covers %v-%v instead of %v-%v", i, a.TopLeft, a.BottomRight, e.TopLeft, e.BottomRight)
+		}
+		if !reflect.DeepEqual(e.Points, a.Points) {
+			t.Fatalf("leaf %d has %d points instead of %d", i, len(a.Points), len(e.Points))
+		}
+	}
+}
+
+func TestConvTreeEquivalence(t *testing.T) {
+	SetLogger(nil)
+	r := rand.New(rand.NewSource(7))
+	initial := clusteredPoints(r, 1500)
+	topLeft, bottomRight := Point{X: 0, Y: 100}, Point{X: 100, Y: 0}
+	legacy, err := NewConvTree(topLeft, bottomRight, 1, 1, 30, 8, 2, 8, nil, initial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := convtree.NewConvTree(topLeft, bottomRight, 1, 1, 30, 8, 2, 8, nil, initial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameLeaves(t, &current, legacy.ConvTree)
+
+	for i, point := range clusteredPoints(r, 1500) {
+		allowSplit := i%2 == 0
+		legacy.Insert(point, allowSplit)
+		if err := current.Insert(point, allowSplit); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, point := range []Point{{X: -1, Y: 50}, {X: 50, Y: 101}} {
+		legacy.Insert(point, true)
+		if err := current.Insert(point, true); err != convtree.ErrOutOfBounds {
+			t.Fatalf("expected ErrOutOfBounds, got %v", err)
+		}
+	}
+	sameLeaves(t, &current, legacy.ConvTree)
+
+	for i := 0; i < 50; i++ {
+		x, y := r.Float64()*80, r.Float64()*80+20
+		regionTopLeft, regionBottomRight := Point{X: x, Y: y}, Point{X: x + 20, Y: y - 20}
+		expected := current.QueryRange(regionTopLeft, regionBottomRight)
+		actual := legacy.QueryRange(regionTopLeft, regionBottomRight)
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("query %v-%v returned %d points instead of %d", regionTopLeft, regionBottomRight,
+				len(actual), len(expected))
+		}
+	}
+}
+
+func TestConvTreeCheckEquivalence(t *testing.T) {
+	SetLogger(nil)
+	points := clusteredPoints(rand.New(rand.NewSource(8)), 400)
+	topLeft, bottomRight := Point{X: 0, Y: 100}, Point{X: 100, Y: 0}
+	legacy, err := NewConvTree(topLeft, bottomRight, 1, 1, 1000, 8, 2, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := convtree.NewConvTree(topLeft, bottomRight, 1, 1, 1000, 8, 2, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, point := range points {
+		legacy.Insert(point, false)
+		current.Insert(point, false)
+	}
+	legacy.SetMaxPoints(50, false)
+	current.SetMaxPoints(50, false)
+	legacy.Check()
+	current.Check()
+	if current.IsLeaf {
+		t.Fatal("expected Check to split the root")
+	}
+	sameLeaves(t, &current, legacy.ConvTree)
+}
+
+func TestQuadTreeEquivalence(t *testing.T) {
+	SetLogger(nil)
+	r := rand.New(rand.NewSource(9))
+	initial := clusteredPoints(r, 500)
+	topLeft, bottomRight := Point{X: 0, Y: 0}, Point{X: 100, Y: 100}
+	legacy, err := NewQuadTree(topLeft, bottomRight, 1, 1, 20, 8, initial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := convtree.NewQuadTree(topLeft, bottomRight, 1, 1, 20, 8, initial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, point := range clusteredPoints(r, 500) {
+		legacy.Insert(point)
+		current.Insert(point, true)
+	}
+	var compare func(expected, actual *convtree.QuadTree)
+	compare = func(expected, actual *convtree.QuadTree) {
+		if expected.IsLeaf != actual.IsLeaf || expected.TopLeft != actual.TopLeft ||
+			expected.BottomRight != actual.BottomRight || !reflect.DeepEqual(expected.Points, actual.Points) {
+			t.Fatalf("node %v-%v differs", actual.TopLeft, actual.BottomRight)
+		}
+		if expected.IsLeaf {
+			return
+		}
+		compare(expected.ChildTopLeft, actual.ChildTopLeft)
+		compare(expected.ChildTopRight, actual.ChildTopRight)
+		compare(expected.ChildBottomLeft, actual.ChildBottomLeft)
+		compare(expected.ChildBottomRight, actual.ChildBottomRight)
+	}
+	compare(&current, legacy.QuadTree)
+	if current.IsLeaf {
+		t.Fatal("expected a split quad tree")
+	}
+	region := [2]Point{{X: 10, Y: 10}, {X: 50, Y: 60}}
+	if !reflect.DeepEqual(current.QueryRange(region[0], region[1]), legacy.QueryRange(region[0], region[1])) {
+		t.Fatal("quad tree queries differ")
+	}
+}
+
+func TestDeprecationNoticeIsLoggedOnce(t *testing.T) {
+	loggerMu.Lock()
+	notified = map[string]bool{}
+	loggerMu.Unlock()
+	var messages []string
+	SetLogger(func(message string) {
+		messages = append(messages, message)
+	})
+	defer SetLogger(nil)
+	for i := 0; i < 3; i++ {
+		if _, err := NewQuadTree(Point{X: 0, Y: 0}, Point{X: 1, Y: 1}, 0.1, 0.1, 5, 2, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected a single deprecation notice, got %v", messages)
+	}
+}
@@ -2,11 +2,15 @@ package convtree
 
 import (
 	"errors"
-	"fmt"
-	"github.com/google/uuid"
 	"math"
+	"sync"
+	"time"
 )
 
+// ConvTree is a node of a tree. MaxPoints, MaxDepth, GridSize, ConvNum, Kernel, MinXLength and
+// MinYLength mirror the split parameters shared by all nodes of the tree and are read-only; they
+// are refreshed for the node and its descendants when the parameters are changed through the node
+// by SetMaxPoints, SetKernel or Rebuild.
 type ConvTree struct {
 	ID               string
 	IsLeaf           bool
@@ -25,10 +29,25 @@ type ConvTree struct {
 	ChildTopRight    *ConvTree
 	ChildBottomLeft  *ConvTree
 	ChildBottomRight *ConvTree
+	LastInsertAt     time.Time
+	InsertCount      int64
+	RemoveCount      int64
+	Trace            *SplitTrace
+	Values           ValueSummary
+	Pinned           bool
+	BaselineTags     []string
+	Activity         Activity
+	History          []WeightSample
+	Payload          interface{}
+	config           *treeConfig
 }
 
+// NewConvTree creates a tree with the given bounds and splits it until every leaf satisfies the
+// limits. The split grid of a node is convolved convNumber times; with convNumber set to 0 the
+// node is split at the densest cell of the plain weight grid. initPoints is copied, so the tree
+// never modifies the caller's slice.
 func NewConvTree(topLeft Point, bottomRight Point, minXLength float64, minYLength float64, maxPoints int, maxDepth int,
-	convNumber int, gridSize int, kernel [][]float64, initPoints []Point) (ConvTree, error) {
+	convNumber int, gridSize int, kernel [][]float64, initPoints []Point, opts ...Option) (ConvTree, error) {
 	if topLeft.X >= bottomRight.X {
 		err := errors.New("X of top left point is larger or equal to X of bottom right point")
 		return ConvTree{}, err
@@ -37,37 +56,72 @@ func NewConvTree(topLeft Point, bottomRight Point, minXLength float64, minYLengt
 		err := errors.New("Y of bottom right point is larger or equal to Y of top left point")
 		return ConvTree{}, err
 	}
-	id := uuid.New().String()
+	if convNumber < 0 {
+		err := errors.New("number of convolutions must not be negative")
+		return ConvTree{}, err
+	}
+	if gridSize < 1 {
+		err := errors.New("grid size must be positive")
+		return ConvTree{}, err
+	}
+	config := newTreeConfig(opts)
+	id := config.newID()
 	if !checkKernel(kernel) {
-		kernel = [][]float64{
-			[]float64{0.5, 0.5, 0.5},
-			[]float64{0.5, 1.0, 0.5},
-			[]float64{0.5, 0.5, 0.5},
-		}
+		kernel = defaultKernel()
+	}
+	config.params = Config{
+		MinXLength: minXLength,
+		MinYLength: minYLength,
+		MaxPoints:  maxPoints,
+		MaxDepth:   maxDepth,
+		ConvNum:    convNumber,
+		GridSize:   gridSize,
 	}
+	config.useKernel(kernel)
 	tree := ConvTree{
 		IsLeaf:      true,
 		ID:          id,
-		MaxPoints:   maxPoints,
-		GridSize:    gridSize,
-		ConvNum:     convNumber,
-		Kernel:      kernel,
-		MaxDepth:    maxDepth,
 		TopLeft:     topLeft,
 		BottomRight: bottomRight,
 		Points:      []Point{},
-		MinXLength:  minXLength,
-		MinYLength:  minYLength,
+		config:      config,
 	}
+	config.leaves = 1
 	if initPoints != nil {
-		tree.Points = initPoints
+		initPoints, err := tree.validatePoints(initPoints)
+		if err != nil {
+			return ConvTree{}, err
+		}
+		tree.Points = append([]Point{}, initPoints...)
+		if len(initPoints) > 0 {
+			tree.LastInsertAt = tree.config.now()
+			tree.InsertCount = int64(len(initPoints))
+			for _, point := range initPoints {
+				tree.recordActivity(point.Weight)
+			}
+		}
+	}
+	if config.targetLeaves > 0 && len(tree.Points) > 0 {
+		config.params.MaxPoints = suggestMaxPoints(tree.Points, config.targetLeaves, tree.TopLeft, tree.BottomRight,
+			config.params, opts)
 	}
+	tree.mirrorParams()
+	tree.recomputeValues()
+	tree.BaselineTags = tree.getBaseline()
 	if tree.checkSplit() {
 		tree.split()
 	}
 	return tree, nil
 }
 
+func defaultKernel() [][]float64 {
+	return [][]float64{
+		[]float64{0.5, 0.5, 0.5},
+		[]float64{0.5, 1.0, 0.5},
+		[]float64{0.5, 0.5, 0.5},
+	}
+}
+
 func checkKernel(kernel [][]float64) bool {
 	if kernel == nil || len(kernel) == 0 {
 		return false
@@ -76,7 +130,7 @@ func checkKernel(kernel [][]float64) bool {
 		return false
 	}
 	xSize, ySize := len(kernel[0]), len(kernel)
-	if xSize != ySize {
+	if xSize != ySize && (xSize%2 == 0 || ySize%2 == 0) {
 		return false
 	}
 	for _, row := range kernel {
@@ -87,165 +141,192 @@ func checkKernel(kernel [][]float64) bool {
 	return true
 }
 
+// split splits the leaf and then every new leaf that exceeds its capacity. Nodes waiting to be
+// split are kept on an explicit stack and visited in the same depth-first order as recursive
+// splitting would visit them, so the depth of the tree does not grow the call stack.
 func (tree *ConvTree) split() {
-	xSize, ySize := tree.GridSize, tree.GridSize
-	grid := make([][]float64, xSize)
-	xStep := (tree.BottomRight.X - tree.TopLeft.X) / float64(xSize)
-	yStep := (tree.TopLeft.Y - tree.BottomRight.Y) / float64(ySize)
-	for i := 0; i < xSize; i++ {
-		grid[i] = make([]float64, ySize)
-		for j := 0; j < ySize; j++ {
-			xLeft := tree.TopLeft.X + float64(i)*xStep
-			xRight := tree.TopLeft.X + float64(i+1)*xStep
-			yBottom := tree.BottomRight.Y + float64(j)*yStep
-			yTop := tree.BottomRight.Y + float64(j+1)*yStep
-			grid[i][j] = float64(tree.getNodeWeight(xLeft, xRight, yTop, yBottom))
+	var wg sync.WaitGroup
+	pending := []*ConvTree{tree}
+	for len(pending) > 0 {
+		node := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if !node.config.reserveLeaves(3) {
+			continue
 		}
-	}
-	convolved := normalizeGrid(grid)
-	for i := 0; i < tree.ConvNum; i++ {
-		tmpGrid, err := convolve(convolved, tree.Kernel, 1, 1)
-		if err != nil {
-			fmt.Println(err)
-			break
+		children := node.splitOnce()
+		for i := len(children) - 1; i >= 0; i-- {
+			if children[i].checkSplit() && !node.config.splitInWorker(children[i], &wg) {
+				pending = append(pending, children[i])
+			}
 		}
-		convolved = normalizeGrid(tmpGrid)
 	}
-	convolved = normalizeGrid(convolved)
+	wg.Wait()
+}
+
+// splitOnce divides the leaf at the computed split position and returns the new children.
+func (tree *ConvTree) splitOnce() [4]*ConvTree {
+	trace := tree.splitPosition()
+	tree.Trace = trace
+	children := tree.newChildren(trace.X, trace.Y)
+	tree.attachChildren(children)
+	return children
+}
+
+// splitPosition computes where the node would be split without modifying it.
+func (tree *ConvTree) splitPosition() *SplitTrace {
+	params := tree.config.parameters()
+	// A failed convolution is counted by DiagConvolveError, and the split uses the grid convolved
+	// so far.
+	convolved, iterations, _ := tree.densityGrid()
+	xStep := (tree.BottomRight.X - tree.TopLeft.X) / float64(params.GridSize)
+	yStep := (tree.TopLeft.Y - tree.BottomRight.Y) / float64(params.GridSize)
 	xMax, yMax := getSplitPoint(convolved)
-	if xMax < 1 || xMax >= (len(convolved)-1) {
-		xMax = len(convolved) / 2
+	rows, columns := convolved.w, convolved.h
+	putGrid(convolved)
+	if xMax < 1 || xMax >= (rows-1) {
+		xMax = rows / 2
 	}
-	if yMax < 1 || yMax >= (len(convolved[0])-1) {
-		yMax = len(convolved[0]) / 2
+	if yMax < 1 || yMax >= (columns-1) {
+		yMax = columns / 2
 	}
 	xOffset := float64(xMax) * xStep
 	yOffset := float64(yMax) * yStep
 
 	xRight := tree.TopLeft.X + xOffset
-	if xRight-tree.TopLeft.X < tree.MinXLength {
-		xRight = tree.TopLeft.X + tree.MinXLength
+	if xRight-tree.TopLeft.X < params.MinXLength {
+		xRight = tree.TopLeft.X + params.MinXLength
 	}
-	if tree.BottomRight.X-xRight < tree.MinXLength {
-		xRight = tree.BottomRight.X - tree.MinXLength
+	if tree.BottomRight.X-xRight < params.MinXLength {
+		xRight = tree.BottomRight.X - params.MinXLength
 	}
 	yBottom := tree.BottomRight.Y + yOffset
-	if yBottom-tree.BottomRight.Y < tree.MinYLength {
-		yBottom = tree.BottomRight.Y + tree.MinYLength
-	}
-	if tree.TopLeft.Y-yBottom < tree.MinYLength {
-		yBottom = tree.TopLeft.Y - tree.MinYLength
-	}
-	id := uuid.New().String()
-	tree.ChildTopLeft = &ConvTree{
-		ID:      id,
-		TopLeft: tree.TopLeft,
-		BottomRight: Point{
-			X: xRight,
-			Y: yBottom,
-		},
-		MaxPoints:  tree.MaxPoints,
-		MaxDepth:   tree.MaxDepth,
-		Kernel:     tree.Kernel,
-		Depth:      tree.Depth + 1,
-		GridSize:   tree.GridSize,
-		ConvNum:    tree.ConvNum,
-		MinXLength: tree.MinXLength,
-		MinYLength: tree.MinYLength,
-		IsLeaf:     true,
-	}
-	tree.ChildTopLeft.Points = tree.filterSplitPoints(tree.ChildTopLeft.TopLeft, tree.ChildTopLeft.BottomRight)
-	if tree.ChildTopLeft.checkSplit() {
-		tree.ChildTopLeft.split()
-	}
-
-	id = uuid.New().String()
-	tree.ChildTopRight = &ConvTree{
-		ID: id,
-		TopLeft: Point{
-			X: xRight,
-			Y: tree.TopLeft.Y,
-		},
-		BottomRight: Point{
-			X: tree.BottomRight.X,
-			Y: yBottom,
-		},
-		MaxPoints:  tree.MaxPoints,
-		MaxDepth:   tree.MaxDepth,
-		Kernel:     tree.Kernel,
-		Depth:      tree.Depth + 1,
-		GridSize:   tree.GridSize,
-		ConvNum:    tree.ConvNum,
-		MinXLength: tree.MinXLength,
-		MinYLength: tree.MinYLength,
-		IsLeaf:     true,
-	}
-	tree.ChildTopRight.Points = tree.filterSplitPoints(tree.ChildTopRight.TopLeft, tree.ChildTopRight.BottomRight)
-	if tree.ChildTopRight.checkSplit() {
-		tree.ChildTopRight.split()
-	}
-
-	id = uuid.New().String()
-	tree.ChildBottomLeft = &ConvTree{
-		ID: id,
-		TopLeft: Point{
-			X: tree.TopLeft.X,
-			Y: yBottom,
-		},
-		BottomRight: Point{
-			X: xRight,
-			Y: tree.BottomRight.Y,
-		},
-		MaxPoints:  tree.MaxPoints,
-		MaxDepth:   tree.MaxDepth,
-		Kernel:     tree.Kernel,
-		Depth:      tree.Depth + 1,
-		GridSize:   tree.GridSize,
-		ConvNum:    tree.ConvNum,
-		MinXLength: tree.MinXLength,
-		MinYLength: tree.MinYLength,
-		IsLeaf:     true,
-	}
-	tree.ChildBottomLeft.Points = tree.filterSplitPoints(tree.ChildBottomLeft.TopLeft, tree.ChildBottomLeft.BottomRight)
-	if tree.ChildBottomLeft.checkSplit() {
-		tree.ChildBottomLeft.split()
-	}
-
-	id = uuid.New().String()
-	tree.ChildBottomRight = &ConvTree{
-		ID: id,
-		TopLeft: Point{
-			X: xRight,
-			Y: yBottom,
-		},
-		BottomRight: tree.BottomRight,
-		MaxPoints:   tree.MaxPoints,
-		MaxDepth:    tree.MaxDepth,
-		Kernel:      tree.Kernel,
-		Depth:       tree.Depth + 1,
-		GridSize:    tree.GridSize,
-		ConvNum:     tree.ConvNum,
-		MinXLength:  tree.MinXLength,
-		MinYLength:  tree.MinYLength,
-		IsLeaf:      true,
+	if yBottom-tree.BottomRight.Y < params.MinYLength {
+		yBottom = tree.BottomRight.Y + params.MinYLength
+	}
+	if tree.TopLeft.Y-yBottom < params.MinYLength {
+		yBottom = tree.TopLeft.Y - params.MinYLength
+	}
+	refined := false
+	if tree.config.refineSplits() {
+		xRight = tree.refineSplit(xRight, xStep, tree.TopLeft.X, tree.BottomRight.X, params.MinXLength, pointX)
+		yBottom = tree.refineSplit(yBottom, yStep, tree.BottomRight.Y, tree.TopLeft.Y, params.MinYLength, pointY)
+		refined = true
+	}
+	return &SplitTrace{
+		Transform:      tree.config.transformName(),
+		Refined:        refined,
+		ConvIterations: iterations,
+		GridX:          xMax,
+		GridY:          yMax,
+		X:              xRight,
+		Y:              yBottom,
 	}
-	tree.ChildBottomRight.Points = tree.filterSplitPoints(tree.ChildBottomRight.TopLeft, tree.ChildBottomRight.BottomRight)
-	if tree.ChildBottomRight.checkSplit() {
-		tree.ChildBottomRight.split()
+}
+
+// densityGrid bins the node points into a GridSize x GridSize grid, convolves it and returns the
+// normalized result together with the number of convolutions applied. Without convolutions the
+// weight grid is normalized once and returned as is. If a convolution fails, the grid convolved
+// so far is returned with the error.
+func (tree *ConvTree) densityGrid() (*grid, int, error) {
+	grid := tree.weightGrid()
+	tree.config.transformGrid(grid)
+	if !tree.convolves() {
+		return normalizeGrid(grid), 0, nil
+	}
+	convolved, iterations, err := tree.smoothGrid(normalizeGrid(grid))
+	return normalizeGrid(convolved), iterations, err
+}
+
+// convolves reports whether the split grid of the node is convolved at all.
+func (tree *ConvTree) convolves() bool {
+	if tree.config != nil && (tree.config.axisKernels != nil || tree.config.adaptiveConvs > 0) {
+		return true
 	}
+	return tree.config.parameters().ConvNum > 0
+}
+
+// divide turns the leaf into an internal node with four children separated by the vertical line
+// at xRight and the horizontal line at yBottom. Children are not split further.
+func (tree *ConvTree) divide(xRight, yBottom float64) {
+	tree.config.addLeaves(3)
+	tree.attachChildren(tree.newChildren(xRight, yBottom))
+}
 
+// newChildren creates the four children of the leaf without attaching them. The children are
+// allocated as a single block, and their points share a single backing array.
+func (tree *ConvTree) newChildren(xRight, yBottom float64) [4]*ConvTree {
+	bounds := [4][2]Point{
+		{tree.TopLeft, {X: xRight, Y: yBottom}},
+		{{X: xRight, Y: tree.TopLeft.Y}, {X: tree.BottomRight.X, Y: yBottom}},
+		{{X: tree.TopLeft.X, Y: yBottom}, {X: xRight, Y: tree.BottomRight.Y}},
+		{{X: xRight, Y: yBottom}, tree.BottomRight},
+	}
+	points := tree.splitPoints(bounds)
+	block := new([4]ConvTree)
+	children := [4]*ConvTree{}
+	for i := range children {
+		children[i] = &block[i]
+		tree.initChild(children[i], bounds[i][0], bounds[i][1], points[i])
+	}
+	tree.config.recordDivide(tree, children)
+	if tree.config != nil && tree.config.payloadSplit != nil {
+		payloads := tree.config.payloadSplit(tree.Payload, children)
+		for i, child := range children {
+			child.Payload = payloads[i]
+		}
+	}
+	return children
+}
+
+// attachChildren publishes fully built children and turns the leaf into an internal node.
+// Nodes become reachable from the tree only here, under the write lock, so readers holding
+// the read lock never observe an internal node with missing or partially built children.
+func (tree *ConvTree) attachChildren(children [4]*ConvTree) {
+	tree.ChildTopLeft = children[0]
+	tree.ChildTopRight = children[1]
+	tree.ChildBottomLeft = children[2]
+	tree.ChildBottomRight = children[3]
 	tree.IsLeaf = false
 	tree.Points = nil
 }
 
-func getSplitPoint(grid [][]float64) (int, int) {
+func (tree *ConvTree) newChild(topLeft, bottomRight Point, points []Point) *ConvTree {
+	child := &ConvTree{}
+	tree.initChild(child, topLeft, bottomRight, points)
+	return child
+}
+
+func (tree *ConvTree) initChild(child *ConvTree, topLeft, bottomRight Point, points []Point) {
+	id := tree.config.newID()
+	*child = ConvTree{
+		ID:          id,
+		TopLeft:     topLeft,
+		BottomRight: bottomRight,
+		Depth:       tree.Depth + 1,
+		IsLeaf:      true,
+		config:      tree.config,
+	}
+	child.mirrorParams()
+	child.Points = points
+	if len(child.Points) > 0 {
+		child.LastInsertAt = tree.LastInsertAt
+	}
+	if parentWeight := tree.totalWeight(); parentWeight > 0 {
+		child.Activity = tree.Activity.scaled(float64(child.totalWeight()) / float64(parentWeight))
+		child.History = scaleHistory(tree.History, float64(child.totalWeight())/float64(parentWeight))
+	}
+	child.recomputeValues()
+	child.BaselineTags = child.getBaseline()
+}
+
+func getSplitPoint(values *grid) (int, int) {
 	threshold := 0.8
 	maxX, maxY := 0, 0
 	maxValue := 0.0
-	for i := 0; i < len(grid); i++ {
-		for j := 0; j < len(grid[0]); j++ {
-			if grid[i][j] > maxValue {
-				maxValue = grid[i][j]
+	for i := 0; i < values.w; i++ {
+		for j := 0; j < values.h; j++ {
+			if values.At(i, j) > maxValue {
+				maxValue = values.At(i, j)
 				maxX, maxY = i, j
 			}
 		}
@@ -261,25 +342,25 @@ func getSplitPoint(grid [][]float64) (int, int) {
 		i := maxX - counter
 		if i >= 0 {
 			for j := maxY - counter; j <= maxY+counter; j++ {
-				if j >= 0 && j < len(grid[0]) {
-					if grid[i][j] > splitValue {
+				if j >= 0 && j < values.h {
+					if values.At(i, j) > splitValue {
 						itemFound = true
 						x = i
-						vals = append(vals, grid[i][j])
+						vals = append(vals, values.At(i, j))
 					}
 				}
 			}
 		}
 		i = maxX + counter
-		if i < len(grid) {
+		if i < values.w {
 			for j := maxY - counter; j <= maxY+counter; j++ {
-				if j >= 0 && j < len(grid[0]) {
-					if grid[i][j] > splitValue {
+				if j >= 0 && j < values.h {
+					if values.At(i, j) > splitValue {
 						itemFound = true
-						if math.Abs(float64(x-len(grid)/2)) > math.Abs(float64(i-len(grid)/2)) {
+						if math.Abs(float64(x-values.w/2)) > math.Abs(float64(i-values.w/2)) {
 							x = i
 						}
-						vals = append(vals, grid[i][j])
+						vals = append(vals, values.At(i, j))
 					}
 				}
 			}
@@ -287,28 +368,28 @@ func getSplitPoint(grid [][]float64) (int, int) {
 		i = maxY - counter
 		if i >= 0 {
 			for j := maxX - counter; j <= maxX+counter; j++ {
-				if j >= 0 && j < len(grid) {
-					if grid[j][i] > splitValue {
+				if j >= 0 && j < values.w {
+					if values.At(j, i) > splitValue {
 						itemFound = true
 						y = i
 						if j != maxX-counter && j != maxX+counter {
-							vals = append(vals, grid[j][i])
+							vals = append(vals, values.At(j, i))
 						}
 					}
 				}
 			}
 		}
 		i = maxY + counter
-		if i < len(grid[0]) {
+		if i < values.h {
 			for j := maxX - counter; j <= maxX+counter; j++ {
-				if j >= 0 && j < len(grid) {
-					if grid[j][i] > splitValue {
+				if j >= 0 && j < values.w {
+					if values.At(j, i) > splitValue {
 						itemFound = true
-						if math.Abs(float64(y-len(grid[0])/2)) > math.Abs(float64(i-len(grid[0])/2)) {
+						if math.Abs(float64(y-values.h/2)) > math.Abs(float64(i-values.h/2)) {
 							y = i
 						}
 						if j != maxX-counter && j != maxX+counter {
-							vals = append(vals, grid[j][i])
+							vals = append(vals, values.At(j, i))
 						}
 					}
 				}
@@ -347,164 +428,300 @@ func mean(in []float64) float64 {
 	return sum / float64(len(in))
 }
 
-func (tree *ConvTree) Insert(point Point, allowSplit bool) {
-	if !tree.IsLeaf {
-		if point.X >= tree.ChildTopLeft.TopLeft.X && point.X <= tree.ChildTopLeft.BottomRight.X &&
-			point.Y <= tree.ChildTopLeft.TopLeft.Y && point.Y >= tree.ChildTopLeft.BottomRight.Y {
-			tree.ChildTopLeft.Insert(point, allowSplit)
-			return
-		}
-		if point.X >= tree.ChildTopRight.TopLeft.X && point.X <= tree.ChildTopRight.BottomRight.X &&
-			point.Y <= tree.ChildTopRight.TopLeft.Y && point.Y >= tree.ChildTopRight.BottomRight.Y {
-			tree.ChildTopRight.Insert(point, allowSplit)
-			return
-		}
-		if point.X >= tree.ChildBottomLeft.TopLeft.X && point.X <= tree.ChildBottomLeft.BottomRight.X &&
-			point.Y <= tree.ChildBottomLeft.TopLeft.Y && point.Y >= tree.ChildBottomLeft.BottomRight.Y {
-			tree.ChildBottomLeft.Insert(point, allowSplit)
-			return
-		}
-		if point.X >= tree.ChildBottomRight.TopLeft.X && point.X <= tree.ChildBottomRight.BottomRight.X &&
-			point.Y <= tree.ChildBottomRight.TopLeft.Y && point.Y >= tree.ChildBottomRight.BottomRight.Y {
-			tree.ChildBottomRight.Insert(point, allowSplit)
-			return
-		}
-	} else {
-		tree.Points = append(tree.Points, point)
-		if allowSplit {
-			if tree.checkSplit() {
-				tree.split()
-			}
-		}
+// Insert adds the point to the leaf containing it and splits the leaf if allowSplit is true and
+// the leaf exceeds its capacity. Points on a split line are routed as in FindLeaf.
+// ErrOutOfBounds is returned when the point is outside the node bounds.
+func (tree *ConvTree) Insert(point Point, allowSplit bool) error {
+	tree.config.lock()
+	defer tree.config.unlock()
+	if !tree.contains(point.X, point.Y) {
+		tree.config.report(DiagInsertDropped)
+		return ErrOutOfBounds
+	}
+	tree.insert(point, allowSplit)
+	tree.config.recordRoutedMutation(tree, func(node *ConvTree) error {
+		node.insert(point, allowSplit)
+		return nil
+	})
+	return nil
+}
+
+// insert adds the point, which must lie inside the node bounds, to the leaf containing it.
+func (tree *ConvTree) insert(point Point, allowSplit bool) {
+	leaf := tree.findLeaf(point.X, point.Y)
+	leaf.Points = append(leaf.Points, point)
+	leaf.LastInsertAt = leaf.config.now()
+	leaf.InsertCount++
+	leaf.addValue(point)
+	leaf.recordActivity(point.Weight)
+	if allowSplit && leaf.checkSplit() {
+		leaf.split()
 	}
 }
 
-func (tree *ConvTree) Check() {
-	if tree.checkSplit() {
-		tree.split()
+// Check splits the node if it exceeds its capacity and returns the resulting structure change.
+func (tree *ConvTree) Check() StructureChange {
+	tree.config.lock()
+	defer tree.config.unlock()
+	if !tree.IsLeaf {
+		tree.config.report(DiagCheckInternalNode)
+		return StructureChange{}
 	}
+	if !tree.checkSplit() {
+		return StructureChange{}
+	}
+	tree.config.recordChanges()
+	tree.split()
+	tree.config.recordMutation(tree, func(node *ConvTree) error {
+		if node.IsLeaf && node.checkSplit() {
+			node.split()
+		}
+		return nil
+	})
+	return tree.config.finishChanges()
 }
 
+// Clear removes all points but keeps the structure of the tree, so later points are routed into
+// the existing cells. Use Reset to return to a single empty leaf.
 func (tree *ConvTree) Clear() {
+	tree.config.lock()
+	defer tree.config.unlock()
+	tree.clear()
+	tree.config.recordMutation(tree, func(node *ConvTree) error {
+		node.clear()
+		return nil
+	})
+}
+
+// Reset removes all points and children, turning the node into an empty leaf with the same ID,
+// bounds and parameters, as if it was just created. Pinned regions inside the node are dropped.
+func (tree *ConvTree) Reset() {
+	tree.config.lock()
+	defer tree.config.unlock()
+	tree.reset()
+	tree.config.recordMutation(tree, func(node *ConvTree) error {
+		node.reset()
+		return nil
+	})
+}
+
+func (tree *ConvTree) reset() {
+	tree.config.addLeaves(1 - tree.leafCount())
+	tree.replaceStructure(&ConvTree{IsLeaf: true, Points: []Point{}})
+	tree.Pinned = false
+	if tree.config == nil {
+		return
+	}
+	pins := tree.config.pins[:0]
+	for _, pin := range tree.config.pins {
+		if !tree.contains(pin.bounds.TopLeft.X, pin.bounds.TopLeft.Y) ||
+			!tree.contains(pin.bounds.BottomRight.X, pin.bounds.BottomRight.Y) {
+			pins = append(pins, pin)
+		}
+	}
+	tree.config.pins = pins
+}
+
+func (tree *ConvTree) clear() {
+	if tree.IsLeaf && len(tree.Points) == 0 {
+		tree.config.report(DiagClearEmptyLeaf)
+	}
+	tree.RemoveCount += int64(len(tree.Points))
 	tree.Points = nil
+	tree.Values = ValueSummary{}
 	if tree.ChildBottomLeft != nil {
-		tree.ChildBottomLeft.Clear()
+		tree.ChildBottomLeft.clear()
 	}
 	if tree.ChildBottomRight != nil {
-		tree.ChildBottomRight.Clear()
+		tree.ChildBottomRight.clear()
 	}
 	if tree.ChildTopLeft != nil {
-		tree.ChildTopLeft.Clear()
+		tree.ChildTopLeft.clear()
 	}
 	if tree.ChildTopRight != nil {
-		tree.ChildTopRight.Clear()
+		tree.ChildTopRight.clear()
 	}
 }
 
 func (tree ConvTree) checkSplit() bool {
-	cond1 := (tree.BottomRight.X-tree.TopLeft.X) > 2*tree.MinXLength && (tree.TopLeft.Y-tree.BottomRight.Y) > 2*tree.MinYLength
+	if tree.Pinned || (tree.config != nil && tree.config.frozen) {
+		return false
+	}
+	params := tree.config.parameters()
+	cond1 := (tree.BottomRight.X-tree.TopLeft.X) > 2*params.MinXLength && (tree.TopLeft.Y-tree.BottomRight.Y) > 2*params.MinYLength
 	totalWeight := 0
 	for _, point := range tree.Points {
 		totalWeight += point.Weight
 	}
-	cond2 := totalWeight > tree.MaxPoints && tree.Depth < tree.MaxDepth
+	cond2 := totalWeight > tree.maxPoints() && tree.Depth < params.MaxDepth
 	return cond1 && cond2
 }
 
-func (tree ConvTree) getNodeWeight(xLeft, xRight, yTop, yBottom float64) int {
-	total := 0
+// weightGrid sums the point weights over a GridSize x GridSize grid of closed cells in a single
+// pass over the points. A point lying on a line between cells is counted in all cells sharing it.
+func (tree *ConvTree) weightGrid() *grid {
+	size := tree.config.parameters().GridSize
+	xStep := (tree.BottomRight.X - tree.TopLeft.X) / float64(size)
+	yStep := (tree.TopLeft.Y - tree.BottomRight.Y) / float64(size)
+	weights := getGrid(size, size)
 	for _, point := range tree.Points {
-		if point.X >= xLeft && point.X <= xRight && point.Y >= yBottom && point.Y <= yTop {
-			total += point.Weight
+		xFirst, xLast := gridCells(point.X, tree.TopLeft.X, xStep, size)
+		yFirst, yLast := gridCells(point.Y, tree.BottomRight.Y, yStep, size)
+		for i := xFirst; i <= xLast; i++ {
+			for j := yFirst; j <= yLast; j++ {
+				weights.data[i*size+j] += float64(point.Weight)
+			}
+		}
+	}
+	return weights
+}
+
+// gridCells returns the range of cells from origin + i*step to origin + (i+1)*step, bounds
+// included, that contain the value. The range is empty when first > last.
+func gridCells(value, origin, step float64, size int) (first, last int) {
+	estimate := int(math.Floor((value - origin) / step))
+	first, last = size, -1
+	for i := estimate - 1; i <= estimate+1; i++ {
+		if i < 0 || i >= size {
+			continue
+		}
+		if value >= origin+float64(i)*step && value <= origin+float64(i+1)*step {
+			if i < first {
+				first = i
+			}
+			last = i
 		}
 	}
-	return total
+	return first, last
 }
 
-func (tree ConvTree) filterSplitPoints(topLeft, bottomRight Point) []Point {
-	result := []Point{}
+// splitPoints distributes the points of the node among the children with the given bounds. The
+// points are counted first, so every child slice is allocated once with its final size.
+func (tree *ConvTree) splitPoints(bounds [4][2]Point) [4][]Point {
+	counts := [4]int{}
 	for _, point := range tree.Points {
-		if point.X >= topLeft.X && point.X <= bottomRight.X && point.Y >= bottomRight.Y && point.Y <= topLeft.Y {
-			result = append(result, point)
+		for i, child := range bounds {
+			if tree.inSplitChild(point, child[0], child[1]) {
+				counts[i]++
+			}
+		}
+	}
+	// Capacities are capped so that appending to one child never overwrites the points of the next.
+	backing := make([]Point, 0, counts[0]+counts[1]+counts[2]+counts[3])
+	result := [4][]Point{}
+	for i, offset := 0, 0; i < len(result); i++ {
+		result[i] = backing[offset : offset : offset+counts[i]]
+		offset += counts[i]
+	}
+	for _, point := range tree.Points {
+		for i, child := range bounds {
+			if tree.inSplitChild(point, child[0], child[1]) {
+				result[i] = append(result[i], point)
+			}
 		}
 	}
 	return result
 }
 
-func convolve(grid [][]float64, kernel [][]float64, stride, padding int) ([][]float64, error) {
+// inSplitChild reports whether the point of the node belongs to the child with the given bounds.
+// Points on a split line belong to the left and the top children only, as in childFor.
+func (tree *ConvTree) inSplitChild(point, topLeft, bottomRight Point) bool {
+	if point.X == topLeft.X && topLeft.X > tree.TopLeft.X {
+		return false
+	}
+	if point.Y == topLeft.Y && topLeft.Y < tree.TopLeft.Y {
+		return false
+	}
+	return point.X >= topLeft.X && point.X <= bottomRight.X && point.Y >= bottomRight.Y && point.Y <= topLeft.Y
+}
+
+// convolve applies the kernel to the grid surrounded by padding zero cells on every side. With
+// padding 0 only the positions where the kernel fits into the grid are computed.
+func convolve(values *grid, kernel *grid, stride, padding int) (*grid, error) {
 	if stride < 1 {
 		err := errors.New("convolutional stride must be larger than 0")
 		return nil, err
 	}
-	if padding < 1 {
-		err := errors.New("convolutional padding must be larger than 0")
+	if padding < 0 {
+		err := errors.New("convolutional padding must not be negative")
 		return nil, err
 	}
-	kernelSize := len(kernel)
-	if len(grid) < kernelSize {
+	kernelSize := kernel.w
+	if values.w < kernelSize {
 		err := errors.New("grid width is less than convolutional kernel size")
 		return nil, err
 	}
-	if len(grid[0]) < kernelSize {
+	if values.h < kernelSize {
 		err := errors.New("grid height is less than convolutional kernel size")
 		return nil, err
 	}
-	procGrid := make([][]float64, len(grid)+2*padding)
-	for i := 0; i < padding; i++ {
-		procGrid[i] = make([]float64, len(grid)+2*padding)
-		for j := range procGrid[i] {
-			procGrid[i][j] = 0
-		}
-	}
-	for i := 1; i < (len(procGrid) - 1); i++ {
-		procGrid[i] = make([]float64, len(grid)+2*padding)
-		procGrid[i][0] = 0
-		for j := 1; j < len(procGrid[i])-1; j++ {
-			procGrid[i][j] = grid[i-padding][j-padding]
-		}
-		procGrid[i][len(procGrid[i])-1] = 0
+	procGrid := getGrid(values.w+2*padding, values.h+2*padding)
+	defer putGrid(procGrid)
+	for i := 0; i < values.w; i++ {
+		copy(procGrid.data[(i+padding)*procGrid.h+padding:], values.data[i*values.h:(i+1)*values.h])
 	}
-	for i := 0; i < padding; i++ {
-		procGrid[len(procGrid)-i-1] = make([]float64, len(grid)+2*padding)
-		for j := range procGrid[len(procGrid)-i-1] {
-			procGrid[len(procGrid)-i-1][j] = 0
-		}
+	resultWidth := int((values.w-kernelSize+2*padding)/stride) + 1
+	resultHeight := int((values.h-kernelSize+2*padding)/stride) + 1
+	result := getGrid(resultWidth, resultHeight)
+	if stride == 1 && kernelSize == 3 && kernel.h == 3 {
+		convolve3x3(procGrid, kernel, result)
+		return result, nil
 	}
-	resultWidth := int((len(grid)-kernelSize+2*padding)/stride) + 1
-	resultHeight := int((len(grid[0])-kernelSize+2*padding)/stride) + 1
-	result := make([][]float64, resultWidth)
 	for i := 0; i < resultWidth; i++ {
-		result[i] = make([]float64, resultHeight)
 		for j := 0; j < resultHeight; j++ {
 			total := 0.0
 			for x := 0; x < kernelSize; x++ {
-				for y := 0; y < kernelSize; y++ {
-					posX := stride*i + x
-					posY := stride*j + y
-					if posX >= 0 && posX < len(procGrid) && posY >= 0 && posY < len(procGrid[0]) {
-						total += procGrid[posX][posY] * kernel[x][y]
-					}
+				offset := (stride*i+x)*procGrid.h + stride*j
+				kernelRow := kernel.data[x*kernel.h : x*kernel.h+kernelSize]
+				for y, value := range procGrid.data[offset : offset+kernelSize] {
+					total += value * kernelRow[y]
 				}
 			}
-			result[i][j] = total
+			result.data[i*resultHeight+j] = total
 		}
 	}
 	return result, nil
 }
 
-func normalizeGrid(grid [][]float64) [][]float64 {
-	maxValue := -math.MaxFloat64
-	for i := 0; i < len(grid); i++ {
-		for j := 0; j < len(grid[0]); j++ {
-			if grid[i][j] > maxValue {
-				maxValue = grid[i][j]
-			}
+// convolve3x3 applies a 3x3 kernel with stride 1 to the padded grid. The products are added in
+// the same order as in convolve, so the result is identical.
+func convolve3x3(padded, kernel, result *grid) {
+	k := kernel.data[:9]
+	k00, k01, k02, k10, k11, k12, k20, k21, k22 := k[0], k[1], k[2], k[3], k[4], k[5], k[6], k[7], k[8]
+	h := padded.h
+	for i := 0; i < result.w; i++ {
+		row0 := padded.data[i*h : (i+1)*h]
+		row1 := padded.data[(i+1)*h : (i+2)*h]
+		row2 := padded.data[(i+2)*h : (i+3)*h]
+		out := result.data[i*result.h : (i+1)*result.h]
+		for j := range out {
+			total := 0.0
+			total += row0[j] * k00
+			total += row0[j+1] * k01
+			total += row0[j+2] * k02
+			total += row1[j] * k10
+			total += row1[j+1] * k11
+			total += row1[j+2] * k12
+			total += row2[j] * k20
+			total += row2[j+1] * k21
+			total += row2[j+2] * k22
+			out[j] = total
 		}
 	}
-	for i := 0; i < len(grid); i++ {
-		for j := 0; j < len(grid[0]); j++ {
-			grid[i][j] = grid[i][j] / maxValue
+}
+
+func normalizeGrid(values *grid) *grid {
+	maxValue := -math.MaxFloat64
+	for _, value := range values.data {
+		if value > maxValue {
+			maxValue = value
 		}
 	}
-	return grid
+	if maxValue <= 0 {
+		return values
+	}
+	for i := range values.data {
+		values.data[i] = values.data[i] / maxValue
+	}
+	return values
 }
@@ -0,0 +1,421 @@
+package convtree
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// TestQueriesDuringSplits is meant to be run with -race. Readers must never observe a node that
+// is being split, so the number of points and leaves they see can only grow.
+func TestQueriesDuringSplits(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithParallelSplit(4)}} {
+		tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 0.5, 0.5, 10, 10, 1, 8, nil, nil, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		const inserts, iterations = 2000, 200
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(seed int64) {
+				defer wg.Done()
+				r := rand.New(rand.NewSource(seed))
+				seenPoints, seenLeaves := 0, 0
+				for n := 0; n < iterations; n++ {
+					points := len(tree.QueryRange(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}))
+					leaves := len(tree.Leaves())
+					if points < seenPoints || leaves < seenLeaves {
+						t.Errorf("saw %d points and %d leaves after %d and %d", points, leaves, seenPoints, seenLeaves)
+						return
+					}
+					seenPoints, seenLeaves = points, leaves
+					x, y := r.Float64()*100, r.Float64()*100
+					leaf, ok := tree.FindLeaf(x, y)
+					if !ok || x < leaf.TopLeft.X || x > leaf.BottomRight.X || y > leaf.TopLeft.Y || y < leaf.BottomRight.Y {
+						t.Errorf("FindLeaf(%v, %v) returned a leaf that does not contain the point", x, y)
+						return
+					}
+				}
+			}(int64(i))
+		}
+		// Clustered inserts keep splitting the same region.
+		r := rand.New(rand.NewSource(99))
+		for i := 0; i < inserts; i++ {
+			point := Point{X: 30 + r.Float64()*20, Y: 30 + r.Float64()*20, Weight: 1}
+			if err := tree.Insert(point, true); err != nil {
+				t.Fatal(err)
+			}
+		}
+		wg.Wait()
+		if got := len(tree.QueryRange(Point{X: 0, Y: 100}, Point{X: 100, Y: 0})); got != inserts {
+			t.Fatalf("expected %d points, got %d", inserts, got)
+		}
+		checkLeafCount(t, &tree)
+	}
+}
+
+func TestConvolvePadding(t *testing.T) {
+	values := make([][]float64, 5)
+	for i := range values {
+		values[i] = make([]float64, 5)
+		for j := range values[i] {
+			values[i][j] = float64(5*i + j + 1)
+		}
+	}
+	kernel := [][]float64{{0, 1, 0}, {1, 1, 1}, {0, 1, 0}}
+	tests := []struct {
+		name     string
+		stride   int
+		padding  int
+		expected [][]float64
+	}{
+		{
+			name:    "padding 0",
+			stride:  1,
+			padding: 0,
+			expected: [][]float64{
+				{35, 40, 45},
+				{60, 65, 70},
+				{85, 90, 95},
+			},
+		},
+		{
+			name:    "padding 1",
+			stride:  1,
+			padding: 1,
+			expected: [][]float64{
+				{9, 13, 17, 21, 19},
+				{25, 35, 40, 45, 39},
+				{45, 60, 65, 70, 59},
+				{65, 85, 90, 95, 79},
+				{59, 83, 87, 91, 69},
+			},
+		},
+		{
+			name:    "padding 2",
+			stride:  1,
+			padding: 2,
+			expected: [][]float64{
+				{0, 1, 2, 3, 4, 5, 0},
+				{1, 9, 13, 17, 21, 19, 5},
+				{6, 25, 35, 40, 45, 39, 10},
+				{11, 45, 60, 65, 70, 59, 15},
+				{16, 65, 85, 90, 95, 79, 20},
+				{21, 59, 83, 87, 91, 69, 25},
+				{0, 21, 22, 23, 24, 25, 0},
+			},
+		},
+		{
+			name:    "padding 1 with stride 2",
+			stride:  2,
+			padding: 1,
+			expected: [][]float64{
+				{9, 17, 19},
+				{45, 65, 59},
+				{59, 87, 69},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := convolve(newGrid(values), newGrid(kernel), test.stride, test.padding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := result.rows(); !reflect.DeepEqual(actual, test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+	if _, err := convolve(newGrid(values), newGrid(kernel), 1, -1); err == nil {
+		t.Fatal("expected an error for negative padding")
+	}
+}
+
+// referenceWeightGrid is the original per-cell implementation of weightGrid, which scans all
+// points for every cell.
+func referenceWeightGrid(tree *ConvTree, size int) [][]float64 {
+	xStep := (tree.BottomRight.X - tree.TopLeft.X) / float64(size)
+	yStep := (tree.TopLeft.Y - tree.BottomRight.Y) / float64(size)
+	result := make([][]float64, size)
+	for i := range result {
+		result[i] = make([]float64, size)
+		for j := range result[i] {
+			xLeft := tree.TopLeft.X + float64(i)*xStep
+			xRight := tree.TopLeft.X + float64(i+1)*xStep
+			yBottom := tree.BottomRight.Y + float64(j)*yStep
+			yTop := tree.BottomRight.Y + float64(j+1)*yStep
+			total := 0
+			for _, point := range tree.Points {
+				if point.X >= xLeft && point.X <= xRight && point.Y >= yBottom && point.Y <= yTop {
+					total += point.Weight
+				}
+			}
+			result[i][j] = float64(total)
+		}
+	}
+	return result
+}
+
+func TestWeightGridMatchesReference(t *testing.T) {
+	r := rand.New(rand.NewSource(27))
+	for _, bounds := range []Bounds{
+		{TopLeft: Point{X: 0, Y: 100}, BottomRight: Point{X: 100, Y: 0}},
+		{TopLeft: Point{X: -0.3, Y: 0.7}, BottomRight: Point{X: 0.4, Y: -0.1}},
+		{TopLeft: Point{X: 37.1, Y: 55.9}, BottomRight: Point{X: 37.9, Y: 55.3}},
+	} {
+		for _, size := range []int{3, 8, 64} {
+			tree, err := NewConvTree(bounds.TopLeft, bounds.BottomRight, 0, 0, 1<<30, 4, 1, size, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			width := bounds.BottomRight.X - bounds.TopLeft.X
+			height := bounds.TopLeft.Y - bounds.BottomRight.Y
+			xStep, yStep := width/float64(size), height/float64(size)
+			for i := 0; i < 3000; i++ {
+				point := Point{
+					X:      bounds.TopLeft.X + r.Float64()*width,
+					Y:      bounds.BottomRight.Y + r.Float64()*height,
+					Weight: 1 + r.Intn(4),
+				}
+				// Every third point lies on a cell line, where it belongs to several cells.
+				if i%3 == 0 {
+					point.X = bounds.TopLeft.X + float64(r.Intn(size+1))*xStep
+				}
+				if i%6 == 0 {
+					point.Y = bounds.BottomRight.Y + float64(r.Intn(size+1))*yStep
+				}
+				tree.Points = append(tree.Points, point)
+			}
+			expected := referenceWeightGrid(&tree, size)
+			actual := tree.weightGrid().rows()
+			if !reflect.DeepEqual(actual, expected) {
+				t.Fatalf("weight grid of size %d over %v differs from the reference", size, bounds)
+			}
+		}
+	}
+}
+
+func BenchmarkWeightGrid(b *testing.B) {
+	tree, err := NewConvTree(Point{X: 0, Y: 1}, Point{X: 1, Y: 0}, 0, 0, 1<<30, 4, 1, 64, nil, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tree.Points = uniformPoints(rand.New(rand.NewSource(1)), 20000, 1)
+	b.Run("binned", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			putGrid(tree.weightGrid())
+		}
+	})
+	b.Run("reference", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			referenceWeightGrid(&tree, 64)
+		}
+	})
+}
+
+// referenceFilterSplitPoints is the original way of distributing points among children, with
+// a separate growing slice for every child.
+func referenceFilterSplitPoints(points []Point, topLeft, bottomRight Point) []Point {
+	result := []Point{}
+	for _, point := range points {
+		if point.X >= topLeft.X && point.X <= bottomRight.X && point.Y >= bottomRight.Y && point.Y <= topLeft.Y {
+			result = append(result, point)
+		}
+	}
+	return result
+}
+
+func BenchmarkSplitPoints(b *testing.B) {
+	tree, err := NewConvTree(Point{X: 0, Y: 1}, Point{X: 1, Y: 0}, 0, 0, 1<<30, 6, 1, 8, nil, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tree.Points = uniformPoints(rand.New(rand.NewSource(1)), 10000, 1)
+	bounds := [4][2]Point{
+		{{X: 0, Y: 1}, {X: 0.4, Y: 0.6}},
+		{{X: 0.4, Y: 1}, {X: 1, Y: 0.6}},
+		{{X: 0, Y: 0.6}, {X: 0.4, Y: 0}},
+		{{X: 0.4, Y: 0.6}, {X: 1, Y: 0}},
+	}
+	b.Run("partitioned", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tree.splitPoints(bounds)
+		}
+	})
+	b.Run("filtered", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, child := range bounds {
+				referenceFilterSplitPoints(tree.Points, child[0], child[1])
+			}
+		}
+	})
+}
+
+func BenchmarkBuildSixLevels(b *testing.B) {
+	points := uniformPoints(rand.New(rand.NewSource(1)), 100000, 1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		tree, err := NewConvTree(Point{X: 0, Y: 1}, Point{X: 1, Y: 0}, 0.001, 0.001, 20, 6, 2, 16, nil, points)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if i == 0 && tree.Summary().MaxDepth != 6 {
+			b.Fatalf("expected a 6-level tree, got depth %d", tree.Summary().MaxDepth)
+		}
+	}
+}
+
+func TestSplitWithoutConvolution(t *testing.T) {
+	r := rand.New(rand.NewSource(28))
+	points := uniformPoints(r, 100, 80)
+	// Most of the weight lies in the grid cell from 50 to 60 on X and from 20 to 30 on Y.
+	for i := 0; i < 400; i++ {
+		points = append(points, Point{X: 50.5 + r.Float64()*9, Y: 20.5 + r.Float64()*9, Weight: 1})
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 80}, Point{X: 80, Y: 0}, 1, 1, 300, 1, 0, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.IsLeaf || tree.Trace == nil {
+		t.Fatal("expected the root to be split")
+	}
+	if tree.Trace.ConvIterations != 0 {
+		t.Fatalf("expected no convolutions, got %d", tree.Trace.ConvIterations)
+	}
+	xRight, yBottom := tree.Trace.X, tree.Trace.Y
+	if xRight < 1 || xRight > 79 || yBottom < 1 || yBottom > 79 {
+		t.Fatalf("split position (%v, %v) violates the minimum child size", xRight, yBottom)
+	}
+	if (xRight > 50 && xRight < 60) || (yBottom > 20 && yBottom < 30) {
+		t.Fatalf("split position (%v, %v) cuts through the densest cell", xRight, yBottom)
+	}
+	if got := tree.Summary().Points; got != len(points) {
+		t.Fatalf("children hold %d points instead of %d", got, len(points))
+	}
+
+	leaf, err := NewConvTree(Point{X: 0, Y: 80}, Point{X: 80, Y: 0}, 1, 1, 1000, 1, 0, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	density, err := leaf.DensityGrid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	weights := leaf.weightGrid()
+	maxWeight := 0.0
+	for _, value := range weights.data {
+		maxWeight = math.Max(maxWeight, value)
+	}
+	for i := range density {
+		for j := range density[i] {
+			if density[i][j] != weights.At(i, j)/maxWeight {
+				t.Fatalf("density cell [%d][%d] is %v instead of the normalized weight %v", i, j,
+					density[i][j], weights.At(i, j)/maxWeight)
+			}
+		}
+	}
+}
+
+func TestDeepTreeStress(t *testing.T) {
+	r := rand.New(rand.NewSource(29))
+	points := uniformPoints(r, 200, 1)
+	// Tight clusters force splits down to the maximum depth.
+	for _, center := range []Point{{X: 0.3, Y: 0.7}, {X: 0.71, Y: 0.2}} {
+		for i := 0; i < 3000; i++ {
+			points = append(points, Point{
+				X:      center.X + r.NormFloat64()*1e-9,
+				Y:      center.Y + r.NormFloat64()*1e-9,
+				Weight: 1,
+			})
+		}
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 1}, Point{X: 1, Y: 0}, 1e-15, 1e-15, 5, 40, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := tree.Summary()
+	if stats.MaxDepth < 30 || stats.MaxDepth > 40 {
+		t.Fatalf("expected a tree between 30 and 40 levels deep, got %d", stats.MaxDepth)
+	}
+	if stats.Points != len(points) {
+		t.Fatalf("tree has %d points instead of %d", stats.Points, len(points))
+	}
+	checkLeafCount(t, &tree)
+	for _, leaf := range tree.Leaves() {
+		if leaf.checkSplit() {
+			t.Fatalf("leaf %s at depth %d with %d points was left unsplit", leaf.ID, leaf.Depth, len(leaf.Points))
+		}
+		for _, point := range leaf.Points {
+			if found, _ := tree.FindLeaf(point.X, point.Y); found != leaf {
+				t.Fatalf("point %v is stored in leaf %s but routed to %s", point, leaf.ID, found.ID)
+			}
+		}
+	}
+}
+
+func BenchmarkNewChildren(b *testing.B) {
+	tree, err := NewConvTree(Point{X: 0, Y: 1}, Point{X: 1, Y: 0}, 0, 0, 1<<30, 6, 1, 8, nil, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tree.Points = uniformPoints(rand.New(rand.NewSource(1)), 200, 1)
+	b.Run("block", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tree.newChildren(0.4, 0.6)
+		}
+	})
+	// The original layout allocates every child and its points separately.
+	b.Run("separate", func(b *testing.B) {
+		bounds := [4][2]Point{
+			{{X: 0, Y: 1}, {X: 0.4, Y: 0.6}},
+			{{X: 0.4, Y: 1}, {X: 1, Y: 0.6}},
+			{{X: 0, Y: 0.6}, {X: 0.4, Y: 0}},
+			{{X: 0.4, Y: 0.6}, {X: 1, Y: 0}},
+		}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			for _, child := range bounds {
+				tree.newChild(child[0], child[1], referenceFilterSplitPoints(tree.Points, child[0], child[1]))
+			}
+		}
+	})
+}
+
+func BenchmarkBuild100k(b *testing.B) {
+	points := uniformPoints(rand.New(rand.NewSource(1)), 100000, 1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewConvTree(Point{X: 0, Y: 1}, Point{X: 1, Y: 0}, 0.001, 0.001, 100, 12, 2, 16, nil,
+			points); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestNewConvTreeCopiesInitPoints(t *testing.T) {
+	points := uniformPoints(rand.New(rand.NewSource(5)), 500, 100)
+	for i := range points {
+		points[i].Weight = 4
+	}
+	original := append([]Point{}, points...)
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.RemoveFunc(func(p Point) bool { return p.X < 50 }) == 0 {
+		t.Fatal("no points were removed")
+	}
+	if err := tree.DecayWeights(0.5, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(points, original) {
+		t.Fatal("tree modified the initial points slice")
+	}
+}
@@ -0,0 +1,113 @@
+package convtree
+
+import "math"
+
+// CountPoints returns the number of points stored in the leaves and their total weight.
+func (tree *ConvTree) CountPoints() (points int, weight int) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	tree.walkLeaves(func(leaf *ConvTree) {
+		points += len(leaf.Points)
+		for _, point := range leaf.Points {
+			weight += point.Weight
+		}
+	})
+	return points, weight
+}
+
+// NodeCount returns the number of internal nodes and leaves.
+func (tree *ConvTree) NodeCount() (internal int, leaves int) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	tree.walkNodes(func(node *ConvTree) bool {
+		if node.IsLeaf {
+			leaves++
+		} else {
+			internal++
+		}
+		return true
+	})
+	return internal, leaves
+}
+
+// CountPoints returns the number of points stored in the leaves and their total weight.
+func (tree *QuadTree) CountPoints() (points int, weight int) {
+	tree.walkLeaves(func(leaf *QuadTree) {
+		points += len(leaf.Points)
+		for _, point := range leaf.Points {
+			weight += point.Weight
+		}
+	})
+	return points, weight
+}
+
+// NodeCount returns the number of internal nodes and leaves.
+func (tree *QuadTree) NodeCount() (internal int, leaves int) {
+	tree.Walk(func(node *QuadTree, depth int) bool {
+		if node.IsLeaf {
+			leaves++
+		} else {
+			internal++
+		}
+		return true
+	})
+	return internal, leaves
+}
+
+// DepthStats returns the depth of the deepest leaf and the number of leaves at every depth.
+// Depth is counted from the node the method is called on.
+func (tree *ConvTree) DepthStats() (maxDepth int, histogram map[int]int) {
+	histogram = map[int]int{}
+	tree.Walk(func(node *ConvTree, depth int) bool {
+		if node.IsLeaf {
+			histogram[depth]++
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+		}
+		return true
+	})
+	return maxDepth, histogram
+}
+
+// PointsBounds returns the smallest rectangle containing all stored points.
+// ok is false when the tree holds no points.
+func (tree *ConvTree) PointsBounds() (topLeft, bottomRight Point, ok bool) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	tree.walkLeaves(func(leaf *ConvTree) {
+		for _, point := range leaf.Points {
+			if !ok {
+				topLeft = Point{X: point.X, Y: point.Y}
+				bottomRight = Point{X: point.X, Y: point.Y}
+				ok = true
+				continue
+			}
+			topLeft.X = math.Min(topLeft.X, point.X)
+			topLeft.Y = math.Max(topLeft.Y, point.Y)
+			bottomRight.X = math.Max(bottomRight.X, point.X)
+			bottomRight.Y = math.Min(bottomRight.Y, point.Y)
+		}
+	})
+	return topLeft, bottomRight, ok
+}
+
+// PointsBounds returns the smallest rectangle containing all stored points.
+// ok is false when the tree holds no points.
+func (tree *QuadTree) PointsBounds() (topLeft, bottomRight Point, ok bool) {
+	tree.walkLeaves(func(leaf *QuadTree) {
+		for _, point := range leaf.Points {
+			if !ok {
+				topLeft = Point{X: point.X, Y: point.Y}
+				bottomRight = Point{X: point.X, Y: point.Y}
+				ok = true
+				continue
+			}
+			topLeft.X = math.Min(topLeft.X, point.X)
+			topLeft.Y = math.Min(topLeft.Y, point.Y)
+			bottomRight.X = math.Max(bottomRight.X, point.X)
+			bottomRight.Y = math.Max(bottomRight.Y, point.Y)
+		}
+	})
+	return topLeft, bottomRight, ok
+}
@@ -0,0 +1,55 @@
+package convtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDepthStatsAtMaxDepth(t *testing.T) {
+	r := rand.New(rand.NewSource(12))
+	points := uniformPoints(r, 4000, 100)
+	for i := 0; i < 2000; i++ {
+		points = append(points, Point{X: 70 + r.Float64(), Y: 70 + r.Float64(), Weight: 1})
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 0.01, 0.01, 50, 5, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxDepth, histogram := tree.DepthStats()
+	if maxDepth != tree.MaxDepth {
+		t.Fatalf("expected the cluster to reach depth %d, got %d", tree.MaxDepth, maxDepth)
+	}
+	expected := map[int]int{}
+	for _, leaf := range tree.Leaves() {
+		if leaf.Depth > tree.MaxDepth {
+			t.Fatalf("leaf %s is deeper than MaxDepth", leaf.ID)
+		}
+		expected[leaf.Depth]++
+	}
+	if len(histogram) != len(expected) {
+		t.Fatalf("expected histogram %v, got %v", expected, histogram)
+	}
+	for depth, count := range expected {
+		if histogram[depth] != count {
+			t.Fatalf("expected histogram %v, got %v", expected, histogram)
+		}
+	}
+	_, leaves := tree.NodeCount()
+	if leaves != len(tree.Leaves()) || histogram[tree.MaxDepth] == 0 {
+		t.Fatalf("histogram %v does not match %d leaves", histogram, leaves)
+	}
+
+	// Depth is counted from the node DepthStats is called on.
+	sub := tree.childContaining(Point{X: 70.5, Y: 70.5}, Point{X: 70.5, Y: 70.5})
+	subDepth, subHistogram := sub.DepthStats()
+	if subDepth != tree.MaxDepth-1 {
+		t.Fatalf("expected subtree depth %d, got %d", tree.MaxDepth-1, subDepth)
+	}
+	if subHistogram[0] != 0 || subHistogram[subDepth] == 0 {
+		t.Fatalf("unexpected subtree histogram %v", subHistogram)
+	}
+
+	if got, weight := tree.CountPoints(); got != len(points) || weight != len(points) {
+		t.Fatalf("expected %d points, got %d of weight %d", len(points), got, weight)
+	}
+}
@@ -0,0 +1,114 @@
+package convtree
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvPointFields is the number of leading columns describing the point itself.
+const csvPointFields = 4
+
+var csvHeader = []string{"x", "y", "weight", "tags", "leaf_inserts", "leaf_removes",
+	"leaf_value_min", "leaf_value_max", "leaf_value_mean", "leaf_last_insert_at"}
+
+// WriteCSV writes a header and a row of x, y, weight, tags, the churn of the leaf storing the
+// point, the min, max and mean of the values extracted in that leaf and the time of the last
+// insert into it for every stored point. Tags are joined by '|' and are empty when Content is not
+// a []string. The value columns are empty when the leaf has no extracted values, and the time is
+// formatted as RFC 3339 and empty when nothing was inserted.
+func (tree *ConvTree) WriteCSV(w io.Writer) error {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	var err error
+	tree.walkLeaves(func(leaf *ConvTree) {
+		values := []string{"", "", ""}
+		if leaf.Values.Count > 0 {
+			values = []string{
+				strconv.FormatFloat(leaf.Values.Min, 'g', -1, 64),
+				strconv.FormatFloat(leaf.Values.Max, 'g', -1, 64),
+				strconv.FormatFloat(leaf.Values.Sum/float64(leaf.Values.Count), 'g', -1, 64),
+			}
+		}
+		lastInsertAt := ""
+		if !leaf.LastInsertAt.IsZero() {
+			lastInsertAt = leaf.LastInsertAt.Format(time.RFC3339Nano)
+		}
+		values = append(values, lastInsertAt)
+		for _, point := range leaf.Points {
+			if err != nil {
+				return
+			}
+			tags, _ := pointTags(point)
+			err = writer.Write(append([]string{
+				strconv.FormatFloat(point.X, 'g', -1, 64),
+				strconv.FormatFloat(point.Y, 'g', -1, 64),
+				strconv.Itoa(point.Weight),
+				strings.Join(tags, "|"),
+				strconv.FormatInt(leaf.InsertCount, 10),
+				strconv.FormatInt(leaf.RemoveCount, 10),
+			}, values...))
+		}
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// NewConvTreeFromCSV builds a tree from points in the format written by WriteCSV. The header row
+// and the leaf columns are optional. Points with an empty tags column have no Content. Leaf
+// columns are ignored, since the built tree computes its own leaf statistics.
+func NewConvTreeFromCSV(r io.Reader, topLeft, bottomRight Point, params Config, opts ...Option) (*ConvTree, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	points := []Point{}
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) != csvPointFields && len(record) != len(csvHeader) {
+			return nil, fmt.Errorf("line %d: expected %d or %d fields, got %d", line, csvPointFields,
+				len(csvHeader), len(record))
+		}
+		if first && strings.EqualFold(record[0], csvHeader[0]) {
+			continue
+		}
+		x, err := strconv.ParseFloat(record[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid x %q", line, record[0])
+		}
+		y, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid y %q", line, record[1])
+		}
+		weight, err := strconv.Atoi(record[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid weight %q", line, record[2])
+		}
+		point := Point{X: x, Y: y, Weight: weight}
+		if record[3] != "" {
+			point.Content = strings.Split(record[3], "|")
+		}
+		points = append(points, point)
+	}
+	tree, err := NewConvTree(topLeft, bottomRight, params.MinXLength, params.MinYLength, params.MaxPoints,
+		params.MaxDepth, params.ConvNum, params.GridSize, params.Kernel, points, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
@@ -0,0 +1,40 @@
+package convtree
+
+import (
+	"errors"
+	"math"
+)
+
+// DecayWeights multiplies the weight of every point by factor, rounding down, and removes the
+// points whose weight falls below floor. It returns an error if factor is outside (0, 1].
+func (tree *ConvTree) DecayWeights(factor float64, floor int) error {
+	if !(factor > 0 && factor <= 1) {
+		return errors.New("decay factor must be in (0, 1]")
+	}
+	tree.config.lock()
+	defer tree.config.unlock()
+	tree.decayWeights(factor, floor)
+	tree.config.recordMutation(tree, func(node *ConvTree) error {
+		node.decayWeights(factor, floor)
+		return nil
+	})
+	return nil
+}
+
+func (tree *ConvTree) decayWeights(factor float64, floor int) {
+	tree.walkLeaves(func(leaf *ConvTree) {
+		if len(leaf.Points) == 0 {
+			return
+		}
+		for i := range leaf.Points {
+			leaf.Points[i].Weight = int(math.Floor(float64(leaf.Points[i].Weight) * factor))
+		}
+		kept := filterPoints(leaf.Points, func(point Point) bool {
+			return point.Weight < floor
+		})
+		leaf.RemoveCount += int64(len(leaf.Points) - len(kept))
+		leaf.Points = kept
+		leaf.recomputeValues()
+		leaf.BaselineTags = leaf.getBaseline()
+	})
+}
@@ -0,0 +1,28 @@
+package convtree
+
+import "errors"
+
+// DensityGrid returns the normalized convolved weight grid that a split of the node would use,
+// without splitting it. Internal nodes use the points of all their descendants. Cell [i][j]
+// covers the i-th column from the left and the j-th row from the bottom. An error is returned if
+// the grid cannot be convolved, e.g. when it is smaller than the kernel.
+func (tree *ConvTree) DensityGrid() ([][]float64, error) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	if tree.config.parameters().GridSize < 1 {
+		return nil, errors.New("grid size must be positive")
+	}
+	probe := *tree
+	if !tree.IsLeaf {
+		probe.Points = []Point{}
+		tree.walkLeaves(func(leaf *ConvTree) {
+			probe.Points = append(probe.Points, leaf.Points...)
+		})
+	}
+	grid, _, err := probe.densityGrid()
+	if err != nil {
+		putGrid(grid)
+		return nil, err
+	}
+	return grid.rows(), nil
+}
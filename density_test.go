@@ -0,0 +1,69 @@
+package convtree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestDensityGridEmptyNode(t *testing.T) {
+	for _, convNum := range []int{0, 2} {
+		tree, err := NewConvTree(Point{X: 0, Y: 10}, Point{X: 10, Y: 0}, 1, 1, 5, 4, convNum, 8, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		grid, err := tree.DensityGrid()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(grid) != 8 || len(grid[0]) != 8 {
+			t.Fatalf("convNum %d: got %dx%d grid", convNum, len(grid), len(grid[0]))
+		}
+		for i := range grid {
+			for j, value := range grid[i] {
+				if value != 0 {
+					t.Fatalf("convNum %d: cell [%d][%d] of an empty node is %v", convNum, i, j, value)
+				}
+			}
+		}
+	}
+}
+
+func TestDensityGridIsNormalized(t *testing.T) {
+	points := uniformPoints(rand.New(rand.NewSource(3)), 200, 10)
+	tree, err := NewConvTree(Point{X: 0, Y: 10}, Point{X: 10, Y: 0}, 1, 1, 1000, 4, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid, err := tree.DensityGrid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxValue := 0.0
+	for i := range grid {
+		for _, value := range grid[i] {
+			if math.IsNaN(value) || value < 0 {
+				t.Fatalf("unexpected cell value %v", value)
+			}
+			maxValue = math.Max(maxValue, value)
+		}
+	}
+	if maxValue != 1 {
+		t.Fatalf("maximum cell value is %v instead of 1", maxValue)
+	}
+}
+
+func TestDensityGridConvolveError(t *testing.T) {
+	kernel := [][]float64{{1, 1, 1, 1, 1}, {1, 1, 1, 1, 1}, {1, 1, 1, 1, 1}, {1, 1, 1, 1, 1}, {1, 1, 1, 1, 1}}
+	tree, err := NewConvTree(Point{X: 0, Y: 10}, Point{X: 10, Y: 0}, 1, 1, 1000, 4, 1, 3, kernel,
+		uniformPoints(rand.New(rand.NewSource(1)), 50, 10), WithStrictMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.DensityGrid(); err == nil {
+		t.Fatal("expected an error for a grid smaller than the kernel")
+	}
+	if got := tree.Diagnostics()[DiagConvolveError]; got != 1 {
+		t.Fatalf("expected 1 convolve error, got %d", got)
+	}
+}
@@ -0,0 +1,75 @@
+package convtree
+
+import "sync/atomic"
+
+const (
+	DiagInsertDropped      = "insert_dropped"
+	DiagCheckInternalNode  = "check_internal_node"
+	DiagClearEmptyLeaf     = "clear_empty_leaf"
+	DiagConvolveError      = "convolve_error"
+	DiagBaselineNonTagData = "baseline_non_tag_content"
+	DiagPointOutOfBounds   = "point_out_of_bounds"
+	DiagContentDropped     = "content_dropped"
+	DiagLeafCapSkipped     = "leaf_cap_skipped"
+)
+
+var diagnosticNames = []string{
+	DiagInsertDropped,
+	DiagCheckInternalNode,
+	DiagClearEmptyLeaf,
+	DiagConvolveError,
+	DiagBaselineNonTagData,
+	DiagPointOutOfBounds,
+	DiagContentDropped,
+	DiagLeafCapSkipped,
+}
+
+// WithStrictMode makes the tree count operations that silently do nothing, such as inserts that
+// fall outside the tree bounds or Check calls on internal nodes. Where a method can return an error,
+// strict mode returns it instead. Counters are available via Diagnostics.
+func WithStrictMode() Option {
+	return func(config *treeConfig) {
+		config.strict = true
+	}
+}
+
+func newDiagnostics() map[string]*int64 {
+	diagnostics := make(map[string]*int64, len(diagnosticNames))
+	for _, name := range diagnosticNames {
+		diagnostics[name] = new(int64)
+	}
+	return diagnostics
+}
+
+// report increments the named counter in strict mode and reports whether it was counted.
+func (config *treeConfig) report(name string) bool {
+	if config == nil || !config.strict {
+		return false
+	}
+	config.count(name, 1)
+	return true
+}
+
+// count adds n to the named counter regardless of strict mode.
+func (config *treeConfig) count(name string, n int64) {
+	if config == nil {
+		return
+	}
+	if counter, ok := config.diagnostics[name]; ok {
+		atomic.AddInt64(counter, n)
+	}
+}
+
+// Diagnostics returns the diagnostic counters of the tree. All counters except
+// DiagPointOutOfBounds, which counts points dropped outside strict mode, and DiagLeafCapSkipped
+// are strict mode only.
+func (tree *ConvTree) Diagnostics() map[string]int64 {
+	result := map[string]int64{}
+	if tree.config == nil {
+		return result
+	}
+	for name, counter := range tree.config.diagnostics {
+		result[name] = atomic.LoadInt64(counter)
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+package convtree
+
+import "sort"
+
+// CellDrift counts sample points routed to an exported cell and how many of them the live tree
+// routes to a different leaf.
+type CellDrift struct {
+	CellID   string
+	Sampled  int
+	Diverged int
+}
+
+// DriftReport compares the routing of a live tree with exported cell definitions. Points outside
+// both the tree and the exported cells are counted in Outside and ignored otherwise.
+// Cells lists only cells with diverged points, the most diverged first.
+type DriftReport struct {
+	Sampled  int
+	Diverged int
+	Outside  int
+	Fraction float64
+	Cells    []CellDrift
+}
+
+// VerifyAgainstCells routes the sample points through the tree and through the tree imported
+// from defs and reports the points routed to leaves with different IDs. The report is
+// deterministic for a given sample.
+func (tree *ConvTree) VerifyAgainstCells(defs []CellDef, sample []Point) (DriftReport, error) {
+	report := DriftReport{}
+	exported, err := ImportCells(defs)
+	if err != nil {
+		return report, err
+	}
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	cells := map[string]*CellDrift{}
+	for _, point := range sample {
+		liveID, exportedID := "", ""
+		if tree.contains(point.X, point.Y) {
+			liveID = tree.findLeaf(point.X, point.Y).ID
+		}
+		if exported.contains(point.X, point.Y) {
+			exportedID = exported.findLeaf(point.X, point.Y).ID
+		}
+		if liveID == "" && exportedID == "" {
+			report.Outside++
+			continue
+		}
+		report.Sampled++
+		cell, ok := cells[exportedID]
+		if !ok {
+			cell = &CellDrift{CellID: exportedID}
+			cells[exportedID] = cell
+		}
+		cell.Sampled++
+		if liveID != exportedID {
+			cell.Diverged++
+			report.Diverged++
+		}
+	}
+	for _, cell := range cells {
+		if cell.Diverged > 0 {
+			report.Cells = append(report.Cells, *cell)
+		}
+	}
+	sort.Slice(report.Cells, func(i, j int) bool {
+		if report.Cells[i].Diverged != report.Cells[j].Diverged {
+			return report.Cells[i].Diverged > report.Cells[j].Diverged
+		}
+		return report.Cells[i].CellID < report.Cells[j].CellID
+	})
+	if report.Sampled > 0 {
+		report.Fraction = float64(report.Diverged) / float64(report.Sampled)
+	}
+	return report, nil
+}
@@ -0,0 +1,79 @@
+package convtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestVerifyAgainstCells(t *testing.T) {
+	r := rand.New(rand.NewSource(71))
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 1, 8, nil,
+		uniformPoints(r, 1500, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs := tree.ExportCells()
+	sample := append(uniformPoints(r, 1000, 100), Point{X: -5, Y: 50}, Point{X: 50, Y: 120})
+	report, err := tree.VerifyAgainstCells(defs, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Sampled != 1000 || report.Outside != 2 || report.Diverged != 0 || report.Fraction != 0 ||
+		len(report.Cells) != 0 {
+		t.Fatalf("matching cells report %+v", report)
+	}
+
+	// Swapping the IDs of two cells makes every point in them diverge.
+	tampered := append([]CellDef{}, defs...)
+	tampered[0].ID, tampered[1].ID = tampered[1].ID, tampered[0].ID
+	counts := map[string]int{}
+	for _, point := range sample[:1000] {
+		leaf, _ := tree.FindLeaf(point.X, point.Y)
+		counts[leaf.ID]++
+	}
+	first, second := counts[defs[0].ID], counts[defs[1].ID]
+	report, err = tree.VerifyAgainstCells(tampered, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Sampled != 1000 || report.Diverged != first+second || len(report.Cells) != 2 ||
+		report.Fraction != float64(first+second)/1000 {
+		t.Fatalf("expected %d and %d diverged points, got %+v", first, second, report)
+	}
+	for i, cell := range report.Cells {
+		if cell.Sampled != cell.Diverged || (i > 0 && cell.Diverged > report.Cells[i-1].Diverged) {
+			t.Fatalf("unexpected cell drift %+v in %+v", cell, report.Cells)
+		}
+		if (cell.CellID != tampered[0].ID || cell.Diverged != first) &&
+			(cell.CellID != tampered[1].ID || cell.Diverged != second) {
+			t.Fatalf("unexpected cell drift %+v", cell)
+		}
+	}
+
+	// Splitting a leaf of the live tree makes the points in it diverge.
+	leaf := tree.Leaves()[5]
+	for i := 0; i < 100; i++ {
+		point := Point{
+			X:      leaf.TopLeft.X + r.Float64()*(leaf.BottomRight.X-leaf.TopLeft.X),
+			Y:      leaf.BottomRight.Y + r.Float64()*(leaf.TopLeft.Y-leaf.BottomRight.Y),
+			Weight: 1,
+		}
+		if err := tree.Insert(point, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if leaf.IsLeaf {
+		t.Fatal("leaf was not split")
+	}
+	report, err = tree.VerifyAgainstCells(defs, sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Diverged != counts[leaf.ID] || len(report.Cells) != 1 || report.Cells[0].CellID != leaf.ID {
+		t.Fatalf("expected %d diverged points in cell %s, got %+v", counts[leaf.ID], leaf.ID, report)
+	}
+
+	if _, err := tree.VerifyAgainstCells(defs[1:], sample); err == nil {
+		t.Fatal("invalid cells were accepted")
+	}
+}
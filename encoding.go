@@ -0,0 +1,305 @@
+package convtree
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const encodingVersion = 2
+
+type treeDocument struct {
+	Version  int           `json:"version"`
+	Schedule string        `json:"schedule,omitempty"`
+	Frozen   bool          `json:"frozen,omitempty"`
+	Samples  uint64        `json:"samples,omitempty"`
+	Pins     []pinRecord   `json:"pins,omitempty"`
+	Params   *paramsRecord `json:"params"`
+	Root     *nodeRecord   `json:"root"`
+}
+
+type paramsRecord struct {
+	MaxPoints  int         `json:"max_points"`
+	MaxDepth   int         `json:"max_depth"`
+	GridSize   int         `json:"grid_size"`
+	ConvNum    int         `json:"conv_num"`
+	Kernel     [][]float64 `json:"kernel"`
+	MinXLength float64     `json:"min_x_length"`
+	MinYLength float64     `json:"min_y_length"`
+}
+
+type pinRecord struct {
+	ID     string     `json:"id"`
+	Bounds [4]float64 `json:"bounds"`
+}
+
+type pointRecord struct {
+	X      float64   `json:"x"`
+	Y      float64   `json:"y"`
+	Weight int       `json:"weight"`
+	Tags   *[]string `json:"tags,omitempty"`
+}
+
+type nodeRecord struct {
+	ID               string         `json:"id"`
+	IsLeaf           bool           `json:"is_leaf"`
+	Depth            int            `json:"depth"`
+	Bounds           [4]float64     `json:"bounds"`
+	Points           []pointRecord  `json:"points,omitempty"`
+	BaselineTags     []string       `json:"baseline_tags,omitempty"`
+	LastInsertAt     time.Time      `json:"last_insert_at"`
+	InsertCount      int64          `json:"insert_count,omitempty"`
+	RemoveCount      int64          `json:"remove_count,omitempty"`
+	Trace            *SplitTrace    `json:"trace,omitempty"`
+	Values           ValueSummary   `json:"values"`
+	Pinned           bool           `json:"pinned,omitempty"`
+	Activity         *Activity      `json:"activity,omitempty"`
+	History          []WeightSample `json:"history,omitempty"`
+	ChildTopLeft     *nodeRecord    `json:"child_top_left,omitempty"`
+	ChildTopRight    *nodeRecord    `json:"child_top_right,omitempty"`
+	ChildBottomLeft  *nodeRecord    `json:"child_bottom_left,omitempty"`
+	ChildBottomRight *nodeRecord    `json:"child_bottom_right,omitempty"`
+}
+
+// toDocument returns the encoded form of the node shared by JSON and gob.
+func (tree *ConvTree) toDocument() treeDocument {
+	doc := tree.documentHeader()
+	doc.Root = tree.toRecord()
+	return doc
+}
+
+// documentHeader returns the encoded tree-wide state without the root node.
+func (tree *ConvTree) documentHeader() treeDocument {
+	params := tree.config.parameters()
+	doc := treeDocument{Version: encodingVersion, Params: &paramsRecord{
+		MaxPoints:  params.MaxPoints,
+		MaxDepth:   params.MaxDepth,
+		GridSize:   params.GridSize,
+		ConvNum:    params.ConvNum,
+		Kernel:     params.Kernel,
+		MinXLength: params.MinXLength,
+		MinYLength: params.MinYLength,
+	}}
+	if tree.config != nil {
+		if tree.config.schedule != nil {
+			doc.Schedule = tree.config.schedule.Name
+		}
+		doc.Frozen = tree.config.frozen
+		doc.Samples = tree.config.samples
+		pinned := map[string]bool{}
+		tree.walkLeaves(func(leaf *ConvTree) {
+			pinned[leaf.ID] = leaf.Pinned
+		})
+		for _, pin := range tree.config.pins {
+			if pinned[pin.id] {
+				doc.Pins = append(doc.Pins, pinRecord{ID: pin.id, Bounds: boundsArray(pin.bounds.TopLeft, pin.bounds.BottomRight)})
+			}
+		}
+	}
+	return doc
+}
+
+func (tree *ConvTree) toRecord() *nodeRecord {
+	node := tree.toFields()
+	if tree.IsLeaf {
+		node.Points = make([]pointRecord, len(tree.Points))
+		for i, point := range tree.Points {
+			node.Points[i] = tree.pointRecord(point)
+		}
+		return node
+	}
+	node.ChildTopLeft = tree.ChildTopLeft.toRecord()
+	node.ChildTopRight = tree.ChildTopRight.toRecord()
+	node.ChildBottomLeft = tree.ChildBottomLeft.toRecord()
+	node.ChildBottomRight = tree.ChildBottomRight.toRecord()
+	return node
+}
+
+// toFields returns the encoded node without its points and children.
+func (tree *ConvTree) toFields() *nodeRecord {
+	node := &nodeRecord{
+		ID:           tree.ID,
+		IsLeaf:       tree.IsLeaf,
+		Depth:        tree.Depth,
+		Bounds:       boundsArray(tree.TopLeft, tree.BottomRight),
+		BaselineTags: tree.BaselineTags,
+		LastInsertAt: tree.LastInsertAt,
+		InsertCount:  tree.InsertCount,
+		RemoveCount:  tree.RemoveCount,
+		Trace:        tree.Trace,
+		Values:       tree.Values,
+		Pinned:       tree.Pinned,
+		History:      tree.History,
+	}
+	if tree.Activity != (Activity{}) {
+		activity := tree.Activity
+		node.Activity = &activity
+	}
+	return node
+}
+
+// pointRecord encodes a point of the node and counts dropped Content.
+func (tree *ConvTree) pointRecord(point Point) pointRecord {
+	record := newPointRecord(point)
+	if record.Tags == nil && point.Content != nil {
+		tree.config.report(DiagContentDropped)
+	}
+	return record
+}
+
+func (doc treeDocument) toTree(opts []Option) (*ConvTree, error) {
+	if doc.Root == nil {
+		return nil, errors.New("encoded tree has no root")
+	}
+	config := newTreeConfig(opts)
+	tree, err := doc.Root.toTree(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.restore(tree, config); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// restore validates the document and applies the tree-wide state to the decoded tree and its config.
+func (doc treeDocument) restore(tree *ConvTree, config *treeConfig) error {
+	if doc.Version != encodingVersion {
+		return fmt.Errorf("unsupported tree encoding version %d", doc.Version)
+	}
+	if doc.Params == nil {
+		return errors.New("encoded tree has no split parameters")
+	}
+	if !checkKernel(doc.Params.Kernel) {
+		return errors.New("encoded tree has an invalid convolutional kernel")
+	}
+	config.params = Config{
+		MinXLength: doc.Params.MinXLength,
+		MinYLength: doc.Params.MinYLength,
+		MaxPoints:  doc.Params.MaxPoints,
+		MaxDepth:   doc.Params.MaxDepth,
+		ConvNum:    doc.Params.ConvNum,
+		GridSize:   doc.Params.GridSize,
+	}
+	config.useKernel(doc.Params.Kernel)
+	tree.syncParams()
+	if doc.Schedule != "" && (config.schedule == nil || config.schedule.Name != doc.Schedule) {
+		return fmt.Errorf("tree was built with max points schedule %q", doc.Schedule)
+	}
+	config.frozen = config.frozen || doc.Frozen
+	config.rootDepth = tree.Depth
+	config.samples = doc.Samples
+	if err := tree.resolveImportedBounds(); err != nil {
+		return err
+	}
+	tree.resetLeafCount()
+	pinned := map[string]bool{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		pinned[leaf.ID] = leaf.Pinned
+	})
+	for _, pin := range doc.Pins {
+		if !pinned[pin.ID] {
+			return fmt.Errorf("pinned region %s has no pinned leaf", pin.ID)
+		}
+		config.pins = append(config.pins, pinnedRegion{
+			bounds: Bounds{TopLeft: Point{X: pin.Bounds[0], Y: pin.Bounds[1]}, BottomRight: Point{X: pin.Bounds[2], Y: pin.Bounds[3]}},
+			id:     pin.ID,
+		})
+	}
+	return nil
+}
+
+func (node *nodeRecord) toTree(config *treeConfig) (*ConvTree, error) {
+	tree, err := node.toNode(config)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]Point, len(node.Points))
+	for i, point := range node.Points {
+		points[i] = point.point()
+	}
+	var children [4]*ConvTree
+	for i, child := range []*nodeRecord{node.ChildTopLeft, node.ChildTopRight, node.ChildBottomLeft, node.ChildBottomRight} {
+		if child == nil {
+			continue
+		}
+		if children[i], err = child.toTree(config); err != nil {
+			return nil, err
+		}
+	}
+	if err := tree.setDecoded(points, children); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// toNode returns the decoded node without its points and children.
+func (node *nodeRecord) toNode(config *treeConfig) (*ConvTree, error) {
+	tree := &ConvTree{
+		ID:           node.ID,
+		IsLeaf:       node.IsLeaf,
+		Depth:        node.Depth,
+		TopLeft:      Point{X: node.Bounds[0], Y: node.Bounds[1]},
+		BottomRight:  Point{X: node.Bounds[2], Y: node.Bounds[3]},
+		BaselineTags: node.BaselineTags,
+		LastInsertAt: node.LastInsertAt,
+		InsertCount:  node.InsertCount,
+		RemoveCount:  node.RemoveCount,
+		Trace:        node.Trace,
+		Values:       node.Values,
+		Pinned:       node.Pinned,
+		History:      node.History,
+		config:       config,
+	}
+	if node.Activity != nil {
+		tree.Activity = *node.Activity
+	}
+	return tree, nil
+}
+
+// setDecoded attaches the decoded points to a leaf or the decoded children to an internal node.
+func (tree *ConvTree) setDecoded(points []Point, children [4]*ConvTree) error {
+	if tree.IsLeaf {
+		for _, child := range children {
+			if child != nil {
+				return fmt.Errorf("leaf %s has children", tree.ID)
+			}
+		}
+		if points == nil {
+			points = []Point{}
+		}
+		tree.Points = points
+		return nil
+	}
+	for _, child := range children {
+		if child == nil {
+			return fmt.Errorf("internal node %s is missing a child", tree.ID)
+		}
+		if child.Depth != tree.Depth+1 {
+			return fmt.Errorf("node %s has depth %d under a node of depth %d", child.ID, child.Depth, tree.Depth)
+		}
+	}
+	tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight = children[0], children[1], children[2], children[3]
+	return nil
+}
+
+// newPointRecord returns the encoded form of the point, which keeps Content only if it is a []string.
+func newPointRecord(point Point) pointRecord {
+	record := pointRecord{X: point.X, Y: point.Y, Weight: point.Weight}
+	if tags, ok := pointTags(point); ok {
+		record.Tags = &tags
+	}
+	return record
+}
+
+func (record pointRecord) point() Point {
+	point := Point{X: record.X, Y: record.Y, Weight: record.Weight}
+	if record.Tags != nil {
+		point.Content = *record.Tags
+	}
+	return point
+}
+
+func boundsArray(topLeft, bottomRight Point) [4]float64 {
+	return [4]float64{topLeft.X, topLeft.Y, bottomRight.X, bottomRight.Y}
+}
@@ -0,0 +1,109 @@
+package convtree_test
+
+import (
+	"encoding/json"
+	"fmt"
+
+	convtree "github.com/visheratin/conv-tree"
+)
+
+// exampleTree builds a tree over [0, 100] x [0, 100] from clustered synthetic points. Sequential
+// IDs make the output of the examples stable.
+func exampleTree(count, maxPoints int) convtree.ConvTree {
+	bounds := convtree.Bounds{
+		TopLeft:     convtree.Point{X: 0, Y: 100},
+		BottomRight: convtree.Point{X: 100, Y: 0},
+	}
+	points := convtree.GeneratePoints(bounds, convtree.SyntheticConfig{Count: count, Clusters: 3, Spread: 0.1, Seed: 1})
+	tree, err := convtree.NewConvTree(bounds.TopLeft, bounds.BottomRight, 1, 1, maxPoints, 8, 2, 8, nil, points,
+		convtree.WithIDGenerator(convtree.SequentialIDs("cell-")))
+	if err != nil {
+		panic(err)
+	}
+	return tree
+}
+
+func ExampleNewConvTree() {
+	tree := exampleTree(1000, 100)
+	stats := tree.Summary()
+	fmt.Println("points:", stats.Points)
+	fmt.Println("leaves:", stats.Leaves)
+	fmt.Println("depth:", stats.MaxDepth)
+	fmt.Println("root:", tree.ID, "first child:", tree.ChildTopLeft.ID)
+	// Output:
+	// points: 1000
+	// leaves: 37
+	// depth: 5
+	// root: cell-1 first child: cell-2
+}
+
+func ExampleConvTree_QueryRange() {
+	tree := exampleTree(1000, 100)
+	points := tree.QueryRange(convtree.Point{X: 25, Y: 75}, convtree.Point{X: 75, Y: 25})
+	fmt.Println("points in the central square:", len(points))
+	// Output: points in the central square: 512
+}
+
+func ExampleConvTree_ToGeoJSON() {
+	tree := exampleTree(60, 30)
+	data, err := tree.ToGeoJSON(convtree.GeoJSONNonEmptyOnly())
+	if err != nil {
+		panic(err)
+	}
+	document := struct {
+		Features []struct {
+			Properties struct {
+				ID     string `json:"id"`
+				Points int    `json:"points"`
+			} `json:"properties"`
+		} `json:"features"`
+	}{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		panic(err)
+	}
+	for _, feature := range document.Features {
+		fmt.Println(feature.Properties.ID, feature.Properties.Points)
+	}
+	// Output:
+	// cell-10 2
+	// cell-11 20
+	// cell-13 12
+	// cell-7 1
+	// cell-8 19
+	// cell-9 6
+}
+
+func ExampleConvTree_Stats() {
+	tree := exampleTree(300, 100)
+	stats := tree.Stats()
+	// Stats is a map, so the leaves are listed in the order of Leaves.
+	for _, leaf := range tree.Leaves() {
+		cell := stats[leaf.ID]
+		fmt.Printf("%s: %d points, center (%.1f, %.1f)\n", leaf.ID, cell.PointsNumber, cell.CenterPoint.X,
+			cell.CenterPoint.Y)
+	}
+	// Output:
+	// cell-2: 0 points, center (12.5, 62.5)
+	// cell-10: 14 points, center (40.4, 90.4)
+	// cell-11: 84 points, center (60.3, 92.7)
+	// cell-12: 36 points, center (38.7, 70.8)
+	// cell-13: 29 points, center (56.0, 72.5)
+	// cell-7: 17 points, center (75.5, 88.3)
+	// cell-8: 86 points, center (57.0, 45.8)
+	// cell-9: 30 points, center (78.1, 42.4)
+	// cell-4: 0 points, center (12.5, 12.5)
+	// cell-5: 4 points, center (63.9, 23.3)
+}
+
+func ExampleConvTree_FindLeaf() {
+	tree := exampleTree(1000, 100)
+	leaf, ok := tree.FindLeaf(10, 10)
+	fmt.Println(ok, leaf.ID, leaf.Depth)
+	// Output: true cell-4 1
+}
+
+func ExampleSequentialIDs() {
+	next := convtree.SequentialIDs("node-")
+	fmt.Println(next(), next(), next())
+	// Output: node-1 node-2 node-3
+}
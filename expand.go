@@ -0,0 +1,84 @@
+package convtree
+
+import "math"
+
+// Expand grows the tree until p lies inside its bounds and returns the new root. Every step
+// creates a root twice as wide and twice as high, extended towards p, with the previous root as
+// one of its children and three new empty leaves as the others. On an axis where p is already
+// inside the bounds, the root is extended towards the nearer edge. The depth of every existing
+// node and the MaxDepth of the tree grow by one per step, so existing subtrees keep their
+// capacity to split.
+// If p is already inside the bounds, the tree itself is returned. The previous root keeps its ID
+// and remains valid as a subtree.
+func (tree *ConvTree) Expand(p Point) *ConvTree {
+	tree.config.lock()
+	defer tree.config.unlock()
+	if math.IsNaN(p.X) || math.IsNaN(p.Y) || math.IsInf(p.X, 0) || math.IsInf(p.Y, 0) {
+		return tree
+	}
+	root := tree
+	for !root.contains(p.X, p.Y) {
+		root = root.grow(p)
+	}
+	if root != tree {
+		root.syncParams()
+		tree.config.recordMutation(tree, func(node *ConvTree) error {
+			return ErrRebuildConflict
+		})
+	}
+	return root
+}
+
+func (tree *ConvTree) grow(p Point) *ConvTree {
+	width := tree.BottomRight.X - tree.TopLeft.X
+	height := tree.TopLeft.Y - tree.BottomRight.Y
+	right := p.X > tree.BottomRight.X || (p.X >= tree.TopLeft.X && p.X-tree.TopLeft.X > tree.BottomRight.X-p.X)
+	down := p.Y < tree.BottomRight.Y || (p.Y <= tree.TopLeft.Y && tree.TopLeft.Y-p.Y > p.Y-tree.BottomRight.Y)
+	topLeft, bottomRight := tree.TopLeft, tree.BottomRight
+	if right {
+		bottomRight.X += width
+	} else {
+		topLeft.X -= width
+	}
+	if down {
+		bottomRight.Y -= height
+	} else {
+		topLeft.Y += height
+	}
+	tree.walkNodes(func(node *ConvTree) bool {
+		node.Depth++
+		return true
+	})
+	tree.config.parameters().MaxDepth++
+	tree.config.addLeaves(3)
+	root := &ConvTree{
+		ID:           tree.config.newID(),
+		Depth:        tree.Depth - 1,
+		TopLeft:      topLeft,
+		BottomRight:  bottomRight,
+		LastInsertAt: tree.LastInsertAt,
+		config:       tree.config,
+	}
+	xSplit, ySplit := tree.BottomRight.X, tree.BottomRight.Y
+	if !right {
+		xSplit = tree.TopLeft.X
+	}
+	if !down {
+		ySplit = tree.TopLeft.Y
+	}
+	root.ChildTopLeft = root.newChild(topLeft, Point{X: xSplit, Y: ySplit}, []Point{})
+	root.ChildTopRight = root.newChild(Point{X: xSplit, Y: topLeft.Y}, Point{X: bottomRight.X, Y: ySplit}, []Point{})
+	root.ChildBottomLeft = root.newChild(Point{X: topLeft.X, Y: ySplit}, Point{X: xSplit, Y: bottomRight.Y}, []Point{})
+	root.ChildBottomRight = root.newChild(Point{X: xSplit, Y: ySplit}, bottomRight, []Point{})
+	switch {
+	case right && down:
+		root.ChildTopLeft = tree
+	case down:
+		root.ChildTopRight = tree
+	case right:
+		root.ChildBottomLeft = tree
+	default:
+		root.ChildBottomRight = tree
+	}
+	return root
+}
@@ -0,0 +1,71 @@
+package convtree
+
+import (
+	"math"
+	"reflect"
+)
+
+// FindLeaf returns the leaf whose bounds contain the coordinate. Points lying on a split line
+// resolve the same way Insert routes them: a point on the vertical split line belongs to the left
+// children and a point on the horizontal split line belongs to the top children.
+// The second return value is false when the coordinate is outside the node bounds.
+func (tree *ConvTree) FindLeaf(x, y float64) (*ConvTree, bool) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	if !tree.contains(x, y) {
+		return nil, false
+	}
+	return tree.findLeaf(x, y), true
+}
+
+func (tree *ConvTree) findLeaf(x, y float64) *ConvTree {
+	node := tree
+	for !node.IsLeaf {
+		node = node.childFor(x, y)
+	}
+	return node
+}
+
+func (tree *ConvTree) childFor(x, y float64) *ConvTree {
+	left := x <= tree.ChildTopLeft.BottomRight.X
+	top := y >= tree.ChildTopLeft.BottomRight.Y
+	switch {
+	case left && top:
+		return tree.ChildTopLeft
+	case top:
+		return tree.ChildTopRight
+	case left:
+		return tree.ChildBottomLeft
+	default:
+		return tree.ChildBottomRight
+	}
+}
+
+func (tree ConvTree) contains(x, y float64) bool {
+	return x >= tree.TopLeft.X && x <= tree.BottomRight.X && y <= tree.TopLeft.Y && y >= tree.BottomRight.Y
+}
+
+// Contains reports whether the tree holds a point whose coordinates differ from p by at most
+// epsilon on each axis. An epsilon of 0 requires exact equality. When p has non-nil Content,
+// the content must be deeply equal as well.
+func (tree *ConvTree) Contains(p Point, epsilon float64) bool {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	_, _, found := tree.locate(p, epsilon)
+	return found
+}
+
+// locate returns the leaf and the index of the first point matching p within epsilon.
+func (tree *ConvTree) locate(p Point, epsilon float64) (*ConvTree, int, bool) {
+	topLeft := Point{X: p.X - epsilon, Y: p.Y + epsilon}
+	bottomRight := Point{X: p.X + epsilon, Y: p.Y - epsilon}
+	for _, leaf := range tree.leavesInRegion(topLeft, bottomRight) {
+		for i, point := range leaf.Points {
+			if math.Abs(point.X-p.X) <= epsilon && math.Abs(point.Y-p.Y) <= epsilon &&
+				(p.Content == nil || reflect.DeepEqual(p.Content, point.Content)) {
+				return leaf, i, true
+			}
+		}
+	}
+	return nil, 0, false
+}
@@ -0,0 +1,83 @@
+package convtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestInsertOnSplitBoundaries(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 1, 2, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(24)), 200, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.IsLeaf {
+		t.Fatal("expected a split root")
+	}
+	xRight, yBottom := tree.ChildTopLeft.BottomRight.X, tree.ChildTopLeft.BottomRight.Y
+	tests := []struct {
+		name     string
+		point    Point
+		expected *ConvTree
+	}{
+		{"on xRight in the top half", Point{X: xRight, Y: (yBottom + 100) / 2}, tree.ChildTopLeft},
+		{"on xRight in the bottom half", Point{X: xRight, Y: yBottom / 2}, tree.ChildBottomLeft},
+		{"on yBottom in the left half", Point{X: xRight / 2, Y: yBottom}, tree.ChildTopLeft},
+		{"on yBottom in the right half", Point{X: (xRight + 100) / 2, Y: yBottom}, tree.ChildTopRight},
+		{"at the shared corner", Point{X: xRight, Y: yBottom}, tree.ChildTopLeft},
+		{"at the bottom right root corner", Point{X: 100, Y: 0}, tree.ChildBottomRight},
+	}
+	for _, test := range tests {
+		points := tree.Summary().Points
+		before := len(test.expected.Points)
+		test.point.Weight = 1
+		if err := tree.Insert(test.point, false); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := tree.Summary().Points; got != points+1 {
+			t.Fatalf("%s: the point was lost", test.name)
+		}
+		if len(test.expected.Points) != before+1 {
+			t.Fatalf("%s: the point was routed to the wrong child", test.name)
+		}
+		if leaf, ok := tree.FindLeaf(test.point.X, test.point.Y); !ok || leaf != test.expected {
+			t.Fatalf("%s: FindLeaf disagrees with Insert", test.name)
+		}
+	}
+	for _, point := range []Point{{X: -0.1, Y: 50}, {X: 50, Y: 100.1}, {X: 100.1, Y: 0}} {
+		if err := tree.Insert(point, true); err != ErrOutOfBounds {
+			t.Fatalf("point %v: expected ErrOutOfBounds, got %v", point, err)
+		}
+	}
+}
+
+func TestBoundaryPointsSurviveSplits(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 10, 6, 2, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(25))
+	inserted := 0
+	for i := 0; i < 500; i++ {
+		point := Point{X: r.Float64() * 100, Y: r.Float64() * 100, Weight: 1}
+		if !tree.IsLeaf && i%3 == 0 {
+			leaf := tree.Leaves()[r.Intn(len(tree.Leaves()))]
+			// Points on the corners of existing leaves lie on split lines of their ancestors.
+			point.X, point.Y = leaf.BottomRight.X, leaf.BottomRight.Y
+		}
+		if err := tree.Insert(point, true); err != nil {
+			t.Fatal(err)
+		}
+		inserted++
+	}
+	if got := tree.Summary().Points; got != inserted {
+		t.Fatalf("tree has %d points after inserting %d", got, inserted)
+	}
+	for _, leaf := range tree.Leaves() {
+		for _, point := range leaf.Points {
+			if found, _ := tree.FindLeaf(point.X, point.Y); found != leaf {
+				t.Fatalf("point %v is stored in leaf %s but routed to %s", point, leaf.ID, found.ID)
+			}
+		}
+	}
+}
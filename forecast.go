@@ -0,0 +1,163 @@
+package convtree
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// WeightSample is the total weight of a leaf at the moment of a RecordSample call.
+type WeightSample struct {
+	Sample uint64
+	Weight float64
+}
+
+// WithWeightHistory makes leaves keep the last length weight samples recorded by RecordSample.
+// When a leaf is split, its samples are distributed over the children in proportion to their
+// weight, and merged leaves sum their samples.
+func WithWeightHistory(length int) Option {
+	return func(config *treeConfig) {
+		config.historyLength = length
+	}
+}
+
+// RecordSample appends the current weight of every leaf to its history. It returns an error
+// if the tree was created without WithWeightHistory.
+func (tree *ConvTree) RecordSample() error {
+	tree.config.lock()
+	defer tree.config.unlock()
+	if tree.config == nil || tree.config.historyLength < 1 {
+		return errors.New("weight history is not enabled")
+	}
+	tree.config.samples++
+	sample := tree.config.samples
+	tree.recordSample(sample)
+	tree.config.recordMutation(tree, func(node *ConvTree) error {
+		node.recordSample(sample)
+		return nil
+	})
+	return nil
+}
+
+func (tree *ConvTree) recordSample(sample uint64) {
+	length := tree.config.historyLength
+	tree.walkLeaves(func(leaf *ConvTree) {
+		leaf.History = append(leaf.History, WeightSample{Sample: sample, Weight: float64(leaf.totalWeight())})
+		if len(leaf.History) > length {
+			leaf.History = append([]WeightSample{}, leaf.History[len(leaf.History)-length:]...)
+		}
+	})
+}
+
+// ForecastWeight fits a linear trend to the weight history of the leaf with the given ID and
+// returns the weight expected horizon samples after the last one, together with the standard
+// error of the forecast. With fewer than three samples the error is 0, and with a single sample
+// the forecast is that sample.
+func (tree *ConvTree) ForecastWeight(leafID string, horizon int) (float64, float64) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	forecast, stdErr := 0.0, 0.0
+	tree.walkNodes(func(node *ConvTree) bool {
+		if node.ID != leafID {
+			return true
+		}
+		if node.IsLeaf {
+			forecast, stdErr = forecastWeight(node.History, horizon)
+		}
+		return false
+	})
+	return forecast, stdErr
+}
+
+// LeavesForecastAbove returns the IDs of leaves whose forecast weight horizon samples ahead
+// exceeds threshold, in the same order as Leaves.
+func (tree *ConvTree) LeavesForecastAbove(threshold float64, horizon int) []string {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := []string{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		if len(leaf.History) == 0 {
+			return
+		}
+		if forecast, _ := forecastWeight(leaf.History, horizon); forecast > threshold {
+			result = append(result, leaf.ID)
+		}
+	})
+	return result
+}
+
+func forecastWeight(history []WeightSample, horizon int) (float64, float64) {
+	n := float64(len(history))
+	switch len(history) {
+	case 0:
+		return 0, 0
+	case 1:
+		return history[0].Weight, 0
+	}
+	meanX, meanY := 0.0, 0.0
+	for _, sample := range history {
+		meanX += float64(sample.Sample)
+		meanY += sample.Weight
+	}
+	meanX /= n
+	meanY /= n
+	sxx, sxy := 0.0, 0.0
+	for _, sample := range history {
+		dx := float64(sample.Sample) - meanX
+		sxx += dx * dx
+		sxy += dx * (sample.Weight - meanY)
+	}
+	slope := 0.0
+	if sxx > 0 {
+		slope = sxy / sxx
+	}
+	intercept := meanY - slope*meanX
+	x := float64(history[len(history)-1].Sample) + float64(horizon)
+	forecast := intercept + slope*x
+	if len(history) < 3 || sxx == 0 {
+		return forecast, 0
+	}
+	sse := 0.0
+	for _, sample := range history {
+		residual := sample.Weight - (intercept + slope*float64(sample.Sample))
+		sse += residual * residual
+	}
+	s := math.Sqrt(sse / (n - 2))
+	return forecast, s * math.Sqrt(1+1/n+(x-meanX)*(x-meanX)/sxx)
+}
+
+func scaleHistory(history []WeightSample, factor float64) []WeightSample {
+	if len(history) == 0 {
+		return nil
+	}
+	result := make([]WeightSample, len(history))
+	for i, sample := range history {
+		result[i] = WeightSample{Sample: sample.Sample, Weight: sample.Weight * factor}
+	}
+	return result
+}
+
+// mergeHistories sums the samples of the histories by sample number.
+func mergeHistories(histories [][]WeightSample) []WeightSample {
+	weights := map[uint64]float64{}
+	samples := []uint64{}
+	for _, history := range histories {
+		for _, sample := range history {
+			if _, ok := weights[sample.Sample]; !ok {
+				samples = append(samples, sample.Sample)
+			}
+			weights[sample.Sample] += sample.Weight
+		}
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+	result := make([]WeightSample, len(samples))
+	for i, sample := range samples {
+		result[i] = WeightSample{Sample: sample, Weight: weights[sample]}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package convtree
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestForecastWeightKnownTrend(t *testing.T) {
+	history := []WeightSample{{Sample: 1, Weight: 1}, {Sample: 2, Weight: 3}, {Sample: 3, Weight: 2}, {Sample: 4, Weight: 5}}
+	// The fit is 1.1 x with residuals -0.1, 0.8, -1.3 and 0.6, so s^2 = 2.7 / 2 and the error one
+	// sample ahead is s sqrt(1 + 1/4 + 2.5^2/5).
+	forecast, stdErr := forecastWeight(history, 1)
+	if math.Abs(forecast-5.5) > 1e-12 || math.Abs(stdErr-math.Sqrt(1.35*2.5)) > 1e-12 {
+		t.Fatalf("expected 5.5 with an error of %v, got %v and %v", math.Sqrt(1.35*2.5), forecast, stdErr)
+	}
+	if forecast, stdErr := forecastWeight(history[:1], 3); forecast != 1 || stdErr != 0 {
+		t.Fatalf("a single sample forecasts %v with an error of %v", forecast, stdErr)
+	}
+	if forecast, stdErr := forecastWeight(history[:2], 2); forecast != 7 || stdErr != 0 {
+		t.Fatalf("two samples forecast %v with an error of %v", forecast, stdErr)
+	}
+}
+
+func TestForecastWeightFromCheckpoints(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil,
+		WithWeightHistory(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.divide(50, 50)
+	growing, shrinking := tree.ChildTopLeft, tree.ChildBottomRight
+	addTagged(t, &tree, Point{X: 75, Y: 25}, "x", 20)
+	for i := 0; i < 8; i++ {
+		addTagged(t, &tree, Point{X: 25, Y: 75}, "x", 3)
+		for j := 0; j < 2; j++ {
+			if !tree.Remove(Point{X: 75, Y: 25, Content: []string{"x"}}, 0) {
+				t.Fatal("point was not removed")
+			}
+		}
+		if err := tree.RecordSample(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(growing.History) != 5 || growing.History[0].Sample != 4 || growing.History[4].Weight != 24 {
+		t.Fatalf("unexpected history %v", growing.History)
+	}
+	// The leaves gain 3 and lose 2 points per sample, so the fits are exact.
+	if forecast, stdErr := tree.ForecastWeight(growing.ID, 2); forecast != 30 || stdErr != 0 {
+		t.Fatalf("growing leaf forecasts %v with an error of %v", forecast, stdErr)
+	}
+	if forecast, stdErr := tree.ForecastWeight(shrinking.ID, 1); math.Abs(forecast-2) > 1e-9 || stdErr > 1e-9 {
+		t.Fatalf("shrinking leaf forecasts %v with an error of %v", forecast, stdErr)
+	}
+	if got := tree.LeavesForecastAbove(25, 1); !reflect.DeepEqual(got, []string{growing.ID}) {
+		t.Fatalf("expected only the growing leaf, got %v", got)
+	}
+	if forecast, stdErr := tree.ForecastWeight("unknown", 1); forecast != 0 || stdErr != 0 {
+		t.Fatalf("unknown leaf forecasts %v with an error of %v", forecast, stdErr)
+	}
+
+	disabled, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := disabled.RecordSample(); err == nil {
+		t.Fatal("samples were recorded without WithWeightHistory")
+	}
+}
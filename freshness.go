@@ -0,0 +1,18 @@
+package convtree
+
+import "time"
+
+// StaleLeaves returns the leaves that received no inserts during the last olderThan period.
+// Leaves that never received a point are considered stale.
+func (tree *ConvTree) StaleLeaves(olderThan time.Duration) []*ConvTree {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	threshold := tree.config.now().Add(-olderThan)
+	result := []*ConvTree{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		if leaf.LastInsertAt.Before(threshold) {
+			result = append(result, leaf)
+		}
+	})
+	return result
+}
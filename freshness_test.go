@@ -0,0 +1,172 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStaleLeavesWithFakeClock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		return now
+	}
+	start := now
+	points := uniformPoints(rand.New(rand.NewSource(4)), 400, 100)
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil, points,
+		WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.StaleLeaves(time.Hour)) != 0 {
+		t.Fatal("no leaf can be stale right after the tree is built")
+	}
+
+	now = now.Add(3 * time.Hour)
+	fresh := tree.Leaves()[0]
+	center := Point{X: (fresh.TopLeft.X + fresh.BottomRight.X) / 2, Y: (fresh.TopLeft.Y + fresh.BottomRight.Y) / 2,
+		Weight: 1}
+	if err := tree.Insert(center, false); err != nil {
+		t.Fatal(err)
+	}
+	if !fresh.LastInsertAt.Equal(now) {
+		t.Fatalf("leaf timestamp is %v instead of %v", fresh.LastInsertAt, now)
+	}
+	other := tree.Leaves()[1]
+	if !tree.Remove(other.Points[0], 0) {
+		t.Fatal("point was not removed")
+	}
+	if !other.LastInsertAt.Equal(start) {
+		t.Fatal("Remove must not update the leaf timestamp")
+	}
+	stale := tree.StaleLeaves(2 * time.Hour)
+	if len(stale) != len(tree.Leaves())-1 {
+		t.Fatalf("expected every leaf but one to be stale, got %d of %d", len(stale), len(tree.Leaves()))
+	}
+	for _, leaf := range stale {
+		if leaf == fresh {
+			t.Fatal("the leaf that received a point is reported as stale")
+		}
+	}
+	if stats := tree.Stats()[fresh.ID]; !stats.LastInsertAt.Equal(now) {
+		t.Fatalf("CellStats timestamp is %v instead of %v", stats.LastInsertAt, now)
+	}
+
+	now = now.Add(time.Hour)
+	if len(tree.StaleLeaves(30*time.Minute)) != len(tree.Leaves()) {
+		t.Fatal("every leaf must be stale after the clock advances past the threshold")
+	}
+
+	// Children of a split inherit the timestamp of the insert that triggered it.
+	for i := 0; fresh.IsLeaf; i++ {
+		if i > 1000 {
+			t.Fatal("the leaf was not split")
+		}
+		if err := tree.Insert(center, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, leaf := range fresh.Leaves() {
+		if len(leaf.Points) > 0 && !leaf.LastInsertAt.Equal(now) {
+			t.Fatalf("child %s has timestamp %v instead of %v", leaf.ID, leaf.LastInsertAt, now)
+		}
+	}
+
+	data, err := tree.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := UnmarshalConvTree(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves, decodedLeaves := tree.Leaves(), decoded.Leaves()
+	for i := range leaves {
+		if !leaves[i].LastInsertAt.Equal(decodedLeaves[i].LastInsertAt) {
+			t.Fatalf("leaf %s lost its timestamp when serialized", leaves[i].ID)
+		}
+	}
+}
+
+func TestLastInsertAtExports(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(4)), 400, 50), WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(90 * time.Minute)
+	if err := tree.Insert(Point{X: 10, Y: 10, Weight: 1}, false); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{}
+	for _, leaf := range tree.Leaves() {
+		if !leaf.LastInsertAt.IsZero() {
+			expected[leaf.ID] = leaf.LastInsertAt.Format(time.RFC3339Nano)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tree.WriteCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records[0][9] != "leaf_last_insert_at" {
+		t.Fatalf("unexpected header %v", records[0])
+	}
+	for _, record := range records[1:] {
+		x, _ := strconv.ParseFloat(record[0], 64)
+		y, _ := strconv.ParseFloat(record[1], 64)
+		leaf, _ := tree.FindLeaf(x, y)
+		if record[9] != expected[leaf.ID] {
+			t.Fatalf("row %v has last insert %q, expected %q", record, record[9], expected[leaf.ID])
+		}
+	}
+
+	data, err := tree.ToGeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	document := struct {
+		Features []struct {
+			Properties struct {
+				ID           string  `json:"id"`
+				LastInsertAt *string `json:"last_insert_at"`
+			} `json:"properties"`
+		} `json:"features"`
+	}{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	empty := 0
+	for _, feature := range document.Features {
+		want, ok := expected[feature.Properties.ID]
+		got := feature.Properties.LastInsertAt
+		if !ok {
+			empty++
+			if got != nil {
+				t.Fatalf("leaf %s without inserts has last_insert_at %q", feature.Properties.ID, *got)
+			}
+			continue
+		}
+		if got == nil || *got != want {
+			t.Fatalf("leaf %s has last_insert_at %v, expected %q", feature.Properties.ID, got, want)
+		}
+	}
+	if empty == 0 {
+		t.Fatal("expected leaves without inserts")
+	}
+
+	for _, record := range tree.LeafRecords() {
+		if want := expected[record.ID]; want != "" && record.LastInsertAt.Format(time.RFC3339Nano) != want {
+			t.Fatalf("record %s has last insert %v, expected %s", record.ID, record.LastInsertAt, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package convtree
+
+import (
+	"errors"
+	"math"
+)
+
+// NewConvTreeFromGrid builds a tree from pre-aggregated counts instead of individual points.
+// counts is a raster in row-major order with row 0 at the top, covering the bounds with cells of
+// equal size. Every non-zero cell becomes a pseudo-point at the cell center whose weight is the
+// count rounded to the nearest integer, so leaves hold aggregate weights rather than raw events.
+func NewConvTreeFromGrid(topLeft, bottomRight Point, counts [][]float64, params Config, opts ...Option) (*ConvTree, error) {
+	if len(counts) == 0 || len(counts[0]) == 0 {
+		return nil, errors.New("grid is empty")
+	}
+	rows, columns := len(counts), len(counts[0])
+	cellWidth := (bottomRight.X - topLeft.X) / float64(columns)
+	cellHeight := (topLeft.Y - bottomRight.Y) / float64(rows)
+	points := []Point{}
+	for i, row := range counts {
+		if len(row) != columns {
+			return nil, errors.New("grid rows have different lengths")
+		}
+		for j, count := range row {
+			if count < 0 || math.IsNaN(count) || math.IsInf(count, 0) {
+				return nil, errors.New("grid counts must be finite and non-negative")
+			}
+			weight := int(math.Round(count))
+			if weight == 0 {
+				continue
+			}
+			points = append(points, Point{
+				X:      topLeft.X + (float64(j)+0.5)*cellWidth,
+				Y:      topLeft.Y - (float64(i)+0.5)*cellHeight,
+				Weight: weight,
+			})
+		}
+	}
+	tree, err := NewConvTree(topLeft, bottomRight, params.MinXLength, params.MinYLength, params.MaxPoints,
+		params.MaxDepth, params.ConvNum, params.GridSize, params.Kernel, points, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
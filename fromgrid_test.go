@@ -0,0 +1,90 @@
+package convtree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNewConvTreeFromGrid(t *testing.T) {
+	counts := [][]float64{
+		{0, 2.4, 0, 5},
+		{0.4, 0, 1.6, 0},
+		{7, 0, 0, 0},
+	}
+	params := Config{MinXLength: 1, MinYLength: 1, MaxPoints: 100, MaxDepth: 4, ConvNum: 1, GridSize: 8}
+	tree, err := NewConvTreeFromGrid(Point{X: 0, Y: 30}, Point{X: 40, Y: 0}, counts, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cells are 10 x 10 and row 0 is at the top; the 0.4 cell rounds to zero and is dropped.
+	want := [][2]float64{{5, 5}, {15, 25}, {25, 15}, {35, 25}}
+	weights := map[[2]float64]int{{5, 5}: 7, {15, 25}: 2, {25, 15}: 2, {35, 25}: 5}
+	got := sortedXY(tree.Points)
+	if len(got) != len(want) {
+		t.Fatalf("expected pseudo-points %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected pseudo-points %v, got %v", want, got)
+		}
+	}
+	for _, point := range tree.Points {
+		if point.Weight != weights[[2]float64{point.X, point.Y}] {
+			t.Fatalf("point %v has weight %d", point, point.Weight)
+		}
+	}
+	if tree.Summary().TotalWeight != 16 {
+		t.Fatalf("expected a total weight of 16, got %d", tree.Summary().TotalWeight)
+	}
+}
+
+func TestNewConvTreeFromGridSplits(t *testing.T) {
+	r := rand.New(rand.NewSource(97))
+	counts := make([][]float64, 20)
+	points := []Point{}
+	for i := range counts {
+		counts[i] = make([]float64, 30)
+		for j := range counts[i] {
+			counts[i][j] = math.Floor(r.Float64() * 40)
+			if counts[i][j] > 0 {
+				points = append(points, Point{X: float64(j) + 0.5, Y: 20 - float64(i) - 0.5, Weight: int(counts[i][j])})
+			}
+		}
+	}
+	params := Config{MinXLength: 1, MinYLength: 1, MaxPoints: 500, MaxDepth: 6, ConvNum: 1, GridSize: 8}
+	fromGrid, err := NewConvTreeFromGrid(Point{X: 0, Y: 20}, Point{X: 30, Y: 0}, counts, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := NewConvTree(Point{X: 0, Y: 20}, Point{X: 30, Y: 0}, 1, 1, 500, 6, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, want := fromGrid.Leaves(), expected.Leaves()
+	if len(got) < 2 || len(got) != len(want) {
+		t.Fatalf("expected %d leaves, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].TopLeft != want[i].TopLeft || got[i].BottomRight != want[i].BottomRight ||
+			got[i].totalWeight() != want[i].totalWeight() {
+			t.Fatalf("leaf %d differs from the tree built from the same points", i)
+		}
+	}
+}
+
+func TestNewConvTreeFromGridRejectsInvalidCounts(t *testing.T) {
+	params := Config{MinXLength: 1, MinYLength: 1, MaxPoints: 100, MaxDepth: 4, ConvNum: 1, GridSize: 8}
+	for name, counts := range map[string][][]float64{
+		"empty":    {},
+		"no cells": {{}},
+		"ragged":   {{1, 2}, {3}},
+		"negative": {{1, -2}},
+		"NaN":      {{math.NaN()}},
+		"infinite": {{math.Inf(1)}},
+	} {
+		if _, err := NewConvTreeFromGrid(Point{X: 0, Y: 10}, Point{X: 10, Y: 0}, counts, params); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
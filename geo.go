@@ -0,0 +1,66 @@
+package convtree
+
+import "math"
+
+const earthRadius = 6371008.8
+
+// haversine returns the great-circle distance in meters between two points given as X - longitude
+// and Y - latitude in degrees.
+func haversine(a, b Point) float64 {
+	lat1, lat2 := a.Y*math.Pi/180, b.Y*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.X - a.X) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// WithGeodesicStats treats X as longitude and Y as latitude in degrees when computing cell
+// statistics. CenterPoint becomes the weighted centroid on the sphere and distance statistics are
+// great-circle distances in meters. Area and Density stay in square degrees.
+func WithGeodesicStats() Option {
+	return func(config *treeConfig) {
+		config.geodesic = true
+	}
+}
+
+// sphericalCentroid returns the weighted mean of the points as unit vectors projected back onto
+// the sphere. Points must have a positive total weight.
+func sphericalCentroid(points []Point) Point {
+	x, y, z := 0.0, 0.0, 0.0
+	for _, point := range points {
+		lon, lat := point.X*math.Pi/180, point.Y*math.Pi/180
+		weight := float64(point.Weight)
+		x += weight * math.Cos(lat) * math.Cos(lon)
+		y += weight * math.Cos(lat) * math.Sin(lon)
+		z += weight * math.Sin(lat)
+	}
+	return Point{
+		X: math.Atan2(y, x) * 180 / math.Pi,
+		Y: math.Atan2(z, math.Hypot(x, y)) * 180 / math.Pi,
+	}
+}
+
+func euclidean(a, b Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+// geodesicBounds returns the longitude/latitude rectangle that contains every point lying within
+// radius meters of the center.
+func geodesicBounds(center Point, radius float64) (topLeft, bottomRight Point) {
+	angular := radius / earthRadius
+	lat := center.Y * math.Pi / 180
+	minLat, maxLat := lat-angular, lat+angular
+	minLon, maxLon := -math.Pi, math.Pi
+	if minLat > -math.Pi/2 && maxLat < math.Pi/2 {
+		dLon := math.Asin(math.Min(1, math.Sin(angular)/math.Cos(lat)))
+		lon := center.X * math.Pi / 180
+		minLon, maxLon = lon-dLon, lon+dLon
+		if dLon >= math.Pi/2 || minLon < -math.Pi || maxLon > math.Pi {
+			minLon, maxLon = -math.Pi, math.Pi
+		}
+	}
+	minLat, maxLat = math.Max(minLat, -math.Pi/2), math.Min(maxLat, math.Pi/2)
+	topLeft = Point{X: minLon * 180 / math.Pi, Y: maxLat * 180 / math.Pi}
+	bottomRight = Point{X: maxLon * 180 / math.Pi, Y: minLat * 180 / math.Pi}
+	return topLeft, bottomRight
+}
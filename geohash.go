@@ -0,0 +1,91 @@
+package convtree
+
+import (
+	"errors"
+	"math"
+)
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// LeafGeohashes returns the geohash cells of the given precision covering every leaf, keyed by
+// leaf ID, with X as longitude and Y as latitude. Leaves are clipped to the valid coordinate range
+// and cells only touching a leaf along an edge are left out. The number of cells grows by a factor
+// of 32 with every precision step, so high precisions are only practical for small leaves.
+func (tree *ConvTree) LeafGeohashes(precision int) (map[string][]string, error) {
+	if precision < 1 || precision > 12 {
+		return nil, errors.New("geohash precision must be between 1 and 12")
+	}
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	lonBits := (5*precision + 1) / 2
+	latBits := 5 * precision / 2
+	cellWidth := 360 / math.Exp2(float64(lonBits))
+	cellHeight := 180 / math.Exp2(float64(latBits))
+	result := map[string][]string{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		cells := []string{}
+		left, right := geohashRange(leaf.TopLeft.X, leaf.BottomRight.X, -180, 180, cellWidth)
+		bottom, top := geohashRange(leaf.BottomRight.Y, leaf.TopLeft.Y, -90, 90, cellHeight)
+		for j := top; j >= bottom; j-- {
+			for i := left; i <= right; i++ {
+				lon := -180 + (float64(i)+0.5)*cellWidth
+				lat := -90 + (float64(j)+0.5)*cellHeight
+				cells = append(cells, encodeGeohash(lon, lat, precision))
+			}
+		}
+		result[leaf.ID] = cells
+	})
+	return result, nil
+}
+
+// geohashRange returns the indices of the first and the last cell of the given size overlapping
+// the interval from low to high after clipping it to the range from min to max.
+func geohashRange(low, high, min, max, size float64) (int, int) {
+	low, high = math.Max(low, min), math.Min(high, max)
+	first := int(math.Floor((low - min) / size))
+	last := int(math.Ceil((high-min)/size)) - 1
+	cells := int(math.Round((max - min) / size))
+	if first >= cells {
+		first = cells - 1
+	}
+	if last < first {
+		last = first
+	}
+	return first, last
+}
+
+// encodeGeohash returns the geohash of the given precision containing the coordinate.
+func encodeGeohash(lon, lat float64, precision int) string {
+	lonMin, lonMax := -180.0, 180.0
+	latMin, latMax := -90.0, 90.0
+	hash := make([]byte, 0, precision)
+	bit, value, even := 0, 0, true
+	for len(hash) < precision {
+		if even {
+			middle := (lonMin + lonMax) / 2
+			if lon >= middle {
+				value = value<<1 | 1
+				lonMin = middle
+			} else {
+				value <<= 1
+				lonMax = middle
+			}
+		} else {
+			middle := (latMin + latMax) / 2
+			if lat >= middle {
+				value = value<<1 | 1
+				latMin = middle
+			} else {
+				value <<= 1
+				latMax = middle
+			}
+		}
+		even = !even
+		bit++
+		if bit == 5 {
+			hash = append(hash, geohashAlphabet[value])
+			bit, value = 0, 0
+		}
+	}
+	return string(hash)
+}
@@ -0,0 +1,179 @@
+package convtree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+type geoJSONConfig struct {
+	nonEmpty bool
+}
+
+type GeoJSONOption func(*geoJSONConfig)
+
+// GeoJSONNonEmptyOnly leaves out leaves without points.
+func GeoJSONNonEmptyOnly() GeoJSONOption {
+	return func(config *geoJSONConfig) {
+		config.nonEmpty = true
+	}
+}
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// ToGeoJSON returns a FeatureCollection with a Polygon feature for every leaf. Coordinates are
+// [X, Y] pairs, i.e. longitude and latitude. Feature properties hold the leaf id, depth, number of
+// points, total weight and baseline tags, and the root edges the leaf touches in boundary_edges,
+// with boundary set when there is at least one. value_count, value_min, value_max and value_mean
+// describe the values extracted in the leaf; the last three are null without values.
+// last_insert_at is the RFC 3339 time of the last insert into the leaf, or null.
+func (tree *ConvTree) ToGeoJSON(opts ...GeoJSONOption) ([]byte, error) {
+	config := geoJSONConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	collection := geoJSONCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		if config.nonEmpty && len(leaf.Points) == 0 {
+			return
+		}
+		flags := tree.boundaryEdges(leaf)
+		edges := []string{}
+		for _, edge := range []struct {
+			name    string
+			touches bool
+		}{{"top", flags.Top}, {"right", flags.Right}, {"bottom", flags.Bottom}, {"left", flags.Left}} {
+			if edge.touches {
+				edges = append(edges, edge.name)
+			}
+		}
+		tags := leaf.BaselineTags
+		if tags == nil {
+			tags = []string{}
+		}
+		properties := map[string]interface{}{
+			"id":             leaf.ID,
+			"depth":          leaf.Depth,
+			"points":         len(leaf.Points),
+			"weight":         leaf.totalWeight(),
+			"baseline_tags":  tags,
+			"boundary":       len(edges) > 0,
+			"boundary_edges": edges,
+			"value_count":    leaf.Values.Count,
+			"value_min":      nil,
+			"value_max":      nil,
+			"value_mean":     nil,
+			"last_insert_at": nil,
+		}
+		if !leaf.LastInsertAt.IsZero() {
+			properties["last_insert_at"] = leaf.LastInsertAt.Format(time.RFC3339Nano)
+		}
+		if leaf.Values.Count > 0 {
+			properties["value_min"] = leaf.Values.Min
+			properties["value_max"] = leaf.Values.Max
+			properties["value_mean"] = leaf.Values.Sum / float64(leaf.Values.Count)
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONGeometry{Type: "Polygon", Coordinates: [][][2]float64{cellRing(leaf.TopLeft, leaf.BottomRight)}},
+			Properties: properties,
+		})
+	})
+	return json.Marshal(collection)
+}
+
+// cellRing returns the closed counterclockwise ring of the rectangle as [X, Y] pairs.
+func cellRing(topLeft, bottomRight Point) [][2]float64 {
+	left, top, right, bottom := topLeft.X, topLeft.Y, bottomRight.X, bottomRight.Y
+	return [][2]float64{{left, bottom}, {right, bottom}, {right, top}, {left, top}, {left, bottom}}
+}
+
+// CachedGeoJSON works as ToGeoJSON but returns the document memoized by WithProductCache while the
+// tree is not mutated.
+func (tree *ConvTree) CachedGeoJSON(opts ...GeoJSONOption) ([]byte, error) {
+	config := geoJSONConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	key := "geojson"
+	if config.nonEmpty {
+		key = "geojson:non-empty"
+	}
+	return tree.CachedProduct(key, func() ([]byte, error) {
+		return tree.ToGeoJSON(opts...)
+	})
+}
+
+// PointsToGeoJSON returns a FeatureCollection with a Point feature for every stored point. Feature
+// properties hold the point weight and, when Content is a []string, its tags.
+func (tree *ConvTree) PointsToGeoJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := tree.WritePointsGeoJSON(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WritePointsGeoJSON writes the document of PointsToGeoJSON to w one feature at a time.
+func (tree *ConvTree) WritePointsGeoJSON(w io.Writer) error {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	writer := bufio.NewWriter(w)
+	if _, err := writer.WriteString(`{"type":"FeatureCollection","features":[`); err != nil {
+		return err
+	}
+	first := true
+	var err error
+	tree.walkLeaves(func(leaf *ConvTree) {
+		for _, point := range leaf.Points {
+			if err != nil {
+				return
+			}
+			properties := map[string]interface{}{"weight": point.Weight}
+			if tags, ok := pointTags(point); ok {
+				properties["tags"] = tags
+			}
+			var data []byte
+			data, err = json.Marshal(geoJSONFeature{
+				Type:       "Feature",
+				Geometry:   geoJSONGeometry{Type: "Point", Coordinates: [2]float64{point.X, point.Y}},
+				Properties: properties,
+			})
+			if err != nil {
+				return
+			}
+			if !first {
+				err = writer.WriteByte(',')
+			}
+			first = false
+			if err == nil {
+				_, err = writer.Write(data)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := writer.WriteString("]}"); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,77 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+)
+
+func init() {
+	gob.Register([]string{})
+}
+
+// GobEncode encodes the node the same way as MarshalJSON. Payloads and point Content of any type
+// other than []string are dropped.
+func (tree ConvTree) GobEncode() ([]byte, error) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(tree.toDocument()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode replaces the tree with the decoded one. The decoded tree has default options, so
+// a tree encoded with a max points schedule can only be decoded by DecodeGob.
+func (tree *ConvTree) GobDecode(data []byte) error {
+	doc := gobDocument{}
+	if err := doc.GobDecode(data); err != nil {
+		return err
+	}
+	decoded, err := doc.toTree(nil)
+	if err != nil {
+		return err
+	}
+	*tree = *decoded
+	return nil
+}
+
+// DecodeGob reads a single tree written by a gob.Encoder from r. The options are applied as in
+// UnmarshalConvTree.
+func DecodeGob(r io.Reader, opts ...Option) (*ConvTree, error) {
+	doc := gobDocument{}
+	if err := gob.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc.toTree(opts)
+}
+
+// gobDocument receives the document of a tree encoded by ConvTree.GobEncode before the tree is
+// built from it.
+type gobDocument struct {
+	treeDocument
+}
+
+func (doc *gobDocument) GobDecode(data []byte) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(&doc.treeDocument)
+}
+
+// GobEncode encodes the point keeping Content only if it is a []string.
+func (point Point) GobEncode() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(newPointRecord(point)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode decodes a point encoded by GobEncode.
+func (point *Point) GobDecode(data []byte) error {
+	record := pointRecord{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&record); err != nil {
+		return err
+	}
+	*point = record.point()
+	return nil
+}
@@ -0,0 +1,106 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/gob"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestGobRoundTripKeepsInserting(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	points := uniformPoints(r, 2000, 100)
+	for i := range points {
+		if i%2 == 0 {
+			points[i].Content = []string{"tag"}
+		}
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.IsLeaf {
+		t.Fatal("expected a split tree")
+	}
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(tree); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &ConvTree{}
+	if err := gob.NewDecoder(buf).Decode(decoded); err != nil {
+		t.Fatal(err)
+	}
+	compareStructure(t, &tree, decoded)
+	leaves := decoded.Summary().Leaves
+	for _, point := range uniformPoints(r, 2000, 100) {
+		if err := decoded.Insert(point, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if decoded.Summary().Leaves <= leaves {
+		t.Fatal("inserting into the decoded tree did not split any leaf")
+	}
+	if got := decoded.Summary().Points; got != 4000 {
+		t.Fatalf("decoded tree has %d points after inserting, expected 4000", got)
+	}
+	checkLeafCount(t, decoded)
+	for _, leaf := range decoded.Leaves() {
+		for _, point := range leaf.Points {
+			if point.X < leaf.TopLeft.X || point.X > leaf.BottomRight.X ||
+				point.Y < leaf.BottomRight.Y || point.Y > leaf.TopLeft.Y {
+				t.Fatalf("point %v is routed to leaf %s outside of its bounds", point, leaf.ID)
+			}
+		}
+	}
+}
+
+func TestGobPointDropsUnknownContent(t *testing.T) {
+	type label struct{ Name string }
+	points := []Point{
+		{X: 1, Y: 2, Weight: 3, Content: label{Name: "a"}},
+		{X: 4, Y: 5, Weight: 6, Content: []string{"b", "c"}},
+	}
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(points); err != nil {
+		t.Fatal(err)
+	}
+	decoded := []Point{}
+	if err := gob.NewDecoder(buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	expected := []Point{{X: 1, Y: 2, Weight: 3}, {X: 4, Y: 5, Weight: 6, Content: []string{"b", "c"}}}
+	if !reflect.DeepEqual(decoded, expected) {
+		t.Fatalf("expected %v, got %v", expected, decoded)
+	}
+	var content interface{} = []string{"d"}
+	buf.Reset()
+	if err := gob.NewEncoder(buf).Encode(&content); err != nil {
+		t.Fatalf("[]string is not registered with gob: %v", err)
+	}
+}
+
+func TestDecodeGobWithSchedule(t *testing.T) {
+	schedule := ExponentialSchedule(200, 2)
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(5)), 3000, 100), WithMaxPointsSchedule(schedule))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := gob.NewEncoder(buf).Encode(tree); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&ConvTree{}); err == nil {
+		t.Fatal("expected an error decoding a scheduled tree with default options")
+	}
+	decoded, err := DecodeGob(bytes.NewReader(data), WithMaxPointsSchedule(schedule))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStructure(t, &tree, decoded)
+	if _, err := DecodeGob(bytes.NewReader(data), WithMaxPointsSchedule(LinearSchedule(200, 2))); err == nil {
+		t.Fatal("expected an error for a different schedule")
+	}
+}
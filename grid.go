@@ -0,0 +1,66 @@
+package convtree
+
+import "sync"
+
+// grid is a w x h matrix stored in a single slice. Cell (x, y) is the x-th column from the left
+// and the y-th row from the bottom, as in the grids returned by DensityGrid.
+type grid struct {
+	w, h int
+	data []float64
+}
+
+// newGrid copies values, indexed as values[x][y], into a grid. All rows must have the same length.
+func newGrid(values [][]float64) *grid {
+	result := &grid{w: len(values)}
+	if result.w > 0 {
+		result.h = len(values[0])
+	}
+	result.data = make([]float64, 0, result.w*result.h)
+	for _, row := range values {
+		result.data = append(result.data, row...)
+	}
+	return result
+}
+
+func (g *grid) At(x, y int) float64 {
+	return g.data[x*g.h+y]
+}
+
+func (g *grid) Set(x, y int, value float64) {
+	g.data[x*g.h+y] = value
+}
+
+// rows returns the grid as values[x][y]. The rows share the grid data.
+func (g *grid) rows() [][]float64 {
+	result := make([][]float64, g.w)
+	for x := range result {
+		result[x] = g.data[x*g.h : (x+1)*g.h : (x+1)*g.h]
+	}
+	return result
+}
+
+// gridPools holds a sync.Pool of released grids for every grid size. A grid belongs to the caller
+// from getGrid until it is passed to putGrid, so splits running in parallel never share one.
+var gridPools sync.Map
+
+// getGrid returns a zeroed w x h grid, reusing a released one when available.
+func getGrid(w, h int) *grid {
+	if pool, ok := gridPools.Load([2]int{w, h}); ok {
+		if released, ok := pool.(*sync.Pool).Get().(*grid); ok {
+			for i := range released.data {
+				released.data[i] = 0
+			}
+			return released
+		}
+	}
+	return &grid{w: w, h: h, data: make([]float64, w*h)}
+}
+
+// putGrid releases a grid obtained from getGrid. The grid must not be used afterwards.
+func putGrid(released *grid) {
+	if len(released.data) == 0 {
+		return
+	}
+	pool, _ := gridPools.LoadOrStore([2]int{released.w, released.h}, &sync.Pool{})
+	pool.(*sync.Pool).Put(released)
+}
@@ -0,0 +1,124 @@
+package convtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGetGridZeroesReusedGrids(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		used := getGrid(7, 5)
+		for j := range used.data {
+			if used.data[j] != 0 {
+				t.Fatalf("grid cell %d is %v instead of 0", j, used.data[j])
+			}
+			used.data[j] = float64(j + 1)
+		}
+		putGrid(used)
+	}
+	if other := getGrid(5, 7); other.w != 5 || other.h != 7 || len(other.data) != 35 {
+		t.Fatalf("got a %dx%d grid with %d cells instead of 5x7", other.w, other.h, len(other.data))
+	}
+}
+
+func TestGridIndexing(t *testing.T) {
+	values := [][]float64{
+		{0, 1, 2, 3, 4},
+		{10, 11, 12, 13, 14},
+		{20, 21, 22, 23, 24},
+	}
+	g := newGrid(values)
+	if g.w != 3 || g.h != 5 || len(g.data) != 15 {
+		t.Fatalf("expected a 3x5 grid, got %dx%d with %d cells", g.w, g.h, len(g.data))
+	}
+	for x := range values {
+		for y := range values[x] {
+			if g.At(x, y) != values[x][y] || g.data[x*5+y] != values[x][y] {
+				t.Fatalf("cell (%d, %d) is %v instead of %v", x, y, g.At(x, y), values[x][y])
+			}
+		}
+	}
+	g.Set(2, 4, -1)
+	g.Set(0, 4, -2)
+	if g.data[14] != -1 || g.data[4] != -2 || values[2][4] != 24 {
+		t.Fatal("Set wrote the wrong cell or changed the source values")
+	}
+
+	rows := g.rows()
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+	for x, row := range rows {
+		if len(row) != 5 || cap(row) != 5 {
+			t.Fatalf("row %d has length %d and capacity %d instead of 5", x, len(row), cap(row))
+		}
+		for y := range row {
+			if row[y] != g.At(x, y) {
+				t.Fatalf("rows()[%d][%d] is %v instead of %v", x, y, row[y], g.At(x, y))
+			}
+		}
+	}
+	rows[1][2] = 99
+	if g.At(1, 2) != 99 {
+		t.Fatal("rows do not share the grid data")
+	}
+	// Appending to a row must not overwrite the next one.
+	_ = append(rows[0], 100)
+	if g.At(1, 0) != 10 {
+		t.Fatal("appending to a row overwrote the next row")
+	}
+}
+
+// TestDensityGridOrientation checks that the first index of DensityGrid goes from left to right and
+// the second one from bottom to top.
+func TestDensityGridOrientation(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 40}, Point{X: 80, Y: 0}, 1, 1, 1000, 4, 0, 4, nil,
+		[]Point{{X: 5, Y: 35, Weight: 4}, {X: 70, Y: 12, Weight: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := tree.DensityGrid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for x, row := range values {
+		for y, value := range row {
+			expected := 0.0
+			switch {
+			case x == 0 && y == 3:
+				expected = 1
+			case x == 3 && y == 1:
+				expected = 0.5
+			}
+			if value != expected {
+				t.Fatalf("cell (%d, %d) is %v instead of %v: %v", x, y, value, expected, values)
+			}
+		}
+	}
+}
+
+func BenchmarkDensityGrid(b *testing.B) {
+	tree, err := NewConvTree(Point{X: 0, Y: 1}, Point{X: 1, Y: 0}, 0, 0, 1<<30, 6, 3, 32, nil, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	tree.Points = uniformPoints(rand.New(rand.NewSource(1)), 2000, 1)
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			result, _, _ := tree.densityGrid()
+			putGrid(result)
+		}
+	})
+	// The original pipeline allocates a new grid for the weights and for every convolution.
+	b.Run("reference", func(b *testing.B) {
+		kernel := defaultKernel()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			values := referenceWeightGrid(&tree, 32)
+			for j := 0; j < 3; j++ {
+				values = referenceConvolve(values, kernel, 1, 1)
+			}
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package convtree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// RenderHeatmap writes a PNG of pixelWidth x pixelHeight pixels in which every leaf spreads its
+// weight evenly over its area, so leaves smaller than a pixel add up instead of overwriting each
+// other. Pixel values are scaled by the largest one and mapped to a ramp from transparent through
+// yellow to red.
+func (tree *ConvTree) RenderHeatmap(w io.Writer, pixelWidth, pixelHeight int) error {
+	if pixelWidth < 1 || pixelHeight < 1 {
+		return errors.New("image width and height must be positive")
+	}
+	tree.config.rLock()
+	pixels := tree.rasterize(pixelWidth, pixelHeight)
+	tree.config.rUnlock()
+	maxValue := 0.0
+	for _, row := range pixels {
+		for _, value := range row {
+			maxValue = math.Max(maxValue, value)
+		}
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, pixelWidth, pixelHeight))
+	for y, row := range pixels {
+		for x, value := range row {
+			if maxValue > 0 {
+				img.SetNRGBA(x, y, heatColor(value/maxValue))
+			}
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// CachedRaster works as RenderHeatmap but returns the PNG memoized by WithProductCache while the
+// tree is not mutated.
+func (tree *ConvTree) CachedRaster(pixelWidth, pixelHeight int) ([]byte, error) {
+	return tree.CachedProduct(fmt.Sprintf("heatmap:%dx%d", pixelWidth, pixelHeight), func() ([]byte, error) {
+		buf := &bytes.Buffer{}
+		if err := tree.RenderHeatmap(buf, pixelWidth, pixelHeight); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+}
+
+// rasterize returns the weight falling into every pixel, indexed by row from the top and column
+// from the left.
+func (tree *ConvTree) rasterize(pixelWidth, pixelHeight int) [][]float64 {
+	pixels := make([][]float64, pixelHeight)
+	for i := range pixels {
+		pixels[i] = make([]float64, pixelWidth)
+	}
+	scaleX := float64(pixelWidth) / (tree.BottomRight.X - tree.TopLeft.X)
+	scaleY := float64(pixelHeight) / (tree.TopLeft.Y - tree.BottomRight.Y)
+	tree.walkLeaves(func(leaf *ConvTree) {
+		weight := leaf.totalWeight()
+		if weight == 0 {
+			return
+		}
+		left := (leaf.TopLeft.X - tree.TopLeft.X) * scaleX
+		right := (leaf.BottomRight.X - tree.TopLeft.X) * scaleX
+		top := (tree.TopLeft.Y - leaf.TopLeft.Y) * scaleY
+		bottom := (tree.TopLeft.Y - leaf.BottomRight.Y) * scaleY
+		density := float64(weight) / ((right - left) * (bottom - top))
+		for y := int(top); y < pixelHeight && float64(y) < bottom; y++ {
+			height := math.Min(bottom, float64(y+1)) - math.Max(top, float64(y))
+			for x := int(left); x < pixelWidth && float64(x) < right; x++ {
+				width := math.Min(right, float64(x+1)) - math.Max(left, float64(x))
+				pixels[y][x] += density * width * height
+			}
+		}
+	})
+	return pixels
+}
+
+// heatColor maps a value in [0, 1] to a color going from transparent yellow to opaque red.
+func heatColor(value float64) color.NRGBA {
+	return color.NRGBA{R: 255, G: uint8(255 * (1 - value)), B: 0, A: uint8(255 * value)}
+}
@@ -0,0 +1,51 @@
+package convtree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// uniformPoints returns n points of weight 1 spread uniformly over [0, size] x [0, size].
+func uniformPoints(r *rand.Rand, n int, size float64) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{X: r.Float64() * size, Y: r.Float64() * size, Weight: 1}
+	}
+	return points
+}
+
+// checkLeafCount fails the test unless the leaf counter of the tree matches its actual leaves.
+func checkLeafCount(t *testing.T, tree *ConvTree) {
+	t.Helper()
+	stats := tree.Summary()
+	if stats.TotalLeaves != stats.Leaves {
+		t.Fatalf("leaf counter is %d, but the tree has %d leaves", stats.TotalLeaves, stats.Leaves)
+	}
+}
+
+// checkTiling fails the test unless the leaves lie inside the tree bounds, do not overlap and
+// cover its whole area.
+func checkTiling(t *testing.T, tree *ConvTree) {
+	t.Helper()
+	leaves := tree.Leaves()
+	area := 0.0
+	for i, leaf := range leaves {
+		if leaf.TopLeft.X < tree.TopLeft.X || leaf.BottomRight.X > tree.BottomRight.X ||
+			leaf.TopLeft.Y > tree.TopLeft.Y || leaf.BottomRight.Y < tree.BottomRight.Y {
+			t.Fatalf("leaf %s is outside of the tree bounds", leaf.ID)
+		}
+		area += (leaf.BottomRight.X - leaf.TopLeft.X) * (leaf.TopLeft.Y - leaf.BottomRight.Y)
+		for _, other := range leaves[i+1:] {
+			width := math.Min(leaf.BottomRight.X, other.BottomRight.X) - math.Max(leaf.TopLeft.X, other.TopLeft.X)
+			height := math.Min(leaf.TopLeft.Y, other.TopLeft.Y) - math.Max(leaf.BottomRight.Y, other.BottomRight.Y)
+			if width > 0 && height > 0 {
+				t.Fatalf("leaves %s and %s overlap", leaf.ID, other.ID)
+			}
+		}
+	}
+	total := (tree.BottomRight.X - tree.TopLeft.X) * (tree.TopLeft.Y - tree.BottomRight.Y)
+	if math.Abs(area-total) > 1e-9*total {
+		t.Fatalf("leaves cover an area of %v instead of %v", area, total)
+	}
+}
@@ -0,0 +1,23 @@
+package convtree
+
+import "encoding/json"
+
+// MarshalJSON encodes the node and its descendants together with the pinned regions, the name of
+// the max points schedule and the sample counter of the tree. Options are not encoded and have to
+// be passed to UnmarshalConvTree again. Payloads are dropped, and so is point Content of any type
+// other than []string, which is counted as DiagContentDropped in strict mode.
+func (tree ConvTree) MarshalJSON() ([]byte, error) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	return json.Marshal(tree.toDocument())
+}
+
+// UnmarshalConvTree decodes a tree encoded by MarshalJSON. The options are applied as in
+// NewConvTree. A tree encoded with a max points schedule requires a schedule of the same name.
+func UnmarshalConvTree(data []byte, opts ...Option) (*ConvTree, error) {
+	doc := treeDocument{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc.toTree(opts)
+}
@@ -0,0 +1,156 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	points := make([]Point, 4000)
+	for i := range points {
+		points[i] = Point{X: r.NormFloat64()*10 + 50, Y: r.NormFloat64()*10 + 50, Weight: 1 + r.Intn(3)}
+		if i%3 == 0 {
+			points[i].Content = []string{"a", "b"}
+		}
+	}
+	points = clampPoints(points, 0, 100)
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 0.5, 0.5, 50, 8, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if depth := tree.Summary().MaxDepth; depth < 4 {
+		t.Fatalf("expected a tree with at least 4 levels, got depth %d", depth)
+	}
+	// The tree is marshaled by value, as returned by NewConvTree.
+	data, err := json.Marshal(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pointerData, err := json.Marshal(&tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(pointerData) {
+		t.Fatal("encoding by value differs from encoding by pointer")
+	}
+	decoded, err := UnmarshalConvTree(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareStructure(t, &tree, decoded)
+	if got, want := decoded.Summary().Points, tree.Summary().Points; got != want {
+		t.Fatalf("expected %d points, got %d", want, got)
+	}
+	again, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(data) {
+		t.Fatal("re-encoded tree differs")
+	}
+}
+
+func TestEncodeJSONMatchesMarshalJSON(t *testing.T) {
+	r := rand.New(rand.NewSource(43))
+	points := make([]Point, 3000)
+	for i := range points {
+		points[i] = Point{X: r.Float64() * 100, Y: r.Float64() * 100, Weight: 1 + r.Intn(3)}
+		if i%4 == 0 {
+			points[i].Content = []string{"a", strconv.Itoa(i % 7)}
+		}
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 0.5, 0.5, 50, 8, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.Clear()
+	for _, point := range points[:1500] {
+		if err := tree.Insert(point, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := tree.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := tree.EncodeJSON(buf); err != nil {
+		t.Fatal(err)
+	}
+	var marshaled, streamed interface{}
+	if err := json.Unmarshal(data, &marshaled); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &streamed); err != nil {
+		t.Fatalf("streamed document is not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(marshaled, streamed) {
+		t.Fatal("streamed document differs from MarshalJSON")
+	}
+
+	for name, decode := range map[string]func(data []byte) (*ConvTree, error){
+		"UnmarshalConvTree": func(data []byte) (*ConvTree, error) {
+			return UnmarshalConvTree(data)
+		},
+		"DecodeConvTree": func(data []byte) (*ConvTree, error) {
+			return DecodeConvTree(bytes.NewReader(data))
+		},
+	} {
+		decoded, err := decode(buf.Bytes())
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		compareStructure(t, &tree, decoded)
+		again, err := decoded.MarshalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(again) != string(data) {
+			t.Fatalf("%s: streamed tree re-encodes differently", name)
+		}
+	}
+}
+
+// compareStructure fails the test unless both trees have the same nodes, bounds, split parameters
+// and points.
+func compareStructure(t *testing.T, expected, actual *ConvTree) {
+	t.Helper()
+	if expected.ID != actual.ID || expected.IsLeaf != actual.IsLeaf || expected.Depth != actual.Depth ||
+		expected.TopLeft != actual.TopLeft || expected.BottomRight != actual.BottomRight {
+		t.Fatalf("node %s differs from node %s", actual.ID, expected.ID)
+	}
+	if expected.MaxPoints != actual.MaxPoints || expected.GridSize != actual.GridSize ||
+		!reflect.DeepEqual(expected.Kernel, actual.Kernel) {
+		t.Fatalf("node %s has different parameters", actual.ID)
+	}
+	if expected.IsLeaf {
+		if len(expected.Points) != len(actual.Points) {
+			t.Fatalf("leaf %s has %d points instead of %d", actual.ID, len(actual.Points), len(expected.Points))
+		}
+		for i := range expected.Points {
+			if !reflect.DeepEqual(expected.Points[i], actual.Points[i]) {
+				t.Fatalf("leaf %s has point %v instead of %v", actual.ID, actual.Points[i], expected.Points[i])
+			}
+		}
+		return
+	}
+	compareStructure(t, expected.ChildTopLeft, actual.ChildTopLeft)
+	compareStructure(t, expected.ChildTopRight, actual.ChildTopRight)
+	compareStructure(t, expected.ChildBottomLeft, actual.ChildBottomLeft)
+	compareStructure(t, expected.ChildBottomRight, actual.ChildBottomRight)
+}
+
+// clampPoints moves the coordinates of the points into [min, max].
+func clampPoints(points []Point, min, max float64) []Point {
+	for i := range points {
+		points[i].X = math.Max(min, math.Min(max, points[i].X))
+		points[i].Y = math.Max(min, math.Min(max, points[i].Y))
+	}
+	return points
+}
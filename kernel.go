@@ -0,0 +1,232 @@
+package convtree
+
+import (
+	"errors"
+	"math"
+)
+
+type axisKernels struct {
+	x      []float64
+	xConvs int
+	y      []float64
+	yConvs int
+}
+
+// WithAxisKernels replaces the 2-D convolution of the weight grid by two 1-D convolutions.
+// xKernel is applied xConvNum times along the X axis and then yKernel is applied yConvNum times
+// along the Y axis, so smoothing can differ between axes. Kernels must have an odd length and are
+// anchored at their middle element. The ConvNum and Kernel of the tree are ignored.
+func WithAxisKernels(xKernel []float64, xConvNum int, yKernel []float64, yConvNum int) Option {
+	return func(config *treeConfig) {
+		config.axisKernels = &axisKernels{x: xKernel, xConvs: xConvNum, y: yKernel, yConvs: yConvNum}
+	}
+}
+
+// smoothGrid convolves the normalized weight grid of the node and returns the result together
+// with the number of convolutions applied. Every replaced grid, including the given one, is
+// released with putGrid. If a convolution fails, the grid convolved so far is returned with
+// the error.
+func (tree *ConvTree) smoothGrid(values *grid) (*grid, int, error) {
+	iterations := 0
+	if tree.config != nil && tree.config.axisKernels != nil {
+		kernels := tree.config.axisKernels
+		passes := []struct {
+			kernel []float64
+			convs  int
+			axis   int
+		}{
+			{kernels.x, kernels.xConvs, 0},
+			{kernels.y, kernels.yConvs, 1},
+		}
+		for _, pass := range passes {
+			for i := 0; i < pass.convs; i++ {
+				tmpGrid, err := convolveAxis(values, pass.kernel, pass.axis)
+				if err != nil {
+					tree.config.report(DiagConvolveError)
+					return values, iterations, err
+				}
+				putGrid(values)
+				values = normalizeGrid(tmpGrid)
+				iterations++
+			}
+		}
+		return values, iterations, nil
+	}
+	params := tree.config.parameters()
+	kernel := tree.config.kernelGrid()
+	square := kernel.w == kernel.h
+	convNum, adaptive := params.ConvNum, tree.config != nil && tree.config.adaptiveConvs > 0
+	if adaptive {
+		convNum = tree.config.adaptiveConvs
+	}
+	previousX, previousY := gridArgmax(values)
+	stable := 0
+	for i := 0; i < convNum; i++ {
+		var tmpGrid *grid
+		var err error
+		if square {
+			tmpGrid, err = convolve(values, kernel, 1, 1)
+		} else {
+			tmpGrid, err = convolveAnchored(values, kernel)
+		}
+		if err != nil {
+			tree.config.report(DiagConvolveError)
+			return values, iterations, err
+		}
+		putGrid(values)
+		values = normalizeGrid(tmpGrid)
+		iterations++
+		if adaptive {
+			x, y := gridArgmax(values)
+			if x == previousX && y == previousY {
+				stable++
+			} else {
+				stable = 0
+			}
+			if stable == 2 {
+				break
+			}
+			previousX, previousY = x, y
+		}
+	}
+	return values, iterations, nil
+}
+
+// WithAdaptiveConvolution replaces the fixed number of convolutions by up to maxIter convolutions
+// that stop early once the cell with the largest value stays the same for two consecutive passes.
+// The number of passes actually used is recorded in the split trace. The option has no effect
+// together with WithAxisKernels.
+func WithAdaptiveConvolution(maxIter int) Option {
+	return func(config *treeConfig) {
+		config.adaptiveConvs = maxIter
+	}
+}
+
+func gridArgmax(values *grid) (int, int) {
+	maxIndex := 0
+	for i, value := range values.data {
+		if value > values.data[maxIndex] {
+			maxIndex = i
+		}
+	}
+	return maxIndex / values.h, maxIndex % values.h
+}
+
+// convolveAnchored convolves the grid with a kernel of odd, possibly different, sizes along the
+// axes. The kernel is anchored at its middle element and the grid is padded with zeros, so the
+// result has the same dimensions as the grid and cell (i, j) of the result is centered on cell
+// (i, j) of the grid.
+func convolveAnchored(values *grid, kernel *grid) (*grid, error) {
+	kernelX, kernelY := kernel.w, kernel.h
+	if kernelX%2 == 0 || kernelY%2 == 0 {
+		return nil, errors.New("anchored convolutional kernel must have odd dimensions")
+	}
+	if values.w < kernelX {
+		return nil, errors.New("grid width is less than convolutional kernel size")
+	}
+	if values.h < kernelY {
+		return nil, errors.New("grid height is less than convolutional kernel size")
+	}
+	anchorX, anchorY := kernelX/2, kernelY/2
+	result := getGrid(values.w, values.h)
+	for i := 0; i < values.w; i++ {
+		for j := 0; j < values.h; j++ {
+			total := 0.0
+			for x := 0; x < kernelX; x++ {
+				posX := i + x - anchorX
+				if posX < 0 || posX >= values.w {
+					continue
+				}
+				row := values.data[posX*values.h : (posX+1)*values.h]
+				kernelRow := kernel.data[x*kernelY : (x+1)*kernelY]
+				for y := 0; y < kernelY; y++ {
+					if posY := j + y - anchorY; posY >= 0 && posY < len(row) {
+						total += row[posY] * kernelRow[y]
+					}
+				}
+			}
+			result.data[i*values.h+j] = total
+		}
+	}
+	return result, nil
+}
+
+// convolveAxis convolves the grid with a 1-D kernel along the X axis (axis 0) or the Y axis (axis 1).
+func convolveAxis(values *grid, kernel []float64, axis int) (*grid, error) {
+	if len(kernel) == 0 {
+		return nil, errors.New("convolutional kernel is empty")
+	}
+	kernel2D := &grid{w: 1, h: len(kernel), data: kernel}
+	if axis == 0 {
+		kernel2D.w, kernel2D.h = len(kernel), 1
+	}
+	return convolveAnchored(values, kernel2D)
+}
+
+// SetKernel replaces the convolution kernel of the whole tree and re-splits only the subtrees
+// whose split position changes with the new kernel by more than resplitThreshold of the node
+// width or height. Nodes are checked from the root down, so a re-split subtree is rebuilt as a
+// whole. A threshold of 0 re-splits every subtree whose split moves at all, which gives the same
+// structure as building the tree with the new kernel. Subtrees containing pinned leaves and nodes
+// that were not divided by a split are never re-split. It returns the number of re-split subtrees.
+func (tree *ConvTree) SetKernel(kernel [][]float64, resplitThreshold float64) (int, error) {
+	if !checkKernel(kernel) {
+		return 0, errors.New("invalid convolutional kernel")
+	}
+	if resplitThreshold < 0 {
+		return 0, errors.New("resplit threshold must not be negative")
+	}
+	tree.config.lock()
+	defer tree.config.unlock()
+	resplits := tree.setKernel(kernel, resplitThreshold)
+	tree.config.recordMutation(tree, func(node *ConvTree) error {
+		node.setKernel(kernel, resplitThreshold)
+		return nil
+	})
+	return resplits, nil
+}
+
+func (tree *ConvTree) setKernel(kernel [][]float64, resplitThreshold float64) int {
+	tree.config.useKernel(kernel)
+	tree.syncParams()
+	return tree.resplitForKernel(resplitThreshold)
+}
+
+// resplitForKernel re-splits the subtrees whose split position moved with the current kernel.
+func (tree *ConvTree) resplitForKernel(resplitThreshold float64) int {
+	if tree.IsLeaf {
+		return 0
+	}
+	if tree.Trace != nil && !tree.hasPinnedLeaf() {
+		points := []Point{}
+		tree.walkLeaves(func(leaf *ConvTree) {
+			points = append(points, leaf.Points...)
+		})
+		probe := *tree
+		probe.Points = points
+		trace := probe.splitPosition()
+		width := tree.BottomRight.X - tree.TopLeft.X
+		height := tree.TopLeft.Y - tree.BottomRight.Y
+		if math.Abs(trace.X-tree.Trace.X) > resplitThreshold*width ||
+			math.Abs(trace.Y-tree.Trace.Y) > resplitThreshold*height {
+			tree.collapse()
+			if tree.checkSplit() {
+				tree.split()
+			}
+			return 1
+		}
+	}
+	resplits := 0
+	for _, child := range []*ConvTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight} {
+		resplits += child.resplitForKernel(resplitThreshold)
+	}
+	return resplits
+}
+
+func (tree *ConvTree) hasPinnedLeaf() bool {
+	pinned := false
+	tree.walkLeaves(func(leaf *ConvTree) {
+		pinned = pinned || leaf.Pinned
+	})
+	return pinned
+}
@@ -0,0 +1,285 @@
+package convtree
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// referenceConvolve is the original nested-slice implementation of convolve, kept to check that
+// the flattened and unrolled version gives bit-identical results.
+func referenceConvolve(values [][]float64, kernel [][]float64, stride, padding int) [][]float64 {
+	kernelSize := len(kernel)
+	procGrid := make([][]float64, len(values)+2*padding)
+	for i := range procGrid {
+		procGrid[i] = make([]float64, len(values[0])+2*padding)
+	}
+	for i := range values {
+		copy(procGrid[i+padding][padding:], values[i])
+	}
+	resultWidth := (len(values)-kernelSize+2*padding)/stride + 1
+	resultHeight := (len(values[0])-kernelSize+2*padding)/stride + 1
+	result := make([][]float64, resultWidth)
+	for i := range result {
+		result[i] = make([]float64, resultHeight)
+		for j := range result[i] {
+			total := 0.0
+			for x := 0; x < kernelSize; x++ {
+				for y := 0; y < kernelSize; y++ {
+					total += procGrid[stride*i+x][stride*j+y] * kernel[x][y]
+				}
+			}
+			result[i][j] = total
+		}
+	}
+	return result
+}
+
+func randomGrid(r *rand.Rand, w, h int) [][]float64 {
+	values := make([][]float64, w)
+	for i := range values {
+		values[i] = make([]float64, h)
+		for j := range values[i] {
+			values[i][j] = r.Float64()
+		}
+	}
+	return values
+}
+
+func TestConvolveMatchesReference(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	kernels := map[string][][]float64{
+		"default": defaultKernel(),
+		"3x3":     randomGrid(r, 3, 3),
+		"5x5":     randomGrid(r, 5, 5),
+	}
+	for name, kernel := range kernels {
+		for _, size := range []int{5, 16, 33} {
+			for _, stride := range []int{1, 2} {
+				values := randomGrid(r, size, size)
+				result, err := convolve(newGrid(values), newGrid(kernel), stride, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expected := referenceConvolve(values, kernel, stride, 1)
+				actual := result.rows()
+				if len(actual) != len(expected) || len(actual[0]) != len(expected[0]) {
+					t.Fatalf("%s kernel on %dx%d grid: got %dx%d result", name, size, size, len(actual), len(actual[0]))
+				}
+				for i := range expected {
+					for j := range expected[i] {
+						if math.Float64bits(actual[i][j]) != math.Float64bits(expected[i][j]) {
+							t.Fatalf("%s kernel on %dx%d grid with stride %d: cell [%d][%d] is %v instead of %v",
+								name, size, size, stride, i, j, actual[i][j], expected[i][j])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// referenceAnchored convolves values with a kernel anchored at its middle element, treating cells
+// outside of the grid as zeros.
+func referenceAnchored(values [][]float64, kernel [][]float64) [][]float64 {
+	anchorX, anchorY := len(kernel)/2, len(kernel[0])/2
+	result := make([][]float64, len(values))
+	for i := range result {
+		result[i] = make([]float64, len(values[0]))
+		for j := range result[i] {
+			for x := range kernel {
+				for y := range kernel[x] {
+					posX, posY := i+x-anchorX, j+y-anchorY
+					if posX >= 0 && posX < len(values) && posY >= 0 && posY < len(values[0]) {
+						result[i][j] += values[posX][posY] * kernel[x][y]
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+func checkGrid(t *testing.T, name string, actual *grid, expected [][]float64) {
+	t.Helper()
+	rows := actual.rows()
+	if len(rows) != len(expected) || len(rows[0]) != len(expected[0]) {
+		t.Fatalf("%s: got %dx%d result instead of %dx%d", name, len(rows), len(rows[0]), len(expected),
+			len(expected[0]))
+	}
+	for i := range expected {
+		for j := range expected[i] {
+			if math.Abs(rows[i][j]-expected[i][j]) > 1e-12 {
+				t.Fatalf("%s: cell [%d][%d] is %v instead of %v", name, i, j, rows[i][j], expected[i][j])
+			}
+		}
+	}
+}
+
+func TestConvolveAnchoredMatchesReference(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	for _, size := range [][2]int{{3, 5}, {5, 3}, {1, 3}, {5, 1}, {3, 3}} {
+		kernel := randomGrid(r, size[0], size[1])
+		for _, gridSize := range [][2]int{{7, 9}, {12, 5}, {5, 5}} {
+			values := randomGrid(r, gridSize[0], gridSize[1])
+			result, err := convolveAnchored(newGrid(values), newGrid(kernel))
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := fmt.Sprintf("%dx%d kernel on %dx%d grid", size[0], size[1], gridSize[0], gridSize[1])
+			checkGrid(t, name, result, referenceAnchored(values, kernel))
+		}
+	}
+	for _, kernel := range [][][]float64{{{1, 1}}, {{1}, {1}}, randomGrid(r, 9, 3), randomGrid(r, 3, 11)} {
+		if _, err := convolveAnchored(newGrid(randomGrid(r, 7, 9)), newGrid(kernel)); err == nil {
+			t.Fatalf("%dx%d kernel on 7x9 grid was accepted", len(kernel), len(kernel[0]))
+		}
+	}
+}
+
+func TestConvolveAxis(t *testing.T) {
+	values := make([][]float64, 5)
+	for i := range values {
+		values[i] = make([]float64, 6)
+	}
+	values[2][2] = 1
+	kernel := []float64{1, 2, 3}
+	alongX := [][]float64{{0, 0, 0, 0, 0, 0}, {0, 0, 3, 0, 0, 0}, {0, 0, 2, 0, 0, 0}, {0, 0, 1, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0}}
+	alongY := [][]float64{{0, 0, 0, 0, 0, 0}, {0, 0, 0, 0, 0, 0}, {0, 3, 2, 1, 0, 0}, {0, 0, 0, 0, 0, 0},
+		{0, 0, 0, 0, 0, 0}}
+	for axis, expected := range [][][]float64{alongX, alongY} {
+		result, err := convolveAxis(newGrid(values), kernel, axis)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGrid(t, fmt.Sprintf("impulse along axis %d", axis), result, expected)
+	}
+
+	r := rand.New(rand.NewSource(12))
+	random := randomGrid(r, 8, 11)
+	kernel = []float64{0.25, 0.5, 1, 0.5, 0.25}
+	result, err := convolveAxis(newGrid(random), kernel, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGrid(t, "random grid along X", result, referenceAnchored(random, [][]float64{{0.25}, {0.5}, {1}, {0.5}, {0.25}}))
+	result, err = convolveAxis(newGrid(random), kernel, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGrid(t, "random grid along Y", result, referenceAnchored(random, [][]float64{kernel}))
+	if _, err := convolveAxis(newGrid(random), nil, 0); err == nil {
+		t.Fatal("empty kernel was accepted")
+	}
+}
+
+func TestKernelGridIsShared(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 2, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(1)), 1000, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kernel := tree.config.kernelGrid()
+	if kernel != tree.config.kernelGrid() {
+		t.Fatal("kernel grid is built on every call")
+	}
+	if kernel.w != 3 || kernel.h != 3 || kernel.At(1, 1) != 1 {
+		t.Fatalf("unexpected kernel grid %v", kernel.rows())
+	}
+	replacement := [][]float64{{0, 1, 0}, {1, 2, 1}, {0, 1, 0}}
+	if _, err := tree.SetKernel(replacement, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := tree.config.kernelGrid(); got.At(1, 1) != 2 || got.At(0, 0) != 0 {
+		t.Fatalf("kernel grid is not updated by SetKernel: %v", got.rows())
+	}
+}
+
+func TestSetKernelResplitsMovedSubtrees(t *testing.T) {
+	points := uniformPoints(rand.New(rand.NewSource(41)), 3000, 100)
+	newTree := func(kernel [][]float64) ConvTree {
+		tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 100, 6, 2, 10, kernel, points)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tree
+	}
+	kernel := [][]float64{{0, 0, 0}, {0, 1, 0}, {0, 0, 0}}
+	tree := newTree(nil)
+	traces := map[*ConvTree]*SplitTrace{}
+	leaves := map[string]Bounds{}
+	tree.Walk(func(node *ConvTree, depth int) bool {
+		traces[node] = node.Trace
+		if node.IsLeaf {
+			leaves[node.ID] = Bounds{TopLeft: node.TopLeft, BottomRight: node.BottomRight}
+		}
+		return true
+	})
+	resplits, err := tree.SetKernel(kernel, 0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, kept := 0, 0
+	tree.Walk(func(node *ConvTree, depth int) bool {
+		if trace, ok := traces[node]; ok && trace != node.Trace {
+			changed++
+			return false
+		}
+		if node.IsLeaf {
+			bounds, ok := leaves[node.ID]
+			if !ok || bounds.TopLeft != node.TopLeft || bounds.BottomRight != node.BottomRight {
+				t.Fatalf("leaf %s outside of the re-split subtrees has changed", node.ID)
+			}
+			kept++
+		}
+		return true
+	})
+	if resplits == 0 || resplits != changed || kept == 0 {
+		t.Fatalf("expected a partial re-split, got %d re-splits of %d subtrees with %d leaves kept", resplits,
+			changed, kept)
+	}
+	checkTiling(t, &tree)
+	checkLeafCount(t, &tree)
+
+	// A zero threshold re-splits every moved split, which gives the structure of a new tree.
+	tree = newTree(nil)
+	if _, err := tree.SetKernel(kernel, 0); err != nil {
+		t.Fatal(err)
+	}
+	expected := newTree(kernel)
+	got, want := tree.Leaves(), expected.Leaves()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d leaves, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].TopLeft != want[i].TopLeft || got[i].BottomRight != want[i].BottomRight {
+			t.Fatalf("leaf %d has bounds %v %v instead of %v %v", i, got[i].TopLeft, got[i].BottomRight,
+				want[i].TopLeft, want[i].BottomRight)
+		}
+	}
+	if resplits, _ := tree.SetKernel(kernel, 0); resplits != 0 {
+		t.Fatalf("setting the same kernel again re-split %d subtrees", resplits)
+	}
+}
+
+func BenchmarkConvolve128(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	values := randomGrid(r, 128, 128)
+	kernel := defaultKernel()
+	b.Run("flat", func(b *testing.B) {
+		flatValues, flatKernel := newGrid(values), newGrid(kernel)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			result, _ := convolve(flatValues, flatKernel, 1, 1)
+			putGrid(result)
+		}
+	})
+	b.Run("reference", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			referenceConvolve(values, kernel, 1, 1)
+		}
+	})
+}
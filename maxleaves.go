@@ -0,0 +1,53 @@
+package convtree
+
+import "sync/atomic"
+
+// WithMaxLeaves caps the number of leaves of the whole tree. A split that would make the tree
+// exceed the cap is skipped, the leaf keeps its points and the skip is counted as
+// DiagLeafCapSkipped. Pinning regions and Expand are not limited by the cap.
+func WithMaxLeaves(n int) Option {
+	return func(config *treeConfig) {
+		config.maxLeaves = int64(n)
+	}
+}
+
+// reserveLeaves adds n to the leaf count of the tree and reports whether the count stays within
+// the cap set by WithMaxLeaves. The count is not changed when it would exceed the cap.
+func (config *treeConfig) reserveLeaves(n int64) bool {
+	if config == nil {
+		return true
+	}
+	for {
+		current := atomic.LoadInt64(&config.leaves)
+		if config.maxLeaves > 0 && current+n > config.maxLeaves {
+			config.count(DiagLeafCapSkipped, 1)
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&config.leaves, current, current+n) {
+			return true
+		}
+	}
+}
+
+// addLeaves adds n, which may be negative, to the leaf count of the tree regardless of the cap.
+func (config *treeConfig) addLeaves(n int64) {
+	if config != nil {
+		atomic.AddInt64(&config.leaves, n)
+	}
+}
+
+// resetLeafCount sets the leaf count of the tree to the number of leaves of the node, which must
+// be the root.
+func (tree *ConvTree) resetLeafCount() {
+	if tree.config != nil {
+		atomic.StoreInt64(&tree.config.leaves, tree.leafCount())
+	}
+}
+
+func (tree *ConvTree) leafCount() int64 {
+	count := int64(0)
+	tree.walkLeaves(func(leaf *ConvTree) {
+		count++
+	})
+	return count
+}
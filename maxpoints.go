@@ -0,0 +1,92 @@
+package convtree
+
+import (
+	"fmt"
+	"math"
+)
+
+// SetMaxPoints sets the split threshold of the whole tree. When resplit is true, leaves of the node
+// exceeding the new threshold are split immediately; otherwise the threshold applies to later
+// splits only.
+// Nodes are never merged, even if the threshold is raised. A schedule set by WithMaxPointsSchedule
+// takes precedence over MaxPoints.
+func (tree *ConvTree) SetMaxPoints(maxPoints int, resplit bool) {
+	tree.config.lock()
+	defer tree.config.unlock()
+	tree.setMaxPoints(maxPoints, resplit)
+	tree.config.recordMutation(tree, func(node *ConvTree) error {
+		node.setMaxPoints(maxPoints, resplit)
+		return nil
+	})
+}
+
+func (tree *ConvTree) setMaxPoints(maxPoints int, resplit bool) {
+	tree.config.parameters().MaxPoints = maxPoints
+	tree.syncParams()
+	if resplit {
+		tree.splitLeaves()
+	}
+}
+
+// MaxPointsSchedule maps the depth of a node to its split threshold. Name identifies the schedule
+// and its parameters, e.g. "exponential(100,2)".
+type MaxPointsSchedule struct {
+	Name      string
+	Threshold func(depth int) int
+}
+
+// ConstantSchedule uses the same threshold at every depth.
+func ConstantSchedule(maxPoints int) MaxPointsSchedule {
+	return MaxPointsSchedule{
+		Name: fmt.Sprintf("constant(%d)", maxPoints),
+		Threshold: func(depth int) int {
+			return maxPoints
+		},
+	}
+}
+
+// LinearSchedule starts at base and grows by increment with every level.
+func LinearSchedule(base, increment int) MaxPointsSchedule {
+	return MaxPointsSchedule{
+		Name: fmt.Sprintf("linear(%d,%d)", base, increment),
+		Threshold: func(depth int) int {
+			return base + increment*depth
+		},
+	}
+}
+
+// ExponentialSchedule starts at base and doubles every k levels.
+func ExponentialSchedule(base, k int) MaxPointsSchedule {
+	if k < 1 {
+		k = 1
+	}
+	return MaxPointsSchedule{
+		Name: fmt.Sprintf("exponential(%d,%d)", base, k),
+		Threshold: func(depth int) int {
+			return int(float64(base) * math.Pow(2, float64(depth/k)))
+		},
+	}
+}
+
+// CustomSchedule wraps an arbitrary threshold function.
+func CustomSchedule(name string, fn func(depth int) int) MaxPointsSchedule {
+	return MaxPointsSchedule{Name: name, Threshold: fn}
+}
+
+// WithMaxPointsSchedule makes every node use the threshold of the schedule for its depth instead
+// of MaxPoints when deciding whether to split.
+func WithMaxPointsSchedule(schedule MaxPointsSchedule) Option {
+	return func(config *treeConfig) {
+		if schedule.Threshold != nil {
+			config.schedule = &schedule
+		}
+	}
+}
+
+// maxPoints returns the split threshold of the node.
+func (tree ConvTree) maxPoints() int {
+	if tree.config != nil && tree.config.schedule != nil {
+		return tree.config.schedule.Threshold(tree.Depth)
+	}
+	return tree.config.parameters().MaxPoints
+}
@@ -0,0 +1,73 @@
+package convtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSetMaxPointsResplit(t *testing.T) {
+	r := rand.New(rand.NewSource(81))
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 200, 8, 1, 8, nil,
+		uniformPoints(r, 3000, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := tree.Summary().Leaves
+
+	// Without a re-split only later splits use the new threshold.
+	tree.SetMaxPoints(50, false)
+	if got := tree.Summary().Leaves; got != leaves {
+		t.Fatalf("lowering the threshold without a re-split changed the leaves from %d to %d", leaves, got)
+	}
+	var crowded *ConvTree
+	for _, leaf := range tree.Leaves() {
+		if len(leaf.Points) > 50 && leaf.Depth < 8 {
+			crowded = leaf
+			break
+		}
+	}
+	if crowded == nil {
+		t.Fatal("no leaf exceeds the new threshold")
+	}
+	center := Point{X: (crowded.TopLeft.X + crowded.BottomRight.X) / 2, Y: (crowded.TopLeft.Y + crowded.BottomRight.Y) / 2,
+		Weight: 1}
+	if err := tree.Insert(center, true); err != nil {
+		t.Fatal(err)
+	}
+	if crowded.IsLeaf {
+		t.Fatal("a leaf above the new threshold was not split by a later insert")
+	}
+	checkLeafCount(t, &tree)
+
+	tree.SetMaxPoints(50, true)
+	for _, leaf := range tree.Leaves() {
+		if leaf.checkSplit() {
+			t.Fatalf("leaf %s with %d points was left unsplit", leaf.ID, len(leaf.Points))
+		}
+	}
+	checkLeafCount(t, &tree)
+	checkTiling(t, &tree)
+	leaves = tree.Summary().Leaves
+
+	// Raising the threshold never merges nodes.
+	tree.SetMaxPoints(1000, true)
+	if got := tree.Summary().Leaves; got != leaves {
+		t.Fatalf("raising the threshold changed the leaves from %d to %d", leaves, got)
+	}
+}
+
+func TestSetMaxPointsWithSchedule(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 200, 8, 1, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(82)), 3000, 100), WithMaxPointsSchedule(ConstantSchedule(200)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := tree.Summary().Leaves
+	tree.SetMaxPoints(10, true)
+	if got := tree.Summary().Leaves; got != leaves {
+		t.Fatalf("the schedule was overridden: leaves changed from %d to %d", leaves, got)
+	}
+	if tree.MaxPoints != 10 {
+		t.Fatalf("MaxPoints is %d instead of 10", tree.MaxPoints)
+	}
+}
@@ -0,0 +1,81 @@
+package convtree
+
+// Merge collapses every internal node whose descendants hold less than minWeight in total back
+// into a leaf holding all their points. Subtrees containing pinned leaves are never collapsed.
+// It returns the number of collapsed nodes.
+func (tree *ConvTree) Merge(minWeight int) int {
+	tree.config.lock()
+	defer tree.config.unlock()
+	merges, _, _ := tree.merge(minWeight)
+	if merges > 0 {
+		tree.config.recordMutation(tree, func(node *ConvTree) error {
+			node.merge(minWeight)
+			return nil
+		})
+	}
+	return merges
+}
+
+// merge returns the number of collapsed nodes, the total weight of the subtree and
+// whether the subtree contains a pinned leaf.
+func (tree *ConvTree) merge(minWeight int) (int, int, bool) {
+	if tree.IsLeaf {
+		return 0, tree.totalWeight(), tree.Pinned
+	}
+	merges, weight, pinned := 0, 0, false
+	for _, child := range []*ConvTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight} {
+		childMerges, childWeight, childPinned := child.merge(minWeight)
+		merges += childMerges
+		weight += childWeight
+		pinned = pinned || childPinned
+	}
+	if pinned || weight >= minWeight {
+		return merges, weight, pinned
+	}
+	tree.collapse()
+	return merges + 1, weight, false
+}
+
+// collapse turns the node into a leaf holding all points of its descendants.
+func (tree *ConvTree) collapse() {
+	points := []Point{}
+	payloads := []interface{}{}
+	activity := Activity{}
+	histories := [][]WeightSample{}
+	leaves := int64(0)
+	tree.walkNodes(func(node *ConvTree) bool {
+		if node == tree {
+			return true
+		}
+		tree.InsertCount += node.InsertCount
+		tree.RemoveCount += node.RemoveCount
+		if node.IsLeaf {
+			leaves++
+			points = append(points, node.Points...)
+			payloads = append(payloads, node.Payload)
+			activity.add(node.Activity)
+			histories = append(histories, node.History)
+			if node.LastInsertAt.After(tree.LastInsertAt) {
+				tree.LastInsertAt = node.LastInsertAt
+			}
+		}
+		return true
+	})
+	if leaves > 0 {
+		tree.config.addLeaves(1 - leaves)
+	}
+	tree.Points = points
+	tree.Activity = activity
+	tree.History = mergeHistories(histories)
+	tree.ChildTopLeft = nil
+	tree.ChildTopRight = nil
+	tree.ChildBottomLeft = nil
+	tree.ChildBottomRight = nil
+	tree.IsLeaf = true
+	tree.Trace = nil
+	tree.recomputeValues()
+	tree.BaselineTags = tree.getBaseline()
+	if tree.config != nil && tree.config.payloadMerge != nil {
+		tree.Payload = tree.config.payloadMerge(payloads)
+	}
+}
@@ -0,0 +1,72 @@
+package convtree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeThresholds(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.divide(50, 50)
+	tree.ChildTopRight.divide(75, 75)
+	addTagged(t, &tree, Point{X: 25, Y: 75}, "w", 10)
+	addTagged(t, &tree, Point{X: 60, Y: 90}, "x", 3)
+	addTagged(t, &tree, Point{X: 90, Y: 90}, "y", 1)
+	addTagged(t, &tree, Point{X: 60, Y: 60}, "y", 1)
+	addTagged(t, &tree, Point{X: 90, Y: 60}, "z", 1)
+	if got := len(tree.Leaves()); got != 7 {
+		t.Fatalf("expected 7 leaves, got %d", got)
+	}
+
+	// The top right quarter weighs exactly 6, which is not below the threshold.
+	if got := tree.Merge(6); got != 0 || tree.ChildTopRight.IsLeaf {
+		t.Fatalf("expected no merges at the subtree weight, got %d", got)
+	}
+	if got := tree.Merge(7); got != 1 || !tree.ChildTopRight.IsLeaf {
+		t.Fatalf("expected the top right quarter to be merged, got %d merges", got)
+	}
+	merged := tree.ChildTopRight
+	if len(merged.Points) != 6 || merged.totalWeight() != 6 {
+		t.Fatalf("merged leaf holds %d points of weight %d", len(merged.Points), merged.totalWeight())
+	}
+	// The counts are x 3, y 2 and z 1, so only x reaches mean + standard deviation = 2.82.
+	if !reflect.DeepEqual(merged.BaselineTags, []string{"x"}) {
+		t.Fatalf("expected the baseline to be recomputed as [x], got %v", merged.BaselineTags)
+	}
+	if got := len(tree.Leaves()); got != 4 {
+		t.Fatalf("expected 4 leaves after the merge, got %d", got)
+	}
+	checkLeafCount(t, &tree)
+
+	if got := tree.Merge(16); got != 0 || tree.IsLeaf {
+		t.Fatalf("expected no merges at the tree weight, got %d", got)
+	}
+	if got := tree.Merge(17); got != 1 || !tree.IsLeaf {
+		t.Fatalf("expected the root to be merged, got %d merges", got)
+	}
+	// The counts are w 10, x 3, y 2 and z 1 with a threshold of 4 + 3.54.
+	if len(tree.Points) != 16 || !reflect.DeepEqual(tree.BaselineTags, []string{"w"}) {
+		t.Fatalf("expected 16 points with baseline [w], got %d points with %v", len(tree.Points), tree.BaselineTags)
+	}
+	checkLeafCount(t, &tree)
+	if got := tree.Summary().Leaves; got != 1 {
+		t.Fatalf("expected a single leaf, got %d", got)
+	}
+}
+
+func TestMergeKeepsPinnedSubtrees(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.divide(50, 50)
+	tree.ChildTopRight.divide(75, 75)
+	tree.ChildTopRight.ChildTopLeft.Pinned = true
+	if got := tree.Merge(100); got != 0 || tree.IsLeaf || tree.ChildTopRight.IsLeaf {
+		t.Fatalf("expected the pinned subtree to be kept, got %d merges", got)
+	}
+	checkLeafCount(t, &tree)
+}
@@ -0,0 +1,30 @@
+package convtree
+
+import "errors"
+
+var (
+	ErrOutOfBounds   = errors.New("point is outside the tree bounds")
+	ErrPointNotFound = errors.New("point is not found in the tree")
+)
+
+// Move replaces the point old, matched exactly as by Contains with zero epsilon, with the point
+// new. The leaf receiving the new point is split only when allowSplit is true. If new is outside
+// the tree bounds, ErrOutOfBounds is returned and the old point is kept.
+func (tree *ConvTree) Move(old Point, new Point, allowSplit bool) error {
+	tree.config.lock()
+	defer tree.config.unlock()
+	if !tree.contains(new.X, new.Y) {
+		return ErrOutOfBounds
+	}
+	if !tree.remove(old, 0) {
+		return ErrPointNotFound
+	}
+	tree.insert(new, allowSplit)
+	tree.config.recordRoutedMutation(tree, func(node *ConvTree) error {
+		if node.remove(old, 0) {
+			node.insert(new, allowSplit)
+		}
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,68 @@
+package convtree
+
+import "testing"
+
+func TestMoveAcrossLeaves(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.divide(50, 50)
+	old := Point{X: 25, Y: 75, Weight: 3, Content: []string{"a"}}
+	for _, point := range []Point{old, {X: 20, Y: 80, Weight: 1}} {
+		if err := tree.Insert(point, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	source, target := tree.ChildTopLeft, tree.ChildBottomRight
+	moved := Point{X: 75, Y: 25, Weight: 5, Content: []string{"b"}}
+	if err := tree.Move(old, moved, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(source.Points) != 1 || source.totalWeight() != 1 || source.InsertCount != 2 || source.RemoveCount != 1 {
+		t.Fatalf("source leaf has %d points of weight %d, %d inserts and %d removals", len(source.Points),
+			source.totalWeight(), source.InsertCount, source.RemoveCount)
+	}
+	if len(target.Points) != 1 || target.totalWeight() != 5 || target.InsertCount != 1 || target.RemoveCount != 0 {
+		t.Fatalf("target leaf has %d points of weight %d, %d inserts and %d removals", len(target.Points),
+			target.totalWeight(), target.InsertCount, target.RemoveCount)
+	}
+	if tree.Contains(old, 0) || !tree.Contains(moved, 0) {
+		t.Fatal("the old point is kept or the new point is missing")
+	}
+	if stats := tree.Summary(); stats.TotalWeight != 6 || stats.Inserts != 3 || stats.Removes != 1 {
+		t.Fatalf("expected a total weight of 6, 3 inserts and 1 removal, got %+v", stats)
+	}
+
+	// Moving inside a leaf counts as an insert and a removal of the same leaf.
+	if err := tree.Move(moved, Point{X: 80, Y: 20, Weight: 2}, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(target.Points) != 1 || target.totalWeight() != 2 || target.InsertCount != 2 || target.RemoveCount != 1 {
+		t.Fatalf("target leaf has %d points of weight %d, %d inserts and %d removals", len(target.Points),
+			target.totalWeight(), target.InsertCount, target.RemoveCount)
+	}
+}
+
+func TestMoveErrors(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := Point{X: 25, Y: 75, Weight: 1}
+	if err := tree.Insert(old, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, outside := range []Point{{X: 120, Y: 50}, {X: 50, Y: -1}, {X: -0.5, Y: 100.5}} {
+		if err := tree.Move(old, outside, false); err != ErrOutOfBounds {
+			t.Fatalf("moving to %v returned %v", outside, err)
+		}
+	}
+	if err := tree.Move(Point{X: 30, Y: 70, Weight: 1}, Point{X: 60, Y: 60, Weight: 1}, false); err != ErrPointNotFound {
+		t.Fatalf("moving a missing point returned %v", err)
+	}
+	if !tree.Contains(old, 0) || len(tree.Points) != 1 || tree.InsertCount != 1 || tree.RemoveCount != 0 {
+		t.Fatalf("failed moves changed the tree: %d points, %d inserts and %d removals", len(tree.Points),
+			tree.InsertCount, tree.RemoveCount)
+	}
+}
@@ -0,0 +1,65 @@
+package convtree
+
+import "container/heap"
+
+type nearestItem struct {
+	distance float64
+	node     *ConvTree
+	point    Point
+}
+
+type nearestQueue []nearestItem
+
+func (queue nearestQueue) Len() int {
+	return len(queue)
+}
+
+func (queue nearestQueue) Less(i, j int) bool {
+	return queue[i].distance < queue[j].distance
+}
+
+func (queue nearestQueue) Swap(i, j int) {
+	queue[i], queue[j] = queue[j], queue[i]
+}
+
+func (queue *nearestQueue) Push(item interface{}) {
+	*queue = append(*queue, item.(nearestItem))
+}
+
+func (queue *nearestQueue) Pop() interface{} {
+	old := *queue
+	item := old[len(old)-1]
+	*queue = old[:len(old)-1]
+	return item
+}
+
+// Nearest returns up to k points closest to p, sorted by Euclidean distance.
+// Nodes are visited in order of their distance to p, so only leaves that can contain
+// one of the k nearest points are scanned.
+func (tree *ConvTree) Nearest(p Point, k int) []Point {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := []Point{}
+	if k <= 0 {
+		return result
+	}
+	queue := &nearestQueue{{distance: tree.minDistance(p), node: tree}}
+	for queue.Len() > 0 && len(result) < k {
+		item := heap.Pop(queue).(nearestItem)
+		if item.node == nil {
+			result = append(result, item.point)
+			continue
+		}
+		node := item.node
+		if node.IsLeaf {
+			for _, point := range node.Points {
+				heap.Push(queue, nearestItem{distance: euclidean(p, point), point: point})
+			}
+			continue
+		}
+		for _, child := range []*ConvTree{node.ChildTopLeft, node.ChildTopRight, node.ChildBottomLeft, node.ChildBottomRight} {
+			heap.Push(queue, nearestItem{distance: child.minDistance(p), node: child})
+		}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package convtree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// bruteNearest returns the distances of the k points closest to p in ascending order.
+func bruteNearest(points []Point, p Point, k int) []float64 {
+	distances := make([]float64, len(points))
+	for i, point := range points {
+		distances[i] = euclidean(p, point)
+	}
+	sort.Float64s(distances)
+	if k < len(distances) {
+		distances = distances[:k]
+	}
+	return distances
+}
+
+func checkNearest(t *testing.T, tree *ConvTree, points []Point, p Point, k int) {
+	t.Helper()
+	got := tree.Nearest(p, k)
+	want := bruteNearest(points, p, k)
+	if len(got) != len(want) {
+		t.Fatalf("k=%d around %v: expected %d points, got %d", k, p, len(want), len(got))
+	}
+	for i, point := range got {
+		if distance := euclidean(p, point); distance != want[i] {
+			t.Fatalf("k=%d around %v: point %d is at %v, expected %v", k, p, i, distance, want[i])
+		}
+	}
+}
+
+func TestNearestMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(6))
+	points := uniformPoints(r, 3000, 100)
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []int{1, 5, 50, 500} {
+		for _, p := range uniformPoints(r, 20, 100) {
+			checkNearest(t, &tree, points, p, k)
+		}
+	}
+	// Query points outside of the tree bounds are allowed.
+	checkNearest(t, &tree, points, Point{X: -20, Y: 130}, 10)
+}
+
+func TestNearestSmallTrees(t *testing.T) {
+	empty, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := empty.Nearest(Point{X: 50, Y: 50}, 3); len(got) != 0 {
+		t.Fatalf("empty tree returned %d points", len(got))
+	}
+	points := uniformPoints(rand.New(rand.NewSource(7)), 60, 100)
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 10, 8, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNearest(t, &tree, points, Point{X: 30, Y: 70}, 100)
+	if got := tree.Nearest(Point{X: 30, Y: 70}, 0); len(got) != 0 {
+		t.Fatalf("k=0 returned %d points", len(got))
+	}
+}
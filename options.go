@@ -0,0 +1,219 @@
+package convtree
+
+import (
+	"github.com/google/uuid"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+type Option func(*treeConfig)
+
+type treeConfig struct {
+	params        Config
+	kernel        *grid
+	clock         func() time.Time
+	idGenerator   func() string
+	transform     WeightTransform
+	strict        bool
+	refine        bool
+	activity      bool
+	axisKernels   *axisKernels
+	adaptiveConvs int
+	schedule      *MaxPointsSchedule
+	historyLength int
+	samples       uint64
+	anomalyRatio  float64
+	geodesic      bool
+	workers       chan struct{}
+	maxLeaves     int64
+	leaves        int64
+	rootDepth     int
+	extractor     func(content interface{}) (float64, bool)
+	pins          []pinnedRegion
+	frozen        bool
+	generation    uint64
+	products      *productCache
+	targetLeaves  int
+	payloadSplit  func(parentPayload interface{}, children [4]*ConvTree) [4]interface{}
+	payloadMerge  func(payloads []interface{}) interface{}
+	diagnostics   map[string]*int64
+	mu            sync.RWMutex
+	rebuilding    bool
+	mutationLog   []mutation
+	changes       *structureRecorder
+}
+
+func newTreeConfig(opts []Option) *treeConfig {
+	config := &treeConfig{
+		clock:       time.Now,
+		diagnostics: newDiagnostics(),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(config)
+		}
+	}
+	return config
+}
+
+// detached returns a config sharing the options and diagnostics of config but none of its mutable
+// state. It is used to build structures outside the lock, which are attached to config afterwards.
+func (config *treeConfig) detached() *treeConfig {
+	return &treeConfig{
+		params:        config.params,
+		kernel:        config.kernel,
+		clock:         config.clock,
+		idGenerator:   config.idGenerator,
+		transform:     config.transform,
+		strict:        config.strict,
+		refine:        config.refine,
+		activity:      config.activity,
+		axisKernels:   config.axisKernels,
+		adaptiveConvs: config.adaptiveConvs,
+		schedule:      config.schedule,
+		historyLength: config.historyLength,
+		anomalyRatio:  config.anomalyRatio,
+		geodesic:      config.geodesic,
+		workers:       config.workers,
+		maxLeaves:     config.maxLeaves,
+		extractor:     config.extractor,
+		frozen:        config.frozen,
+		targetLeaves:  config.targetLeaves,
+		payloadSplit:  config.payloadSplit,
+		payloadMerge:  config.payloadMerge,
+		diagnostics:   config.diagnostics,
+	}
+}
+
+// WithClock replaces time.Now as the source of insert timestamps.
+func WithClock(clock func() time.Time) Option {
+	return func(config *treeConfig) {
+		if clock != nil {
+			config.clock = clock
+		}
+	}
+}
+
+// WithWeightTransform applies the transform to the weight grid of a node before convolution,
+// so the split position is chosen on a compressed dynamic range. Stored weights are not changed.
+func WithWeightTransform(transform WeightTransform) Option {
+	return func(config *treeConfig) {
+		config.transform = transform
+	}
+}
+
+// WithIDGenerator replaces random UUIDs as node identifiers, e.g. with SequentialIDs
+// to get reproducible IDs.
+func WithIDGenerator(generator func() string) Option {
+	return func(config *treeConfig) {
+		if generator != nil {
+			config.idGenerator = generator
+		}
+	}
+}
+
+// SequentialIDs returns a generator producing IDs prefix1, prefix2 and so on.
+// The generator is safe for concurrent use.
+func SequentialIDs(prefix string) func() string {
+	var counter int64
+	return func() string {
+		return prefix + strconv.FormatInt(atomic.AddInt64(&counter, 1), 10)
+	}
+}
+
+func (config *treeConfig) newID() string {
+	if config == nil || config.idGenerator == nil {
+		return uuid.New().String()
+	}
+	return config.idGenerator()
+}
+
+func (config *treeConfig) now() time.Time {
+	if config == nil || config.clock == nil {
+		return time.Now()
+	}
+	return config.clock()
+}
+
+func (config *treeConfig) lock() {
+	if config != nil {
+		config.mu.Lock()
+	}
+}
+
+func (config *treeConfig) unlock() {
+	if config != nil {
+		config.mu.Unlock()
+	}
+}
+
+func (config *treeConfig) rLock() {
+	if config != nil {
+		config.mu.RLock()
+	}
+}
+
+func (config *treeConfig) rUnlock() {
+	if config != nil {
+		config.mu.RUnlock()
+	}
+}
+
+// rLockPair read-locks the configs of two trees in the order of their addresses, so calls locking
+// the same pair in opposite order cannot deadlock behind waiting writers. It returns the function
+// releasing both locks.
+func rLockPair(a, b *treeConfig) func() {
+	if a == b {
+		a.rLock()
+		return a.rUnlock
+	}
+	if uintptr(unsafe.Pointer(a)) > uintptr(unsafe.Pointer(b)) {
+		a, b = b, a
+	}
+	a.rLock()
+	b.rLock()
+	return func() {
+		b.rUnlock()
+		a.rUnlock()
+	}
+}
+
+// mutation is a change made while the tree was being rebuilt in the background. It is replayed
+// on the node of the rebuilt structure that has the bounds of the node it was made on. A routed
+// mutation only routes points by their coordinates and can be replayed on any node containing
+// these bounds.
+type mutation struct {
+	target Bounds
+	routed bool
+	apply  func(node *ConvTree) error
+}
+
+// recordMutation advances the generation of the tree and records the mutation of target so that
+// it can be replayed on a tree rebuilt in the background. A nil apply only advances the generation.
+// It must be called with the write lock held.
+func (config *treeConfig) recordMutation(target *ConvTree, apply func(node *ConvTree) error) {
+	config.logMutation(target, false, apply)
+}
+
+// recordRoutedMutation is recordMutation for mutations that only route points by their
+// coordinates.
+func (config *treeConfig) recordRoutedMutation(target *ConvTree, apply func(node *ConvTree) error) {
+	config.logMutation(target, true, apply)
+}
+
+func (config *treeConfig) logMutation(target *ConvTree, routed bool, apply func(node *ConvTree) error) {
+	if config == nil {
+		return
+	}
+	config.generation++
+	if config.rebuilding && apply != nil {
+		config.mutationLog = append(config.mutationLog, mutation{
+			target: Bounds{TopLeft: target.TopLeft, BottomRight: target.BottomRight},
+			routed: routed,
+			apply:  apply,
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package convtree
+
+import (
+	"runtime"
+	"sync"
+)
+
+// WithParallelSplit makes splits process the children of a node in separate goroutines, with at
+// most workers goroutines running at a time, or GOMAXPROCS when workers is not positive. Children
+// share no state, so the structure is the same as with sequential splitting, but nodes are created
+// in a different order. ID generators, weight transforms and payload hooks must therefore be safe
+// for concurrent use, and the order of leaves in StructureChange is not deterministic.
+func WithParallelSplit(workers int) Option {
+	return func(config *treeConfig) {
+		if workers <= 0 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		config.workers = make(chan struct{}, workers)
+	}
+}
+
+// splitInWorker splits the node in a free worker goroutine tracked by wg and reports whether a
+// worker was free.
+func (config *treeConfig) splitInWorker(node *ConvTree, wg *sync.WaitGroup) bool {
+	if !config.acquireWorker() {
+		return false
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer config.releaseWorker()
+		node.split()
+	}()
+	return true
+}
+
+// acquireWorker reserves a worker without blocking, so a busy pool makes the caller split
+// sequentially instead of waiting.
+func (config *treeConfig) acquireWorker() bool {
+	if config == nil || config.workers == nil {
+		return false
+	}
+	select {
+	case config.workers <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (config *treeConfig) releaseWorker() {
+	<-config.workers
+}
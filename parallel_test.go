@@ -0,0 +1,57 @@
+package convtree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func parallelTestPoints(count int) []Point {
+	return GeneratePoints(Bounds{TopLeft: Point{X: 0, Y: 100}, BottomRight: Point{X: 100, Y: 0}},
+		SyntheticConfig{Count: count, Clusters: 6, Spread: 0.05, Seed: 26})
+}
+
+func TestParallelSplitMatchesSequential(t *testing.T) {
+	points := parallelTestPoints(50000)
+	build := func(opts ...Option) ConvTree {
+		tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 0.1, 0.1, 100, 12, 2, 16, nil, points,
+			opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tree
+	}
+	sequential := build()
+	parallel := build(WithParallelSplit(4))
+	expected, actual := sequential.Leaves(), parallel.Leaves()
+	if len(expected) != len(actual) {
+		t.Fatalf("parallel build has %d leaves instead of %d", len(actual), len(expected))
+	}
+	for i := range expected {
+		if expected[i].TopLeft != actual[i].TopLeft || expected[i].BottomRight != actual[i].BottomRight ||
+			expected[i].Depth != actual[i].Depth || !reflect.DeepEqual(expected[i].Points, actual[i].Points) {
+			t.Fatalf("leaf %d differs between sequential and parallel builds", i)
+		}
+	}
+	checkLeafCount(t, &parallel)
+}
+
+func BenchmarkParallelSplit(b *testing.B) {
+	points := parallelTestPoints(200000)
+	for _, bench := range []struct {
+		name string
+		opts []Option
+	}{
+		{"sequential", nil},
+		{"parallel", []Option{WithParallelSplit(0)}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 0.1, 0.1, 100, 12, 2, 16, nil,
+					points, bench.opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
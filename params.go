@@ -0,0 +1,48 @@
+package convtree
+
+// parameters returns the split parameters shared by all nodes of the tree. Nodes without a config
+// get zero parameters.
+func (config *treeConfig) parameters() *Config {
+	if config == nil {
+		return &Config{}
+	}
+	return &config.params
+}
+
+// useKernel sets the convolutional kernel of the tree and flattens it once for all splits.
+func (config *treeConfig) useKernel(kernel [][]float64) {
+	if config == nil {
+		return
+	}
+	config.params.Kernel = kernel
+	config.kernel = newGrid(kernel)
+}
+
+// kernelGrid returns the flattened convolutional kernel of the tree.
+func (config *treeConfig) kernelGrid() *grid {
+	if config == nil || config.kernel == nil {
+		return newGrid(config.parameters().Kernel)
+	}
+	return config.kernel
+}
+
+// mirrorParams copies the shared split parameters into the parameter fields of the node.
+func (tree *ConvTree) mirrorParams() {
+	params := tree.config.parameters()
+	tree.MaxPoints = params.MaxPoints
+	tree.MaxDepth = params.MaxDepth
+	tree.GridSize = params.GridSize
+	tree.ConvNum = params.ConvNum
+	tree.Kernel = params.Kernel
+	tree.MinXLength = params.MinXLength
+	tree.MinYLength = params.MinYLength
+}
+
+// syncParams copies the shared split parameters into the parameter fields of the node and its
+// descendants.
+func (tree *ConvTree) syncParams() {
+	tree.walkNodes(func(node *ConvTree) bool {
+		node.mirrorParams()
+		return true
+	})
+}
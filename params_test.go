@@ -0,0 +1,59 @@
+package convtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParamFieldsMirrorSharedConfig(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	points := make([]Point, 2000)
+	for i := range points {
+		points[i] = Point{X: r.Float64() * 100, Y: r.Float64() * 100, Weight: 1}
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 100, 6, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(root *ConvTree, maxPoints int) {
+		t.Helper()
+		root.walkNodes(func(node *ConvTree) bool {
+			if node.MaxPoints != maxPoints || node.MaxDepth != 6 || node.GridSize != 8 || node.ConvNum != 2 ||
+				node.MinXLength != 1 || node.MinYLength != 1 || !checkKernel(node.Kernel) {
+				t.Fatalf("node %s has parameters %d %d %d %d %v %v %v", node.ID, node.MaxPoints, node.MaxDepth,
+					node.GridSize, node.ConvNum, node.Kernel, node.MinXLength, node.MinYLength)
+			}
+			return true
+		})
+	}
+	check(&tree, 100)
+	tree.SetMaxPoints(50, true)
+	check(&tree, 50)
+	data, err := tree.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := UnmarshalConvTree(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(decoded, 50)
+	check(tree.Clone(), 50)
+}
+
+func TestNewConvTreeRejectsInvalidParameters(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		convNum  int
+		gridSize int
+	}{
+		{"negative convolutions", -1, 8},
+		{"zero grid size", 1, 0},
+		{"negative grid size", 1, -4},
+	} {
+		_, err := NewConvTree(Point{X: 0, Y: 10}, Point{X: 10, Y: 0}, 1, 1, 10, 4, test.convNum, test.gridSize, nil, nil)
+		if err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package parquetio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	convtree "github.com/visheratin/conv-tree"
+)
+
+// ColumnSpec maps Parquet columns to point fields. Nested columns are addressed by dot-separated
+// paths, e.g. "tags.list.element" for a standard list of strings. Weight and Tags are optional.
+type ColumnSpec struct {
+	X         string
+	Y         string
+	Weight    string
+	Tags      string
+	BatchSize int
+}
+
+const defaultBatchSize = 10000
+
+type column struct {
+	name  string
+	index int
+}
+
+// LoadParquet reads points from the Parquet file row group by row group and inserts them into
+// the tree in batches of spec.BatchSize points. Null weights default to 1 and null tags to an
+// empty list. It returns the number of inserted points.
+func LoadParquet(r io.ReaderAt, size int64, spec ColumnSpec, tree *convtree.ConvTree) (int, error) {
+	if tree == nil {
+		return 0, errors.New("tree is nil")
+	}
+	file, err := parquet.OpenFile(r, size)
+	if err != nil {
+		return 0, err
+	}
+	x, err := lookupColumn(file.Schema(), spec.X)
+	if err != nil {
+		return 0, err
+	}
+	y, err := lookupColumn(file.Schema(), spec.Y)
+	if err != nil {
+		return 0, err
+	}
+	weight := column{index: -1}
+	if spec.Weight != "" {
+		if weight, err = lookupColumn(file.Schema(), spec.Weight); err != nil {
+			return 0, err
+		}
+	}
+	tags := column{index: -1}
+	if spec.Tags != "" {
+		if tags, err = lookupColumn(file.Schema(), spec.Tags); err != nil {
+			return 0, err
+		}
+	}
+	batchSize := spec.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	total := 0
+	batch := make([]convtree.Point, 0, batchSize)
+	rows := make([]parquet.Row, 256)
+	for _, rowGroup := range file.RowGroups() {
+		reader := rowGroup.Rows()
+		for {
+			n, readErr := reader.ReadRows(rows)
+			for _, row := range rows[:n] {
+				point, err := rowToPoint(row, x, y, weight, tags)
+				if err != nil {
+					reader.Close()
+					return total, err
+				}
+				batch = append(batch, point)
+				if len(batch) == batchSize {
+					if _, err := tree.InsertBatch(batch); err != nil {
+						reader.Close()
+						return total, err
+					}
+					total += len(batch)
+					batch = batch[:0]
+				}
+			}
+			if readErr == io.EOF {
+				break
+			}
+			if readErr != nil {
+				reader.Close()
+				return total, readErr
+			}
+		}
+		reader.Close()
+	}
+	if _, err := tree.InsertBatch(batch); err != nil {
+		return total, err
+	}
+	total += len(batch)
+	return total, nil
+}
+
+func lookupColumn(schema *parquet.Schema, name string) (column, error) {
+	if name == "" {
+		return column{}, errors.New("column name is empty")
+	}
+	leaf, ok := schema.Lookup(strings.Split(name, ".")...)
+	if !ok {
+		return column{}, fmt.Errorf("column %q not found", name)
+	}
+	return column{name: name, index: leaf.ColumnIndex}, nil
+}
+
+func rowToPoint(row parquet.Row, x, y, weight, tags column) (convtree.Point, error) {
+	point := convtree.Point{Weight: 1}
+	xSet, ySet := false, false
+	var pointTags []string
+	for _, value := range row {
+		var err error
+		switch value.Column() {
+		case x.index:
+			point.X, err = floatValue(value, x.name)
+			xSet = !value.IsNull()
+		case y.index:
+			point.Y, err = floatValue(value, y.name)
+			ySet = !value.IsNull()
+		case weight.index:
+			if !value.IsNull() {
+				point.Weight, err = intValue(value, weight.name)
+			}
+		case tags.index:
+			if !value.IsNull() {
+				if value.Kind() != parquet.ByteArray {
+					return point, fmt.Errorf("column %q: expected byte array, got %s", tags.name, value.Kind())
+				}
+				pointTags = append(pointTags, string(value.ByteArray()))
+			}
+		}
+		if err != nil {
+			return point, err
+		}
+	}
+	if !xSet {
+		return point, fmt.Errorf("column %q: null coordinate", x.name)
+	}
+	if !ySet {
+		return point, fmt.Errorf("column %q: null coordinate", y.name)
+	}
+	if tags.index >= 0 {
+		if pointTags == nil {
+			pointTags = []string{}
+		}
+		point.Content = pointTags
+	}
+	return point, nil
+}
+
+func floatValue(value parquet.Value, name string) (float64, error) {
+	switch value.Kind() {
+	case parquet.Double:
+		return value.Double(), nil
+	case parquet.Float:
+		return float64(value.Float()), nil
+	case parquet.Int32:
+		return float64(value.Int32()), nil
+	case parquet.Int64:
+		return float64(value.Int64()), nil
+	}
+	if value.IsNull() {
+		return 0, nil
+	}
+	return 0, fmt.Errorf("column %q: expected numeric value, got %s", name, value.Kind())
+}
+
+func intValue(value parquet.Value, name string) (int, error) {
+	switch value.Kind() {
+	case parquet.Int32:
+		return int(value.Int32()), nil
+	case parquet.Int64:
+		return int(value.Int64()), nil
+	}
+	return 0, fmt.Errorf("column %q: expected integer value, got %s", name, value.Kind())
+}
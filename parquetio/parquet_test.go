@@ -0,0 +1,159 @@
+package parquetio
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	convtree "github.com/visheratin/conv-tree"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+type pointRow struct {
+	X      float64  `parquet:"x"`
+	Y      float64  `parquet:"y"`
+	Weight *int64   `parquet:"weight,optional"`
+	Tags   []string `parquet:"tags,list"`
+}
+
+var spec = ColumnSpec{X: "x", Y: "y", Weight: "weight", Tags: "tags.list.element", BatchSize: 7}
+
+func writeRows(tb testing.TB, rows []pointRow) []byte {
+	tb.Helper()
+	buf := &bytes.Buffer{}
+	if err := parquet.Write(buf, rows); err != nil {
+		tb.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTree(tb testing.TB) *convtree.ConvTree {
+	tb.Helper()
+	tree, err := convtree.NewConvTree(convtree.Point{X: 0, Y: 100}, convtree.Point{X: 100, Y: 0},
+		1, 1, 1000, 8, 1, 8, nil, nil)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return &tree
+}
+
+// goldenRows covers null and present weights and empty, single and multiple tags.
+func goldenRows() []pointRow {
+	weight := func(w int64) *int64 { return &w }
+	return []pointRow{
+		{X: 1.5, Y: 2.5, Weight: weight(3), Tags: []string{"a"}},
+		{X: 10, Y: 90, Tags: []string{"b", "c"}},
+		{X: 55.25, Y: 44.75, Weight: weight(1), Tags: []string{}},
+		{X: 99, Y: 0.5, Weight: weight(7)},
+	}
+}
+
+func formatPoints(points []convtree.Point) string {
+	lines := make([]string, len(points))
+	for i, p := range points {
+		lines[i] = fmt.Sprintf("%g %g %d %v", p.X, p.Y, p.Weight, p.Content)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func TestLoadParquetGolden(t *testing.T) {
+	input := filepath.Join("testdata", "points.parquet")
+	golden := filepath.Join("testdata", "points.golden")
+	if *update {
+		if err := os.WriteFile(input, writeRows(t, goldenRows()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := newTree(t)
+	n, err := LoadParquet(bytes.NewReader(data), int64(len(data)), spec, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(goldenRows()) {
+		t.Fatalf("expected %d points, got %d", len(goldenRows()), n)
+	}
+	got := formatPoints(tree.Points)
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Fatalf("loaded points differ from %s:\n%s\nwant:\n%s", golden, got, want)
+	}
+}
+
+func TestLoadParquetRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	rows := make([]pointRow, 1000)
+	want := make([]convtree.Point, len(rows))
+	for i := range rows {
+		weight := int64(r.Intn(10) + 1)
+		tags := []string{fmt.Sprintf("tag%d", i%3)}
+		rows[i] = pointRow{X: r.Float64() * 100, Y: r.Float64() * 100, Weight: &weight, Tags: tags}
+		want[i] = convtree.Point{X: rows[i].X, Y: rows[i].Y, Weight: int(weight), Content: tags}
+	}
+	data := writeRows(t, rows)
+	tree := newTree(t)
+	n, err := LoadParquet(bytes.NewReader(data), int64(len(data)), spec, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(rows) {
+		t.Fatalf("expected %d points, got %d", len(rows), n)
+	}
+	got := tree.QueryRange(tree.TopLeft, tree.BottomRight)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d points in the tree, got %d", len(want), len(got))
+	}
+	byCoordinates := make(map[[2]float64]convtree.Point, len(want))
+	for _, p := range want {
+		byCoordinates[[2]float64{p.X, p.Y}] = p
+	}
+	for _, p := range got {
+		if expected := byCoordinates[[2]float64{p.X, p.Y}]; !reflect.DeepEqual(p, expected) {
+			t.Fatalf("loaded point %v, expected %v", p, expected)
+		}
+	}
+}
+
+func TestLoadParquetMissingColumn(t *testing.T) {
+	data := writeRows(t, goldenRows())
+	_, err := LoadParquet(bytes.NewReader(data), int64(len(data)), ColumnSpec{X: "x", Y: "z"}, newTree(t))
+	if err == nil {
+		t.Fatal("expected an error for a missing column")
+	}
+}
+
+func BenchmarkLoadParquet1M(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	rows := make([]pointRow, 1000000)
+	for i := range rows {
+		rows[i] = pointRow{X: r.Float64() * 100, Y: r.Float64() * 100, Tags: []string{"tag"}}
+	}
+	data := writeRows(b, rows)
+	benchSpec := ColumnSpec{X: "x", Y: "y", Weight: "weight", Tags: "tags.list.element"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := LoadParquet(bytes.NewReader(data), int64(len(data)), benchSpec, newTree(b)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
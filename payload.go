@@ -0,0 +1,18 @@
+package convtree
+
+// OnPayloadSplit sets the hook called when a node is split. It receives the payload of the node
+// and its new children and returns the payloads for the children in the order
+// top left, top right, bottom left, bottom right.
+func OnPayloadSplit(fn func(parentPayload interface{}, children [4]*ConvTree) [4]interface{}) Option {
+	return func(config *treeConfig) {
+		config.payloadSplit = fn
+	}
+}
+
+// OnPayloadMerge sets the hook called when leaves are merged into a single node.
+// It receives the payloads of the merged leaves and returns the payload of the resulting node.
+func OnPayloadMerge(fn func(payloads []interface{}) interface{}) Option {
+	return func(config *treeConfig) {
+		config.payloadMerge = fn
+	}
+}
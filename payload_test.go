@@ -0,0 +1,98 @@
+package convtree
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+type pathPayload struct {
+	path   string
+	points int
+}
+
+func payloadTree(t *testing.T, merges *int) ConvTree {
+	t.Helper()
+	split := func(parent interface{}, children [4]*ConvTree) [4]interface{} {
+		path := "r"
+		if parent != nil {
+			path = parent.(pathPayload).path
+		}
+		result := [4]interface{}{}
+		for i, child := range children {
+			result[i] = pathPayload{path: path + strconv.Itoa(i), points: len(child.Points)}
+		}
+		return result
+	}
+	merge := func(payloads []interface{}) interface{} {
+		*merges++
+		paths := []string{}
+		points := 0
+		for _, payload := range payloads {
+			paths = append(paths, payload.(pathPayload).path)
+			points += payload.(pathPayload).points
+		}
+		sort.Strings(paths)
+		return pathPayload{path: paths[0][:len(paths[0])-1], points: points}
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 6, 1, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(14)), 600, 100), OnPayloadSplit(split), OnPayloadMerge(merge))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestPayloadSplitCallback(t *testing.T) {
+	merges := 0
+	tree := payloadTree(t, &merges)
+	if tree.IsLeaf || tree.Payload != nil {
+		t.Fatal("expected a split root without a payload")
+	}
+	var check func(node *ConvTree, path string)
+	check = func(node *ConvTree, path string) {
+		if node.IsLeaf {
+			payload, ok := node.Payload.(pathPayload)
+			if !ok || payload.path != path {
+				t.Fatalf("leaf at %s has payload %v", path, node.Payload)
+			}
+			// Leaves that were not split again got their points before the callback ran.
+			if payload.points != len(node.Points) {
+				t.Fatalf("leaf at %s had %d points in the callback, but holds %d", path, payload.points,
+					len(node.Points))
+			}
+			return
+		}
+		for i, child := range []*ConvTree{node.ChildTopLeft, node.ChildTopRight, node.ChildBottomLeft, node.ChildBottomRight} {
+			check(child, path+strconv.Itoa(i))
+		}
+	}
+	check(&tree, "r")
+	if merges != 0 {
+		t.Fatalf("merge callback was called %d times during the build", merges)
+	}
+}
+
+func TestPayloadMergeCallback(t *testing.T) {
+	merges := 0
+	tree := payloadTree(t, &merges)
+	sub := tree.ChildTopLeft
+	if sub.IsLeaf {
+		t.Fatal("expected a split subtree")
+	}
+	points := sub.Summary().Points
+	if got := sub.Merge(1 << 20); got == 0 {
+		t.Fatal("subtree was not merged")
+	}
+	payload, ok := sub.Payload.(pathPayload)
+	if !ok || payload.path != "r0" || payload.points != points {
+		t.Fatalf("expected merged payload r0 with %d points, got %v", points, sub.Payload)
+	}
+	if merges == 0 {
+		t.Fatal("merge callback was not called")
+	}
+	if _, ok := tree.ChildTopRight.Leaves()[0].Payload.(pathPayload); !ok {
+		t.Fatal("payloads outside of the merged subtree were changed")
+	}
+}
@@ -0,0 +1,107 @@
+package convtree
+
+import "errors"
+
+type pinnedRegion struct {
+	bounds Bounds
+	id     string
+}
+
+// PinRegion protects a rectangle from being split. The leaf containing the rectangle is divided
+// along the rectangle edges until a leaf covering exactly the rectangle exists, and that leaf is
+// marked as pinned. When the rectangle spans the whole width or height of the containing leaf,
+// that leaf is pinned as is. The rectangle must not cross existing split lines or overlap other pinned leaves.
+func (tree *ConvTree) PinRegion(topLeft, bottomRight Point) error {
+	tree.config.lock()
+	defer tree.config.unlock()
+	if topLeft.X >= bottomRight.X || topLeft.Y <= bottomRight.Y {
+		return errors.New("pinned region must have positive width and height")
+	}
+	if !tree.contains(topLeft.X, topLeft.Y) || !tree.contains(bottomRight.X, bottomRight.Y) {
+		return errors.New("pinned region is outside of the tree bounds")
+	}
+	overlaps := false
+	tree.walkLeaves(func(leaf *ConvTree) {
+		if leaf.Pinned && leaf.overlaps(topLeft, bottomRight) {
+			overlaps = true
+		}
+	})
+	if overlaps {
+		return errors.New("pinned region overlaps another pinned region")
+	}
+	leaf, err := tree.pin(topLeft, bottomRight, "")
+	if err != nil {
+		return err
+	}
+	if tree.config != nil {
+		tree.config.pins = append(tree.config.pins, pinnedRegion{
+			bounds: Bounds{TopLeft: topLeft, BottomRight: bottomRight},
+			id:     leaf.ID,
+		})
+	}
+	id := leaf.ID
+	tree.config.recordMutation(tree, func(node *ConvTree) error {
+		_, err := node.pin(topLeft, bottomRight, id)
+		return err
+	})
+	return nil
+}
+
+func (tree *ConvTree) pin(topLeft, bottomRight Point, id string) (*ConvTree, error) {
+	node := tree
+	for !node.IsLeaf {
+		node = node.childContaining(topLeft, bottomRight)
+		if node == nil {
+			return nil, errors.New("pinned region crosses existing cell boundaries")
+		}
+	}
+	for {
+		cutX := topLeft.X > node.TopLeft.X || bottomRight.X < node.BottomRight.X
+		cutY := topLeft.Y < node.TopLeft.Y || bottomRight.Y > node.BottomRight.Y
+		if !cutX || !cutY {
+			break
+		}
+		x := bottomRight.X
+		if topLeft.X > node.TopLeft.X {
+			x = topLeft.X
+		}
+		y := bottomRight.Y
+		if topLeft.Y < node.TopLeft.Y {
+			y = topLeft.Y
+		}
+		node.divide(x, y)
+		next := node.childContaining(topLeft, bottomRight)
+		for _, child := range []*ConvTree{node.ChildTopLeft, node.ChildTopRight, node.ChildBottomLeft, node.ChildBottomRight} {
+			if child != next && child.checkSplit() {
+				child.split()
+			}
+		}
+		node = next
+	}
+	node.Pinned = true
+	if id != "" {
+		node.ID = id
+	}
+	return node, nil
+}
+
+// applyPins pins the regions again after the tree structure was rebuilt, keeping their leaf IDs.
+func (tree *ConvTree) applyPins(pins []pinnedRegion) {
+	for _, pin := range pins {
+		tree.pin(pin.bounds.TopLeft, pin.bounds.BottomRight, pin.id)
+	}
+}
+
+func (tree *ConvTree) childContaining(topLeft, bottomRight Point) *ConvTree {
+	for _, child := range []*ConvTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight} {
+		if child.contains(topLeft.X, topLeft.Y) && child.contains(bottomRight.X, bottomRight.Y) {
+			return child
+		}
+	}
+	return nil
+}
+
+func (tree ConvTree) overlaps(topLeft, bottomRight Point) bool {
+	return topLeft.X < tree.BottomRight.X && bottomRight.X > tree.TopLeft.X &&
+		topLeft.Y > tree.BottomRight.Y && bottomRight.Y < tree.TopLeft.Y
+}
@@ -0,0 +1,72 @@
+package convtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPinnedLeafNeverSplits(t *testing.T) {
+	r := rand.New(rand.NewSource(15))
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 0.5, 0.5, 40, 10, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.PinRegion(Point{X: 10, Y: 90}, Point{X: 30, Y: 70}); err != nil {
+		t.Fatal(err)
+	}
+	children := func(node *ConvTree) []*ConvTree {
+		return []*ConvTree{node.ChildTopLeft, node.ChildTopRight, node.ChildBottomLeft, node.ChildBottomRight}
+	}
+	var pinned, parent *ConvTree
+	tree.walkNodes(func(node *ConvTree) bool {
+		if node.IsLeaf {
+			return true
+		}
+		for _, child := range children(node) {
+			if child.Pinned {
+				pinned, parent = child, node
+			}
+		}
+		return true
+	})
+	if pinned == nil {
+		t.Fatal("no pinned leaf")
+	}
+	id := pinned.ID
+	points := len(pinned.Points)
+	siblings := []*ConvTree{}
+	for _, child := range children(parent) {
+		if child != pinned {
+			siblings = append(siblings, child)
+		}
+	}
+
+	// Every leaf of the parent receives far more points than MaxPoints.
+	for _, node := range children(parent) {
+		for i := 0; i < 500; i++ {
+			point := Point{
+				X:      node.TopLeft.X + (0.1+0.8*r.Float64())*(node.BottomRight.X-node.TopLeft.X),
+				Y:      node.BottomRight.Y + (0.1+0.8*r.Float64())*(node.TopLeft.Y-node.BottomRight.Y),
+				Weight: 1 + r.Intn(5),
+			}
+			if err := tree.Insert(point, true); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if !pinned.IsLeaf || !pinned.Pinned || pinned.ID != id {
+		t.Fatal("the pinned leaf was split")
+	}
+	if got := len(pinned.Points); got != points+500 {
+		t.Fatalf("pinned leaf has %d points instead of %d", got, points+500)
+	}
+	for _, sibling := range siblings {
+		if sibling.IsLeaf {
+			t.Fatalf("sibling %s under the same load was not split", sibling.ID)
+		}
+	}
+	if leaf, _ := tree.FindLeaf(20, 80); leaf != pinned {
+		t.Fatal("points of the pinned region are not routed to the pinned leaf")
+	}
+	checkLeafCount(t, &tree)
+}
@@ -6,3 +6,8 @@ type Point struct {
 	Weight  int
 	Content interface{}
 }
+
+func pointTags(point Point) ([]string, bool) {
+	tags, ok := point.Content.([]string)
+	return tags, ok
+}
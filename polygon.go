@@ -0,0 +1,129 @@
+package convtree
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	polygonOutside = iota
+	polygonInside
+	polygonIntersects
+)
+
+// QueryPolygon returns points inside the polygon defined by vertices. The polygon may be
+// non-convex; it is closed implicitly and must not intersect itself. Subtrees lying entirely
+// inside the polygon are returned without per-point tests.
+func (tree *ConvTree) QueryPolygon(vertices []Point) ([]Point, error) {
+	if len(vertices) > 3 && vertices[0].X == vertices[len(vertices)-1].X && vertices[0].Y == vertices[len(vertices)-1].Y {
+		vertices = vertices[:len(vertices)-1]
+	}
+	if len(vertices) < 3 {
+		return nil, errors.New("polygon must have at least 3 vertices")
+	}
+	if polygonSelfIntersects(vertices) {
+		return nil, errors.New("polygon is self-intersecting")
+	}
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := []Point{}
+	tree.queryPolygon(vertices, &result)
+	return result, nil
+}
+
+func (tree *ConvTree) queryPolygon(vertices []Point, result *[]Point) {
+	switch tree.polygonRelation(vertices) {
+	case polygonOutside:
+		return
+	case polygonInside:
+		tree.walkLeaves(func(leaf *ConvTree) {
+			*result = append(*result, leaf.Points...)
+		})
+		return
+	}
+	if tree.IsLeaf {
+		for _, point := range tree.Points {
+			if pointInPolygon(point, vertices) {
+				*result = append(*result, point)
+			}
+		}
+		return
+	}
+	tree.ChildTopLeft.queryPolygon(vertices, result)
+	tree.ChildTopRight.queryPolygon(vertices, result)
+	tree.ChildBottomLeft.queryPolygon(vertices, result)
+	tree.ChildBottomRight.queryPolygon(vertices, result)
+}
+
+func (tree ConvTree) polygonRelation(vertices []Point) int {
+	corners := []Point{
+		tree.TopLeft,
+		{X: tree.BottomRight.X, Y: tree.TopLeft.Y},
+		tree.BottomRight,
+		{X: tree.TopLeft.X, Y: tree.BottomRight.Y},
+	}
+	for i := range vertices {
+		a, b := vertices[i], vertices[(i+1)%len(vertices)]
+		for j := range corners {
+			if segmentsIntersect(a, b, corners[j], corners[(j+1)%len(corners)]) {
+				return polygonIntersects
+			}
+		}
+	}
+	if tree.contains(vertices[0].X, vertices[0].Y) {
+		return polygonIntersects
+	}
+	if pointInPolygon(corners[0], vertices) {
+		return polygonInside
+	}
+	return polygonOutside
+}
+
+// pointInPolygon uses ray casting; points on the polygon boundary are treated as inside.
+func pointInPolygon(point Point, vertices []Point) bool {
+	inside := false
+	for i, j := 0, len(vertices)-1; i < len(vertices); j, i = i, i+1 {
+		a, b := vertices[i], vertices[j]
+		if onSegment(point, a, b) {
+			return true
+		}
+		if (a.Y > point.Y) != (b.Y > point.Y) && point.X < (b.X-a.X)*(point.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func polygonSelfIntersects(vertices []Point) bool {
+	n := len(vertices)
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if j == i+1 || (i == 0 && j == n-1) {
+				continue
+			}
+			if segmentsIntersect(vertices[i], vertices[(i+1)%n], vertices[j], vertices[(j+1)%n]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func orientation(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+func onSegment(point, a, b Point) bool {
+	return orientation(a, b, point) == 0 &&
+		point.X >= math.Min(a.X, b.X) && point.X <= math.Max(a.X, b.X) &&
+		point.Y >= math.Min(a.Y, b.Y) && point.Y <= math.Max(a.Y, b.Y)
+}
+
+func segmentsIntersect(a, b, c, d Point) bool {
+	o1, o2 := orientation(a, b, c), orientation(a, b, d)
+	o3, o4 := orientation(c, d, a), orientation(c, d, b)
+	if ((o1 > 0 && o2 < 0) || (o1 < 0 && o2 > 0)) && ((o3 > 0 && o4 < 0) || (o3 < 0 && o4 > 0)) {
+		return true
+	}
+	return onSegment(c, a, b) || onSegment(d, a, b) || onSegment(a, c, d) || onSegment(b, c, d)
+}
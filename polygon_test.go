@@ -0,0 +1,61 @@
+package convtree
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestQueryPolygonLShape(t *testing.T) {
+	points := uniformPoints(rand.New(rand.NewSource(20)), 3000, 100)
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lShape := []Point{{X: 10, Y: 10}, {X: 80, Y: 10}, {X: 80, Y: 40}, {X: 40, Y: 40}, {X: 40, Y: 90}, {X: 10, Y: 90}}
+	if leaves := tree.LeavesInRegion(Point{X: 10, Y: 90}, Point{X: 80, Y: 10}); len(leaves) < 10 {
+		t.Fatalf("expected the polygon to straddle many leaves, got %d", len(leaves))
+	}
+	expected := []Point{}
+	for _, point := range points {
+		inBottom := point.X > 10 && point.X < 80 && point.Y > 10 && point.Y < 40
+		inLeft := point.X > 10 && point.X < 40 && point.Y >= 40 && point.Y < 90
+		if inBottom || inLeft {
+			expected = append(expected, point)
+		}
+	}
+	closed := append(append([]Point{}, lShape...), lShape[0])
+	for _, vertices := range [][]Point{lShape, closed} {
+		actual, err := tree.QueryPolygon(vertices)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(actual) != len(expected) {
+			t.Fatalf("got %d points instead of %d", len(actual), len(expected))
+		}
+		sortPoints(actual)
+		sortPoints(expected)
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Fatalf("point %d is %v instead of %v", i, actual[i], expected[i])
+			}
+		}
+	}
+
+	bowtie := []Point{{X: 10, Y: 10}, {X: 90, Y: 90}, {X: 90, Y: 10}, {X: 10, Y: 90}}
+	if _, err := tree.QueryPolygon(bowtie); err == nil {
+		t.Fatal("expected an error for a self-intersecting polygon")
+	}
+	if _, err := tree.QueryPolygon(lShape[:2]); err == nil {
+		t.Fatal("expected an error for a polygon with 2 vertices")
+	}
+}
+
+func sortPoints(points []Point) {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X != points[j].X {
+			return points[i].X < points[j].X
+		}
+		return points[i].Y < points[j].Y
+	})
+}
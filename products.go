@@ -0,0 +1,104 @@
+package convtree
+
+import (
+	"container/list"
+	"sync"
+)
+
+type productEntry struct {
+	key  string
+	data []byte
+}
+
+type productCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// productCache memoizes derived products of a tree for a single generation. Entries are evicted
+// in least recently used order once their total size exceeds the budget.
+type productCache struct {
+	mu         sync.Mutex
+	budget     int64
+	size       int64
+	generation uint64
+	entries    map[string]*list.Element
+	order      *list.List
+	calls      map[string]*productCall
+}
+
+// WithProductCache enables memoization of derived products such as GeoJSON documents and rasters.
+// Cached products are dropped when the tree is mutated, and at most budget bytes are kept.
+func WithProductCache(budget int64) Option {
+	return func(config *treeConfig) {
+		config.products = &productCache{
+			budget:  budget,
+			entries: map[string]*list.Element{},
+			order:   list.New(),
+			calls:   map[string]*productCall{},
+		}
+	}
+}
+
+// Generation returns a counter that changes every time the tree is mutated.
+func (tree *ConvTree) Generation() uint64 {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	if tree.config == nil {
+		return 0
+	}
+	return tree.config.generation
+}
+
+// CachedProduct returns the product stored under key if it was built for the current generation
+// of the tree. Otherwise it calls build and caches the result. Concurrent calls for the same key
+// wait for a single build. Without WithProductCache the product is built on every call.
+func (tree *ConvTree) CachedProduct(key string, build func() ([]byte, error)) ([]byte, error) {
+	if tree.config == nil || tree.config.products == nil {
+		return build()
+	}
+	return tree.config.products.get(key, tree.Generation(), build)
+}
+
+func (cache *productCache) get(key string, generation uint64, build func() ([]byte, error)) ([]byte, error) {
+	cache.mu.Lock()
+	if generation != cache.generation {
+		cache.entries = map[string]*list.Element{}
+		cache.order.Init()
+		cache.size = 0
+		cache.generation = generation
+	}
+	if element, ok := cache.entries[key]; ok {
+		cache.order.MoveToFront(element)
+		cache.mu.Unlock()
+		return element.Value.(*productEntry).data, nil
+	}
+	if call, ok := cache.calls[key]; ok {
+		cache.mu.Unlock()
+		<-call.done
+		return call.data, call.err
+	}
+	call := &productCall{done: make(chan struct{})}
+	cache.calls[key] = call
+	cache.mu.Unlock()
+
+	call.data, call.err = build()
+
+	cache.mu.Lock()
+	delete(cache.calls, key)
+	if call.err == nil && generation == cache.generation && int64(len(call.data)) <= cache.budget {
+		cache.entries[key] = cache.order.PushFront(&productEntry{key: key, data: call.data})
+		cache.size += int64(len(call.data))
+		for cache.size > cache.budget {
+			oldest := cache.order.Back()
+			entry := oldest.Value.(*productEntry)
+			cache.order.Remove(oldest)
+			delete(cache.entries, entry.key)
+			cache.size -= int64(len(entry.data))
+		}
+	}
+	cache.mu.Unlock()
+	close(call.done)
+	return call.data, call.err
+}
@@ -0,0 +1,153 @@
+package convtree
+
+import (
+	"bytes"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestProductCacheHitsAndMisses(t *testing.T) {
+	r := rand.New(rand.NewSource(18))
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil,
+		uniformPoints(r, 500, 100), WithProductCache(1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	builds := 0
+	build := func() ([]byte, error) {
+		builds++
+		return []byte("product"), nil
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := tree.CachedProduct("key", build); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if builds != 1 {
+		t.Fatalf("expected a single build before mutations, got %d", builds)
+	}
+	generation := tree.Generation()
+	if err := tree.Insert(Point{X: 50, Y: 50, Weight: 1}, true); err != nil {
+		t.Fatal(err)
+	}
+	if tree.Generation() == generation {
+		t.Fatal("Insert did not change the generation")
+	}
+	if _, err := tree.CachedProduct("key", build); err != nil {
+		t.Fatal(err)
+	}
+	if builds != 2 {
+		t.Fatalf("expected a rebuild after a mutation, got %d builds", builds)
+	}
+
+	cached, err := tree.CachedGeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := tree.CachedGeoJSON()
+	if &cached[0] != &again[0] {
+		t.Fatal("CachedGeoJSON did not return the memoized document")
+	}
+	if !tree.Remove(Point{X: 50, Y: 50, Weight: 1}, 0) {
+		t.Fatal("point was not removed")
+	}
+	fresh, _ := tree.CachedGeoJSON()
+	expected, _ := tree.ToGeoJSON()
+	if !bytes.Equal(fresh, expected) || bytes.Equal(fresh, cached) {
+		t.Fatal("CachedGeoJSON returned a stale document after Remove")
+	}
+}
+
+func TestProductCacheBudget(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil, nil,
+		WithProductCache(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	builds := map[string]int{}
+	product := func(key string, size int) {
+		tree.CachedProduct(key, func() ([]byte, error) {
+			builds[key]++
+			return make([]byte, size), nil
+		})
+	}
+	product("a", 4)
+	product("b", 4)
+	product("a", 4)
+	product("c", 4)
+	product("a", 4)
+	product("b", 4)
+	product("large", 11)
+	product("large", 11)
+	if builds["a"] != 1 || builds["b"] != 2 || builds["c"] != 1 || builds["large"] != 2 {
+		t.Fatalf("unexpected builds %v", builds)
+	}
+}
+
+// TestProductCacheConcurrentAccess is meant to be run with -race.
+func TestProductCacheConcurrentAccess(t *testing.T) {
+	r := rand.New(rand.NewSource(19))
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil,
+		uniformPoints(r, 500, 100), WithProductCache(1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var builds int32
+	release := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := tree.CachedProduct("slow", func() ([]byte, error) {
+				atomic.AddInt32(&builds, 1)
+				<-release
+				return []byte("slow"), nil
+			})
+			if err != nil || string(data) != "slow" {
+				t.Errorf("unexpected product %q, %v", data, err)
+			}
+		}()
+	}
+	close(release)
+	wg.Wait()
+	if builds != 1 {
+		t.Fatalf("expected a single build for concurrent calls, got %d", builds)
+	}
+
+	const iterations = 50
+	points := uniformPoints(r, iterations, 100)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				if _, err := tree.CachedGeoJSON(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, point := range points {
+			if err := tree.Insert(point, true); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	cached, err := tree.CachedGeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := tree.ToGeoJSON()
+	if !bytes.Equal(cached, expected) {
+		t.Fatal("CachedGeoJSON is stale after concurrent mutations")
+	}
+}
@@ -0,0 +1,45 @@
+package convtree
+
+// Prune discards the points of every leaf whose total weight is below minWeight and collapses
+// internal nodes whose leaves are all empty afterwards. Subtrees containing pinned leaves keep
+// their structure. The node itself is never removed, only emptied. It returns the number of
+// discarded points.
+func (tree *ConvTree) Prune(minWeight int) int {
+	tree.config.lock()
+	defer tree.config.unlock()
+	discarded, _, _ := tree.prune(minWeight)
+	if discarded > 0 {
+		tree.config.recordMutation(tree, func(node *ConvTree) error {
+			node.prune(minWeight)
+			return nil
+		})
+	}
+	return discarded
+}
+
+// prune returns the number of discarded points and whether the subtree is empty and contains
+// a pinned leaf afterwards.
+func (tree *ConvTree) prune(minWeight int) (int, bool, bool) {
+	if tree.IsLeaf {
+		discarded := 0
+		if len(tree.Points) > 0 && tree.totalWeight() < minWeight {
+			discarded = len(tree.Points)
+			tree.RemoveCount += int64(discarded)
+			tree.Points = []Point{}
+			tree.Values = ValueSummary{}
+			tree.BaselineTags = tree.getBaseline()
+		}
+		return discarded, len(tree.Points) == 0, tree.Pinned
+	}
+	discarded, empty, pinned := 0, true, false
+	for _, child := range []*ConvTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight} {
+		childDiscarded, childEmpty, childPinned := child.prune(minWeight)
+		discarded += childDiscarded
+		empty = empty && childEmpty
+		pinned = pinned || childPinned
+	}
+	if empty && !pinned {
+		tree.collapse()
+	}
+	return discarded, empty, pinned
+}
@@ -37,6 +37,7 @@ func NewQuadTree(topLeft Point, bottomRight Point, minXLength float64, minYLengt
 	id := uuid.New().String()
 	tree := QuadTree{
 		ID:          id,
+		IsLeaf:      true,
 		maxPoints:   maxPoints,
 		maxDepth:    maxDepth,
 		Depth:       0,
@@ -56,36 +57,81 @@ func NewQuadTree(topLeft Point, bottomRight Point, minXLength float64, minYLengt
 	return tree, nil
 }
 
-func (tree *QuadTree) Insert(point Point) {
+func (tree *QuadTree) Insert(point Point, allowSplit bool) {
 	if !tree.IsLeaf {
 		if point.X >= tree.ChildTopLeft.TopLeft.X && point.X <= tree.ChildTopLeft.BottomRight.X &&
 			point.Y >= tree.ChildTopLeft.TopLeft.Y && point.Y <= tree.ChildTopLeft.BottomRight.Y {
-			tree.ChildTopLeft.Insert(point)
+			tree.ChildTopLeft.Insert(point, allowSplit)
 			return
 		}
 		if point.X >= tree.ChildTopRight.TopLeft.X && point.X <= tree.ChildTopRight.BottomRight.X &&
 			point.Y >= tree.ChildTopRight.TopLeft.Y && point.Y <= tree.ChildTopRight.BottomRight.Y {
-			tree.ChildTopRight.Insert(point)
+			tree.ChildTopRight.Insert(point, allowSplit)
 			return
 		}
 		if point.X >= tree.ChildBottomLeft.TopLeft.X && point.X <= tree.ChildBottomLeft.BottomRight.X &&
 			point.Y >= tree.ChildBottomLeft.TopLeft.Y && point.Y <= tree.ChildBottomLeft.BottomRight.Y {
-			tree.ChildBottomLeft.Insert(point)
+			tree.ChildBottomLeft.Insert(point, allowSplit)
 			return
 		}
 		if point.X >= tree.ChildBottomRight.TopLeft.X && point.X <= tree.ChildBottomRight.BottomRight.X &&
 			point.Y >= tree.ChildBottomRight.TopLeft.Y && point.Y <= tree.ChildBottomRight.BottomRight.Y {
-			tree.ChildBottomRight.Insert(point)
+			tree.ChildBottomRight.Insert(point, allowSplit)
 			return
 		}
 	} else {
 		tree.Points = append(tree.Points, point)
-		if tree.checkSplit() {
+		if allowSplit && tree.checkSplit() {
 			tree.split()
 		}
 	}
 }
 
+func (tree *QuadTree) Check() {
+	if tree.IsLeaf && tree.checkSplit() {
+		tree.split()
+	}
+}
+
+// CheckAll splits every leaf that exceeds its capacity.
+func (tree *QuadTree) CheckAll() {
+	leaves := []*QuadTree{}
+	tree.walkLeaves(func(leaf *QuadTree) {
+		leaves = append(leaves, leaf)
+	})
+	for _, leaf := range leaves {
+		leaf.Check()
+	}
+}
+
+// Clear removes all points but keeps the structure of the tree. Use Reset to return to a single empty leaf.
+func (tree *QuadTree) Clear() {
+	tree.Points = nil
+	if tree.ChildBottomLeft != nil {
+		tree.ChildBottomLeft.Clear()
+	}
+	if tree.ChildBottomRight != nil {
+		tree.ChildBottomRight.Clear()
+	}
+	if tree.ChildTopLeft != nil {
+		tree.ChildTopLeft.Clear()
+	}
+	if tree.ChildTopRight != nil {
+		tree.ChildTopRight.Clear()
+	}
+}
+
+// Reset removes all points and children, turning the node into an empty leaf with the same ID,
+// bounds and parameters.
+func (tree *QuadTree) Reset() {
+	tree.Points = []Point{}
+	tree.ChildTopLeft = nil
+	tree.ChildTopRight = nil
+	tree.ChildBottomLeft = nil
+	tree.ChildBottomRight = nil
+	tree.IsLeaf = true
+}
+
 func (tree QuadTree) Print(prefix string) {
 	innerPrefix := "\t"
 	fmt.Printf("%s top left X - %f, top left Y - %f\n", prefix, tree.TopLeft.X, tree.TopLeft.Y)
@@ -102,6 +148,17 @@ func (tree QuadTree) Print(prefix string) {
 	}
 }
 
+func (tree *QuadTree) walkLeaves(fn func(leaf *QuadTree)) {
+	if tree.IsLeaf {
+		fn(tree)
+		return
+	}
+	tree.ChildTopLeft.walkLeaves(fn)
+	tree.ChildTopRight.walkLeaves(fn)
+	tree.ChildBottomLeft.walkLeaves(fn)
+	tree.ChildBottomRight.walkLeaves(fn)
+}
+
 func (tree QuadTree) checkSplit() bool {
 	cond1 := (tree.BottomRight.X-tree.TopLeft.X) > 2*tree.minXLength && (tree.BottomRight.Y-tree.TopLeft.Y) > 2*tree.minYLength
 	total := 0
@@ -0,0 +1,140 @@
+package convtree
+
+import "testing"
+
+func TestNewQuadTreeIsLeaf(t *testing.T) {
+	points := []Point{{X: 1, Y: 1, Weight: 1}, {X: 2, Y: 2, Weight: 1}}
+	tree, err := NewQuadTree(Point{X: 0, Y: 0}, Point{X: 10, Y: 10}, 1, 1, 10, 5, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tree.IsLeaf {
+		t.Fatal("a tree that was not split is not a leaf")
+	}
+	if len(tree.Points) != len(points) {
+		t.Fatalf("expected %d points, got %d", len(points), len(tree.Points))
+	}
+	empty, err := NewQuadTree(Point{X: 0, Y: 0}, Point{X: 10, Y: 10}, 1, 1, 10, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !empty.IsLeaf {
+		t.Fatal("an empty tree is not a leaf")
+	}
+}
+
+func quadLeaves(tree *QuadTree) []*QuadTree {
+	leaves := []*QuadTree{}
+	tree.walkLeaves(func(leaf *QuadTree) {
+		leaves = append(leaves, leaf)
+	})
+	return leaves
+}
+
+func TestQuadTreeCheck(t *testing.T) {
+	tree, err := NewQuadTree(Point{X: 0, Y: 0}, Point{X: 10, Y: 10}, 1, 1, 3, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		tree.Insert(Point{X: 2, Y: 2, Weight: 1}, false)
+	}
+	tree.Check()
+	if !tree.IsLeaf {
+		t.Fatal("a leaf within its capacity was split")
+	}
+	tree.Insert(Point{X: 8, Y: 8, Weight: 1}, false)
+	tree.Check()
+	if tree.IsLeaf {
+		t.Fatal("a leaf above its capacity was not split")
+	}
+	if len(tree.ChildTopLeft.Points) != 3 || len(tree.ChildBottomRight.Points) != 1 {
+		t.Fatalf("points were not distributed: %d and %d", len(tree.ChildTopLeft.Points),
+			len(tree.ChildBottomRight.Points))
+	}
+	// Check does nothing on internal nodes.
+	tree.Check()
+	if len(quadLeaves(&tree)) != 4 {
+		t.Fatalf("expected 4 leaves, got %d", len(quadLeaves(&tree)))
+	}
+}
+
+func TestQuadTreeClearAndReset(t *testing.T) {
+	points := []Point{}
+	for i := 0; i < 40; i++ {
+		points = append(points, Point{X: float64(i%10) + 0.5, Y: float64(i/10) + 0.5, Weight: 1})
+	}
+	tree, err := NewQuadTree(Point{X: 0, Y: 0}, Point{X: 10, Y: 10}, 1, 1, 5, 5, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := len(quadLeaves(&tree))
+	if leaves < 4 {
+		t.Fatalf("expected the tree to be split, got %d leaves", leaves)
+	}
+	id := tree.ID
+	tree.Clear()
+	if got := len(quadLeaves(&tree)); got != leaves {
+		t.Fatalf("Clear changed the number of leaves from %d to %d", leaves, got)
+	}
+	if count, weight := tree.CountPoints(); count != 0 || weight != 0 {
+		t.Fatalf("%d points of weight %d are left after Clear", count, weight)
+	}
+	// Later points are routed into the existing cells.
+	tree.Insert(Point{X: 9.5, Y: 9.5, Weight: 1}, false)
+	if leaf := quadLeaves(&tree)[len(quadLeaves(&tree))-1]; len(leaf.Points) != 1 {
+		t.Fatal("point was not routed to the bottom right leaf")
+	}
+
+	tree.Reset()
+	if !tree.IsLeaf || tree.ID != id || len(tree.Points) != 0 || tree.ChildTopLeft != nil {
+		t.Fatal("Reset did not return the tree to a single empty leaf")
+	}
+	if tree.TopLeft != (Point{X: 0, Y: 0}) || tree.BottomRight != (Point{X: 10, Y: 10}) {
+		t.Fatalf("Reset changed the bounds to %v %v", tree.TopLeft, tree.BottomRight)
+	}
+	for _, point := range points {
+		tree.Insert(point, true)
+	}
+	if got := len(quadLeaves(&tree)); got < 4 {
+		t.Fatalf("the reset tree did not split again, got %d leaves", got)
+	}
+}
+
+func TestQuadTreeInsertWithoutSplit(t *testing.T) {
+	tree, err := NewQuadTree(Point{X: 0, Y: 0}, Point{X: 10, Y: 10}, 1, 1, 3, 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		tree.Insert(Point{X: float64(i%10) + 0.3, Y: float64(i%7) + 0.3, Weight: 1}, false)
+	}
+	if !tree.IsLeaf || len(tree.Points) != 20 {
+		t.Fatalf("inserting without splits changed the tree: leaf %v with %d points", tree.IsLeaf, len(tree.Points))
+	}
+	tree.CheckAll()
+	if tree.IsLeaf {
+		t.Fatal("CheckAll did not split the leaf")
+	}
+	for _, leaf := range quadLeaves(&tree) {
+		if leaf.checkSplit() {
+			t.Fatalf("leaf %s with %d points was left unsplit", leaf.ID, len(leaf.Points))
+		}
+	}
+	if count, _ := tree.CountPoints(); count != 20 {
+		t.Fatalf("expected 20 points after CheckAll, got %d", count)
+	}
+
+	// Inserting without splits into a split tree only grows the target leaf.
+	leaves := len(quadLeaves(&tree))
+	for i := 0; i < 10; i++ {
+		tree.Insert(Point{X: 0.5, Y: 0.5, Weight: 1}, false)
+	}
+	if got := len(quadLeaves(&tree)); got != leaves {
+		t.Fatalf("inserting without splits changed the leaves from %d to %d", leaves, got)
+	}
+	tree.Insert(Point{X: 0.5, Y: 0.5, Weight: 1}, true)
+	if got := len(quadLeaves(&tree)); got <= leaves {
+		t.Fatal("inserting with splits did not split the crowded leaf")
+	}
+}
@@ -0,0 +1,190 @@
+package convtree
+
+import (
+	"math"
+	"time"
+)
+
+func (tree *ConvTree) QueryRange(topLeft, bottomRight Point) []Point {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := []Point{}
+	tree.queryRange(topLeft, bottomRight, nil, &result, nil)
+	return result
+}
+
+type QueryStats struct {
+	NodesVisited   int
+	LeavesScanned  int
+	PointsExamined int
+	PointsReturned int
+	Duration       time.Duration
+}
+
+// QueryWithStats works as QueryRange and additionally reports how much of the tree the query touched.
+func (tree *ConvTree) QueryWithStats(topLeft, bottomRight Point) ([]Point, QueryStats) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	start := time.Now()
+	stats := QueryStats{}
+	result := []Point{}
+	tree.queryRange(topLeft, bottomRight, nil, &result, &stats)
+	stats.PointsReturned = len(result)
+	stats.Duration = time.Since(start)
+	return result, stats
+}
+
+// QueryFunc returns points inside the region for which fn returns true.
+func (tree *ConvTree) QueryFunc(region Bounds, fn func(point Point) bool) []Point {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := []Point{}
+	tree.queryRange(region.TopLeft, region.BottomRight, fn, &result, nil)
+	return result
+}
+
+// QueryByTag returns points inside the rectangle whose Content is a []string containing the tag.
+func (tree *ConvTree) QueryByTag(tag string, topLeft, bottomRight Point) []Point {
+	return tree.QueryFunc(Bounds{TopLeft: topLeft, BottomRight: bottomRight}, func(point Point) bool {
+		tags, ok := pointTags(point)
+		if !ok {
+			return false
+		}
+		for _, pointTag := range tags {
+			if pointTag == tag {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (tree *ConvTree) queryRange(topLeft, bottomRight Point, filter func(point Point) bool, result *[]Point,
+	stats *QueryStats) {
+	if stats != nil {
+		stats.NodesVisited++
+	}
+	if !tree.intersects(topLeft, bottomRight) {
+		return
+	}
+	if tree.IsLeaf {
+		if stats != nil {
+			stats.LeavesScanned++
+			stats.PointsExamined += len(tree.Points)
+		}
+		for _, point := range tree.Points {
+			if point.X >= topLeft.X && point.X <= bottomRight.X && point.Y >= bottomRight.Y && point.Y <= topLeft.Y &&
+				(filter == nil || filter(point)) {
+				*result = append(*result, point)
+			}
+		}
+		return
+	}
+	tree.ChildTopLeft.queryRange(topLeft, bottomRight, filter, result, stats)
+	tree.ChildTopRight.queryRange(topLeft, bottomRight, filter, result, stats)
+	tree.ChildBottomLeft.queryRange(topLeft, bottomRight, filter, result, stats)
+	tree.ChildBottomRight.queryRange(topLeft, bottomRight, filter, result, stats)
+}
+
+// LeavesInRegion returns the leaves whose bounds intersect the rectangle, including leaves that
+// only touch it. For a rectangle of zero width or height, the leaves touching the line are returned.
+func (tree *ConvTree) LeavesInRegion(topLeft, bottomRight Point) []*ConvTree {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	return tree.leavesInRegion(topLeft, bottomRight)
+}
+
+func (tree *ConvTree) leavesInRegion(topLeft, bottomRight Point) []*ConvTree {
+	result := []*ConvTree{}
+	tree.walk(func(node *ConvTree, depth int) bool {
+		if !node.intersects(topLeft, bottomRight) {
+			return false
+		}
+		if node.IsLeaf {
+			result = append(result, node)
+		}
+		return true
+	}, 0)
+	return result
+}
+
+func (tree ConvTree) intersects(topLeft, bottomRight Point) bool {
+	return topLeft.X <= tree.BottomRight.X && bottomRight.X >= tree.TopLeft.X &&
+		topLeft.Y >= tree.BottomRight.Y && bottomRight.Y <= tree.TopLeft.Y
+}
+
+func (tree *QuadTree) QueryRange(topLeft, bottomRight Point) []Point {
+	result := []Point{}
+	tree.queryRange(topLeft, bottomRight, &result)
+	return result
+}
+
+func (tree *QuadTree) queryRange(topLeft, bottomRight Point, result *[]Point) {
+	if !tree.intersects(topLeft, bottomRight) {
+		return
+	}
+	if tree.IsLeaf {
+		for _, point := range tree.Points {
+			if point.X >= topLeft.X && point.X <= bottomRight.X && point.Y >= topLeft.Y && point.Y <= bottomRight.Y {
+				*result = append(*result, point)
+			}
+		}
+		return
+	}
+	tree.ChildTopLeft.queryRange(topLeft, bottomRight, result)
+	tree.ChildTopRight.queryRange(topLeft, bottomRight, result)
+	tree.ChildBottomLeft.queryRange(topLeft, bottomRight, result)
+	tree.ChildBottomRight.queryRange(topLeft, bottomRight, result)
+}
+
+func (tree QuadTree) intersects(topLeft, bottomRight Point) bool {
+	return topLeft.X <= tree.BottomRight.X && bottomRight.X >= tree.TopLeft.X &&
+		topLeft.Y <= tree.BottomRight.Y && bottomRight.Y >= tree.TopLeft.Y
+}
+
+// QueryRadius returns points lying within radius of the center. When geodesic is true, X and Y are
+// treated as longitude and latitude in degrees and radius is measured in meters along the great circle.
+func (tree *ConvTree) QueryRadius(center Point, radius float64, geodesic bool) []Point {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := []Point{}
+	if radius < 0 {
+		return result
+	}
+	if geodesic {
+		topLeft, bottomRight := geodesicBounds(center, radius)
+		tree.queryRadius(center, radius, haversine, func(node *ConvTree) bool {
+			return node.intersects(topLeft, bottomRight)
+		}, &result)
+		return result
+	}
+	tree.queryRadius(center, radius, euclidean, func(node *ConvTree) bool {
+		return node.minDistance(center) <= radius
+	}, &result)
+	return result
+}
+
+func (tree *ConvTree) queryRadius(center Point, radius float64, distance func(a, b Point) float64,
+	visit func(node *ConvTree) bool, result *[]Point) {
+	if !visit(tree) {
+		return
+	}
+	if tree.IsLeaf {
+		for _, point := range tree.Points {
+			if distance(center, point) <= radius {
+				*result = append(*result, point)
+			}
+		}
+		return
+	}
+	tree.ChildTopLeft.queryRadius(center, radius, distance, visit, result)
+	tree.ChildTopRight.queryRadius(center, radius, distance, visit, result)
+	tree.ChildBottomLeft.queryRadius(center, radius, distance, visit, result)
+	tree.ChildBottomRight.queryRadius(center, radius, distance, visit, result)
+}
+
+func (tree ConvTree) minDistance(point Point) float64 {
+	dx := math.Max(math.Max(tree.TopLeft.X-point.X, 0), point.X-tree.BottomRight.X)
+	dy := math.Max(math.Max(tree.BottomRight.Y-point.Y, 0), point.Y-tree.TopLeft.Y)
+	return math.Hypot(dx, dy)
+}
@@ -0,0 +1,193 @@
+package convtree
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func sortedXY(points []Point) [][2]float64 {
+	result := make([][2]float64, len(points))
+	for i, point := range points {
+		result[i] = [2]float64{point.X, point.Y}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i][0] != result[j][0] {
+			return result[i][0] < result[j][0]
+		}
+		return result[i][1] < result[j][1]
+	})
+	return result
+}
+
+func TestQueryRadiusPlanar(t *testing.T) {
+	r := rand.New(rand.NewSource(8))
+	points := uniformPoints(r, 2000, 100)
+	for i := range points {
+		// Keep the random points out of the query circle.
+		for math.Hypot(points[i].X-50, points[i].Y-50) <= 6 {
+			points[i].X, points[i].Y = r.Float64()*100, r.Float64()*100
+		}
+	}
+	inside := []Point{{X: 50, Y: 50}, {X: 55, Y: 50}, {X: 53, Y: 54}, {X: 46.5, Y: 46.5}, {X: 50, Y: 45}}
+	outside := []Point{{X: 54, Y: 54}, {X: 50, Y: 44.9}, {X: 45.5, Y: 54.5}}
+	for _, point := range append(append([]Point{}, inside...), outside...) {
+		point.Weight = 1
+		points = append(points, point)
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 30, 8, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := sortedXY(tree.QueryRadius(Point{X: 50, Y: 50}, 5, false))
+	want := sortedXY(inside)
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	for _, center := range uniformPoints(r, 50, 100) {
+		radius := r.Float64() * 20
+		expected := 0
+		for _, point := range points {
+			if math.Hypot(point.X-center.X, point.Y-center.Y) <= radius {
+				expected++
+			}
+		}
+		if got := len(tree.QueryRadius(center, radius, false)); got != expected {
+			t.Fatalf("radius %v around %v: expected %d points, got %d", radius, center, expected, got)
+		}
+	}
+	if got := tree.QueryRadius(Point{X: 50, Y: 50}, -1, false); len(got) != 0 {
+		t.Fatalf("negative radius returned %d points", len(got))
+	}
+}
+
+func TestQueryRadiusHaversine(t *testing.T) {
+	// One degree along a meridian is 111195 m. At latitude 60 one degree of longitude is
+	// 2R asin(cos 60° sin 0.5°) = 55597 m along the great circle.
+	points := []Point{
+		{X: 10, Y: 60.5, Weight: 1},  // 55597.5 m north
+		{X: 11, Y: 60, Weight: 1},    // 55597 m east
+		{X: 9, Y: 60, Weight: 1},     // 55597 m west
+		{X: 10, Y: 60.6, Weight: 1},  // 66717 m north
+		{X: 10, Y: 59.49, Weight: 1}, // 56707 m south
+		{X: 11.2, Y: 60, Weight: 1},  // 66716 m east
+	}
+	r := rand.New(rand.NewSource(4))
+	for i := 0; i < 500; i++ {
+		points = append(points, Point{X: r.Float64()*360 - 180, Y: r.Float64()*160 - 80, Weight: 1})
+	}
+	tree, err := NewConvTree(Point{X: -180, Y: 90}, Point{X: 180, Y: -90}, 0.1, 0.1, 20, 10, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	center := Point{X: 10, Y: 60}
+	cases := []struct {
+		radius float64
+		want   [][2]float64
+	}{
+		{radius: 55000},
+		{radius: 55597.2, want: [][2]float64{{9, 60}, {11, 60}}},
+		{radius: 56000, want: [][2]float64{{9, 60}, {10, 60.5}, {11, 60}}},
+		{radius: 60000, want: [][2]float64{{9, 60}, {10, 59.49}, {10, 60.5}, {11, 60}}},
+	}
+	for _, c := range cases {
+		got := []Point{}
+		for _, point := range tree.QueryRadius(center, c.radius, true) {
+			if math.Abs(point.X-center.X) < 2 && math.Abs(point.Y-center.Y) < 2 {
+				got = append(got, point)
+			}
+		}
+		if len(got) != len(c.want) {
+			t.Fatalf("radius %v: expected %v, got %v", c.radius, c.want, sortedXY(got))
+		}
+		for i, xy := range sortedXY(got) {
+			if xy != c.want[i] {
+				t.Fatalf("radius %v: expected %v, got %v", c.radius, c.want, sortedXY(got))
+			}
+		}
+	}
+	for i := 0; i < 20; i++ {
+		center := Point{X: r.Float64()*300 - 150, Y: r.Float64()*140 - 70}
+		radius := r.Float64() * 2e6
+		expected := 0
+		for _, point := range points {
+			if haversine(center, point) <= radius {
+				expected++
+			}
+		}
+		if got := len(tree.QueryRadius(center, radius, true)); got != expected {
+			t.Fatalf("radius %v around %v: expected %d points, got %d", radius, center, expected, got)
+		}
+	}
+}
+
+func TestQueryWithStats(t *testing.T) {
+	r := rand.New(rand.NewSource(12))
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 30, 8, 1, 8, nil,
+		uniformPoints(r, 3000, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, leaves := 0, 0
+	tree.Walk(func(node *ConvTree, depth int) bool {
+		nodes++
+		if node.IsLeaf {
+			leaves++
+		}
+		return true
+	})
+	result, stats := tree.QueryWithStats(Point{X: 0, Y: 100}, Point{X: 100, Y: 0})
+	if stats.NodesVisited != nodes || stats.LeavesScanned != leaves || stats.PointsExamined != 3000 ||
+		stats.PointsReturned != 3000 || len(result) != 3000 {
+		t.Fatalf("full query: expected %d nodes, %d leaves and 3000 points, got %+v", nodes, leaves, stats)
+	}
+	// A query outside of the tree only visits the root.
+	if _, stats := tree.QueryWithStats(Point{X: 200, Y: 300}, Point{X: 300, Y: 200}); stats.NodesVisited != 1 ||
+		stats.LeavesScanned != 0 || stats.PointsExamined != 0 || stats.PointsReturned != 0 {
+		t.Fatalf("outside query: expected only the root to be visited, got %+v", stats)
+	}
+
+	// A small query in a corner prunes the other children at every level it descends through.
+	_, stats = tree.QueryWithStats(Point{X: 1, Y: 99}, Point{X: 2, Y: 98})
+	if stats.NodesVisited >= nodes || stats.LeavesScanned != 1 || (stats.NodesVisited-1)%4 != 0 {
+		t.Fatalf("corner query: expected a single path through the tree, got %+v", stats)
+	}
+
+	for i := 0; i < 30; i++ {
+		corner := uniformPoints(r, 2, 100)
+		topLeft := Point{X: math.Min(corner[0].X, corner[1].X), Y: math.Max(corner[0].Y, corner[1].Y)}
+		bottomRight := Point{X: math.Max(corner[0].X, corner[1].X), Y: math.Min(corner[0].Y, corner[1].Y)}
+		visited, pruned, scanned, examined := 0, 0, 0, 0
+		tree.Walk(func(node *ConvTree, depth int) bool {
+			visited++
+			if !node.intersects(topLeft, bottomRight) {
+				pruned++
+				return false
+			}
+			if node.IsLeaf {
+				scanned++
+				examined += len(node.Points)
+			}
+			return true
+		})
+		result, stats := tree.QueryWithStats(topLeft, bottomRight)
+		if stats.NodesVisited != visited || stats.LeavesScanned != scanned || stats.PointsExamined != examined ||
+			stats.PointsReturned != len(result) {
+			t.Fatalf("query %v %v: expected %d visited, %d scanned and %d examined, got %+v", topLeft, bottomRight,
+				visited, scanned, examined, stats)
+		}
+		if pruned == 0 && scanned != leaves {
+			t.Fatalf("query %v %v: %d leaves scanned without pruning any node", topLeft, bottomRight, scanned)
+		}
+		if len(result) != len(tree.QueryRange(topLeft, bottomRight)) {
+			t.Fatalf("query %v %v: stats query returned %d points", topLeft, bottomRight, len(result))
+		}
+	}
+}
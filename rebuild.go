@@ -0,0 +1,228 @@
+package convtree
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrRebuildInProgress = errors.New("tree is already being rebuilt")
+	ErrNotRoot           = errors.New("node is not the root of the tree")
+	ErrRebuildConflict   = errors.New("tree was changed during the rebuild in a way that cannot be replayed")
+)
+
+// RebuildAsync rebuilds the tree from its current points in the background and swaps the new
+// structure into the root once it is ready. The root keeps its ID, bounds and parameters, and
+// mutations made while the rebuild was running are replayed on the new structure, each on the node
+// with the bounds of the node it was made on. If such a node does not exist in the new structure,
+// a pinned region cannot be pinned again or the tree was expanded, the rebuilt structure is
+// discarded and ErrRebuildConflict is returned. The returned channel receives nil on success or
+// the error that stopped the rebuild, and is closed afterwards.
+// Cancelling ctx discards the rebuilt structure and leaves the tree unchanged. Only the root can
+// be rebuilt asynchronously, as mutations of the whole tree are replayed on it.
+func (tree *ConvTree) RebuildAsync(ctx context.Context) <-chan error {
+	result := make(chan error, 1)
+	if tree.config == nil {
+		result <- errors.New("tree must be created with NewConvTree to be rebuilt asynchronously")
+		close(result)
+		return result
+	}
+	config := tree.config
+	config.lock()
+	if tree.Depth != config.rootDepth {
+		config.unlock()
+		result <- ErrNotRoot
+		close(result)
+		return result
+	}
+	if config.rebuilding {
+		config.unlock()
+		result <- ErrRebuildInProgress
+		close(result)
+		return result
+	}
+	config.rebuilding = true
+	config.mutationLog = nil
+	snapshot := tree.rebuildTemplate()
+	snapshot.config = config.detached()
+	snapshot.config.leaves = config.leaves - tree.leafCount() + 1
+	pins := append([]pinnedRegion{}, config.pins...)
+	config.unlock()
+
+	go func() {
+		defer close(result)
+		snapshot.applyPins(pins)
+		if ctx.Err() == nil {
+			snapshot.splitLeaves()
+		}
+		config.lock()
+		defer config.unlock()
+		mutations := config.mutationLog
+		config.rebuilding = false
+		config.mutationLog = nil
+		if err := ctx.Err(); err != nil {
+			result <- err
+			return
+		}
+		snapshot.walkNodes(func(node *ConvTree) bool {
+			node.config = config
+			return true
+		})
+		previous := &ConvTree{}
+		previous.replaceStructure(tree)
+		livePins := append([]pinnedRegion{}, config.pins...)
+		config.addLeaves(snapshot.leafCount() - tree.leafCount())
+		tree.replaceStructure(snapshot)
+		err := tree.replay(mutations)
+		config.pins = livePins
+		if err != nil {
+			config.addLeaves(previous.leafCount() - tree.leafCount())
+			tree.replaceStructure(previous)
+			result <- err
+			return
+		}
+		config.generation++
+		result <- nil
+	}()
+	return result
+}
+
+// replay applies the mutations recorded during a rebuild to the new structure of the node.
+func (tree *ConvTree) replay(mutations []mutation) error {
+	for _, mutation := range mutations {
+		node := tree.nodeWithBounds(mutation.target, mutation.routed)
+		if node == nil || mutation.apply(node) != nil {
+			return ErrRebuildConflict
+		}
+	}
+	return nil
+}
+
+// nodeWithBounds returns the node with the given bounds, or nil if there is none. If containing is
+// true, the deepest node containing the bounds is returned instead when no node matches exactly.
+func (tree *ConvTree) nodeWithBounds(bounds Bounds, containing bool) *ConvTree {
+	node := tree
+	for {
+		if node.TopLeft.X == bounds.TopLeft.X && node.TopLeft.Y == bounds.TopLeft.Y &&
+			node.BottomRight.X == bounds.BottomRight.X && node.BottomRight.Y == bounds.BottomRight.Y {
+			return node
+		}
+		var child *ConvTree
+		if !node.IsLeaf {
+			child = node.childContaining(bounds.TopLeft, bounds.BottomRight)
+		}
+		if child == nil {
+			if containing && node.contains(bounds.TopLeft.X, bounds.TopLeft.Y) &&
+				node.contains(bounds.BottomRight.X, bounds.BottomRight.Y) {
+				return node
+			}
+			return nil
+		}
+		node = child
+	}
+}
+
+func (tree *ConvTree) rebuildTemplate() *ConvTree {
+	points := []Point{}
+	lastInsertAt := tree.LastInsertAt
+	var inserts, removes int64
+	payloads := []interface{}{}
+	activity := Activity{}
+	histories := [][]WeightSample{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		points = append(points, leaf.Points...)
+		payloads = append(payloads, leaf.Payload)
+		activity.add(leaf.Activity)
+		histories = append(histories, leaf.History)
+		inserts += leaf.InsertCount
+		removes += leaf.RemoveCount
+		if leaf.LastInsertAt.After(lastInsertAt) {
+			lastInsertAt = leaf.LastInsertAt
+		}
+	})
+	template := &ConvTree{
+		ID:           tree.ID,
+		IsLeaf:       true,
+		Depth:        tree.Depth,
+		Points:       points,
+		TopLeft:      tree.TopLeft,
+		BottomRight:  tree.BottomRight,
+		LastInsertAt: lastInsertAt,
+		InsertCount:  inserts,
+		RemoveCount:  removes,
+		Activity:     activity,
+		History:      mergeHistories(histories),
+		config:       tree.config,
+	}
+	template.Payload = tree.Payload
+	if !tree.IsLeaf && tree.config != nil && tree.config.payloadMerge != nil {
+		template.Payload = tree.config.payloadMerge(payloads)
+	}
+	template.recomputeValues()
+	template.BaselineTags = template.getBaseline()
+	return template
+}
+
+func (tree *ConvTree) replaceStructure(source *ConvTree) {
+	tree.IsLeaf = source.IsLeaf
+	tree.Points = source.Points
+	tree.LastInsertAt = source.LastInsertAt
+	tree.InsertCount = source.InsertCount
+	tree.RemoveCount = source.RemoveCount
+	tree.Trace = source.Trace
+	tree.Values = source.Values
+	tree.BaselineTags = source.BaselineTags
+	tree.Activity = source.Activity
+	tree.History = source.History
+	tree.Payload = source.Payload
+	tree.ChildTopLeft = source.ChildTopLeft
+	tree.ChildTopRight = source.ChildTopRight
+	tree.ChildBottomLeft = source.ChildBottomLeft
+	tree.ChildBottomRight = source.ChildBottomRight
+}
+
+// Rebuild collects all points of the node and splits them again with new parameters. An invalid
+// kernel is replaced by the default one as in NewConvTree. The parameters are shared by all nodes,
+// so they change for the whole tree even if the node is not its root. The node keeps its ID,
+// bounds and pinned regions.
+func (tree *ConvTree) Rebuild(maxPoints, maxDepth, convNum, gridSize int, kernel [][]float64) error {
+	if gridSize < 1 {
+		return errors.New("grid size must be positive")
+	}
+	if convNum < 0 {
+		return errors.New("number of convolutions must not be negative")
+	}
+	if !checkKernel(kernel) {
+		kernel = defaultKernel()
+	}
+	tree.config.lock()
+	defer tree.config.unlock()
+	if tree.config != nil && tree.config.rebuilding {
+		return ErrRebuildInProgress
+	}
+	tree.rebuild(maxPoints, maxDepth, convNum, gridSize, kernel)
+	tree.config.recordMutation(tree, nil)
+	return nil
+}
+
+func (tree *ConvTree) rebuild(maxPoints, maxDepth, convNum, gridSize int, kernel [][]float64) {
+	params := tree.config.parameters()
+	params.MaxPoints = maxPoints
+	params.MaxDepth = maxDepth
+	params.ConvNum = convNum
+	params.GridSize = gridSize
+	tree.config.useKernel(kernel)
+	tree.syncParams()
+	tree.config.addLeaves(1 - tree.leafCount())
+	template := tree.rebuildTemplate()
+	if tree.config != nil {
+		for _, pin := range tree.config.pins {
+			if tree.contains(pin.bounds.TopLeft.X, pin.bounds.TopLeft.Y) &&
+				tree.contains(pin.bounds.BottomRight.X, pin.bounds.BottomRight.Y) {
+				template.applyPins([]pinnedRegion{pin})
+			}
+		}
+	}
+	template.splitLeaves()
+	tree.replaceStructure(template)
+}
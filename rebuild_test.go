@@ -0,0 +1,416 @@
+package convtree
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRebuildAsyncRequiresRoot(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	points := make([]Point, 3000)
+	for i := range points {
+		points[i] = Point{X: r.Float64() * 100, Y: r.Float64() * 100, Weight: 1}
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 100, 6, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.IsLeaf {
+		t.Fatal("tree was not split")
+	}
+	if err := <-tree.ChildTopLeft.RebuildAsync(context.Background()); err != ErrNotRoot {
+		t.Fatalf("expected ErrNotRoot, got %v", err)
+	}
+	root := tree.Expand(Point{X: 150, Y: 50})
+	if err := <-tree.RebuildAsync(context.Background()); err != ErrNotRoot {
+		t.Fatalf("expected ErrNotRoot for the previous root, got %v", err)
+	}
+	if err := <-root.RebuildAsync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := root.Summary().Points; got != len(points) {
+		t.Fatalf("expected %d points, got %d", len(points), got)
+	}
+	decoded, err := UnmarshalConvTree(mustMarshal(t, tree.ChildBottomRight))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-decoded.RebuildAsync(context.Background()); err != nil {
+		t.Fatalf("a decoded subtree cannot be rebuilt: %v", err)
+	}
+	if err := <-tree.ChildBottomRight.Clone().RebuildAsync(context.Background()); err != nil {
+		t.Fatalf("a cloned subtree cannot be rebuilt: %v", err)
+	}
+}
+
+func mustMarshal(t *testing.T, tree *ConvTree) []byte {
+	t.Helper()
+	data, err := tree.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// TestRebuildAsyncConcurrentMutations is meant to be run with -race. Points inserted and removed
+// while rebuilds are running must all be reflected by the final structure.
+func TestRebuildAsyncConcurrentMutations(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 30, 8, 1, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(1)), 1000, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const writers, inserts, iterations = 4, 200, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			for i, point := range uniformPoints(rand.New(rand.NewSource(seed)), inserts, 100) {
+				if err := tree.Insert(point, true); err != nil {
+					t.Error(err)
+					return
+				}
+				if i%2 == 1 && !tree.Remove(point, 0) {
+					t.Errorf("inserted point %v is not found", point)
+					return
+				}
+			}
+		}(int64(w + 2))
+	}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for n := 0; n < iterations; n++ {
+			tree.QueryRange(Point{X: 10, Y: 90}, Point{X: 60, Y: 40})
+			tree.FindLeaf(50, 50)
+		}
+	}()
+	rebuilds := 0
+	go func() {
+		defer wg.Done()
+		for n := 0; n < iterations; n++ {
+			if err := <-tree.RebuildAsync(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+			rebuilds++
+		}
+	}()
+	wg.Wait()
+	if rebuilds != iterations {
+		t.Fatalf("%d of %d rebuilds finished", rebuilds, iterations)
+	}
+	want := 1000 + writers*inserts/2
+	if got := len(tree.QueryRange(Point{X: 0, Y: 100}, Point{X: 100, Y: 0})); got != want {
+		t.Fatalf("expected %d points, got %d", want, got)
+	}
+	checkLeafCount(t, &tree)
+}
+
+// rebuildGate is an ID generator that blocks the first ID requested after it is armed, which
+// holds a rebuild in the middle of splitting its snapshot.
+type rebuildGate struct {
+	armed   int32
+	next    int64
+	started chan struct{}
+	release chan struct{}
+}
+
+func (gate *rebuildGate) id() string {
+	if atomic.CompareAndSwapInt32(&gate.armed, 1, 0) {
+		close(gate.started)
+		<-gate.release
+	}
+	return "node-" + strconv.FormatInt(atomic.AddInt64(&gate.next, 1), 10)
+}
+
+// mutateDuringRebuild starts an asynchronous rebuild of tree, calls mutate while the rebuild is
+// running and returns the result of the rebuild.
+func mutateDuringRebuild(tree *ConvTree, gate *rebuildGate, mutate func()) error {
+	gate.started = make(chan struct{})
+	gate.release = make(chan struct{})
+	atomic.StoreInt32(&gate.armed, 1)
+	done := tree.RebuildAsync(context.Background())
+	<-gate.started
+	mutate()
+	close(gate.release)
+	return <-done
+}
+
+func gatedTree(t *testing.T) (*ConvTree, *rebuildGate) {
+	t.Helper()
+	gate := &rebuildGate{}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 100, 6, 2, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(3)), 3000, 100), WithIDGenerator(gate.id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.IsLeaf || tree.ChildTopLeft.IsLeaf {
+		t.Fatal("tree was not split deep enough")
+	}
+	return &tree, gate
+}
+
+func boundsOf(node *ConvTree) Bounds {
+	return Bounds{TopLeft: node.TopLeft, BottomRight: node.BottomRight}
+}
+
+// nodeAt returns the node of tree with the given bounds and fails the test if there is none.
+func nodeAt(t *testing.T, tree *ConvTree, bounds Bounds) *ConvTree {
+	t.Helper()
+	node := tree.nodeWithBounds(bounds, false)
+	if node == nil {
+		t.Fatalf("rebuilt tree has no node with bounds %v", bounds)
+	}
+	return node
+}
+
+func totalWeight(points []Point) int {
+	weight := 0
+	for _, point := range points {
+		weight += point.Weight
+	}
+	return weight
+}
+
+// TestRebuildAsyncReplaysSubtreeMutations checks that mutations of a subtree made during an
+// asynchronous rebuild are replayed on the same subtree of the rebuilt structure and leave the
+// rest of the tree untouched.
+func TestRebuildAsyncReplaysSubtreeMutations(t *testing.T) {
+	cases := []struct {
+		name   string
+		mutate func(t *testing.T, tree, sub *ConvTree)
+		check  func(t *testing.T, sub, rest *ConvTree, subPoints int)
+	}{
+		{
+			name:   "Clear",
+			mutate: func(t *testing.T, tree, sub *ConvTree) { sub.Clear() },
+			check: func(t *testing.T, sub, rest *ConvTree, subPoints int) {
+				if sub.IsLeaf {
+					t.Fatal("Clear collapsed the subtree")
+				}
+				if got := sub.Summary().Points; got != 0 {
+					t.Fatalf("expected an empty subtree, got %d points", got)
+				}
+			},
+		},
+		{
+			name:   "Reset",
+			mutate: func(t *testing.T, tree, sub *ConvTree) { sub.Reset() },
+			check: func(t *testing.T, sub, rest *ConvTree, subPoints int) {
+				if !sub.IsLeaf || len(sub.Points) != 0 {
+					t.Fatal("Reset did not turn the subtree into an empty leaf")
+				}
+			},
+		},
+		{
+			name:   "Prune",
+			mutate: func(t *testing.T, tree, sub *ConvTree) { sub.Prune(1 << 20) },
+			check: func(t *testing.T, sub, rest *ConvTree, subPoints int) {
+				if got := sub.Summary().Points; got != 0 {
+					t.Fatalf("expected a pruned subtree, got %d points", got)
+				}
+			},
+		},
+		{
+			name:   "Merge",
+			mutate: func(t *testing.T, tree, sub *ConvTree) { sub.Merge(1 << 20) },
+			check: func(t *testing.T, sub, rest *ConvTree, subPoints int) {
+				if !sub.IsLeaf || len(sub.Points) != subPoints {
+					t.Fatalf("expected a leaf with %d points, got leaf %v with %d points",
+						subPoints, sub.IsLeaf, len(sub.Points))
+				}
+			},
+		},
+		{
+			name: "RemoveFunc",
+			mutate: func(t *testing.T, tree, sub *ConvTree) {
+				sub.RemoveFunc(func(Point) bool { return true })
+			},
+			check: func(t *testing.T, sub, rest *ConvTree, subPoints int) {
+				if got := sub.Summary().Points; got != 0 {
+					t.Fatalf("expected an empty subtree, got %d points", got)
+				}
+			},
+		},
+		{
+			name: "Remove",
+			mutate: func(t *testing.T, tree, sub *ConvTree) {
+				point := sub.Leaves()[0].Points[0]
+				if !sub.Remove(Point{X: point.X, Y: point.Y}, 0) {
+					t.Fatal("point was not removed")
+				}
+			},
+			check: func(t *testing.T, sub, rest *ConvTree, subPoints int) {
+				if got := sub.Summary().Points; got != subPoints-1 {
+					t.Fatalf("expected %d points, got %d", subPoints-1, got)
+				}
+			},
+		},
+		{
+			name:   "DecayWeights",
+			mutate: func(t *testing.T, tree, sub *ConvTree) { sub.DecayWeights(0.5, 0) },
+			check: func(t *testing.T, sub, rest *ConvTree, subPoints int) {
+				points := sub.QueryRange(sub.TopLeft, sub.BottomRight)
+				if len(points) != subPoints || totalWeight(points) != 0 {
+					t.Fatalf("expected %d points of weight 0, got %d points of weight %d",
+						subPoints, len(points), totalWeight(points))
+				}
+			},
+		},
+		{
+			name: "Checkpoint",
+			mutate: func(t *testing.T, tree, sub *ConvTree) {
+				for _, p := range []Point{{X: 10, Y: 90, Weight: 1}, {X: 90, Y: 10, Weight: 1}} {
+					if err := tree.Insert(p, false); err != nil {
+						t.Fatal(err)
+					}
+				}
+				sub.Checkpoint()
+			},
+			check: func(t *testing.T, sub, rest *ConvTree, subPoints int) {
+				sub.walkNodes(func(node *ConvTree) bool {
+					if node.InsertCount != 0 || node.RemoveCount != 0 {
+						t.Fatalf("node %s keeps its churn counters", node.ID)
+					}
+					return true
+				})
+				if leaf, _ := rest.FindLeaf(90, 10); leaf.InsertCount != 1 {
+					t.Fatalf("expected 1 insert outside of the subtree, got %d", leaf.InsertCount)
+				}
+			},
+		},
+		{
+			name:   "SetMaxPoints",
+			mutate: func(t *testing.T, tree, sub *ConvTree) { sub.SetMaxPoints(30, true) },
+			check: func(t *testing.T, sub, rest *ConvTree, subPoints int) {
+				sub.walkLeaves(func(leaf *ConvTree) {
+					if len(leaf.Points) > 30 && leaf.Depth < leaf.MaxDepth {
+						t.Fatalf("leaf %s with %d points was not re-split", leaf.ID, len(leaf.Points))
+					}
+				})
+				large := false
+				rest.walkLeaves(func(leaf *ConvTree) {
+					large = large || len(leaf.Points) > 30
+				})
+				if !large {
+					t.Fatal("leaves outside of the subtree were re-split")
+				}
+			},
+		},
+		{
+			name: "SetKernel",
+			mutate: func(t *testing.T, tree, sub *ConvTree) {
+				if _, err := sub.SetKernel([][]float64{{0, 1, 0}, {1, 4, 1}, {0, 1, 0}}, 0); err != nil {
+					t.Fatal(err)
+				}
+			},
+			check: func(t *testing.T, sub, rest *ConvTree, subPoints int) {
+				if got := sub.Summary().Points; got != subPoints {
+					t.Fatalf("expected %d points, got %d", subPoints, got)
+				}
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tree, gate := gatedTree(t)
+			sub := tree.ChildTopLeft
+			bounds := boundsOf(sub)
+			subPoints := sub.Summary().Points
+			restBounds := boundsOf(tree.ChildBottomRight)
+			var want []Point
+			err := mutateDuringRebuild(tree, gate, func() {
+				c.mutate(t, tree, sub)
+				want = tree.QueryRange(tree.TopLeft, tree.BottomRight)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := tree.QueryRange(tree.TopLeft, tree.BottomRight)
+			if len(got) != len(want) || totalWeight(got) != totalWeight(want) {
+				t.Fatalf("rebuilt tree has %d points of weight %d instead of %d of weight %d",
+					len(got), totalWeight(got), len(want), totalWeight(want))
+			}
+			rest := nodeAt(t, tree, restBounds)
+			c.check(t, nodeAt(t, tree, bounds), rest, subPoints)
+			checkLeafCount(t, tree)
+			checkTiling(t, tree)
+		})
+	}
+}
+
+func TestRebuildAsyncKeepsPins(t *testing.T) {
+	tree, gate := gatedTree(t)
+	cell := tree.ChildTopLeft.Leaves()[0]
+	x, y := (cell.TopLeft.X+cell.BottomRight.X)/2, (cell.TopLeft.Y+cell.BottomRight.Y)/2
+	topLeft, bottomRight := cell.TopLeft, Point{X: x, Y: y}
+	x, y = (cell.TopLeft.X+x)/2, (cell.TopLeft.Y+y)/2
+	var id string
+	err := mutateDuringRebuild(tree, gate, func() {
+		if err := tree.ChildTopLeft.PinRegion(topLeft, bottomRight); err != nil {
+			t.Fatal(err)
+		}
+		leaf, _ := tree.FindLeaf(x, y)
+		id = leaf.ID
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rebuilt := range []string{"asynchronous", "synchronous"} {
+		leaf, _ := tree.FindLeaf(x, y)
+		if !leaf.Pinned || leaf.ID != id || leaf.TopLeft != topLeft || leaf.BottomRight != bottomRight {
+			t.Fatalf("pin is lost after the %s rebuild: leaf %s pinned %v with bounds %v %v",
+				rebuilt, leaf.ID, leaf.Pinned, leaf.TopLeft, leaf.BottomRight)
+		}
+		if err := tree.Rebuild(tree.MaxPoints, tree.MaxDepth, tree.ConvNum, tree.GridSize, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkLeafCount(t, tree)
+}
+
+func TestRebuildAsyncConflicts(t *testing.T) {
+	tree, gate := gatedTree(t)
+	leaves := len(tree.Leaves())
+	var root *ConvTree
+	err := mutateDuringRebuild(tree, gate, func() {
+		root = tree.Expand(Point{X: 150, Y: 50})
+	})
+	if err != ErrRebuildConflict {
+		t.Fatalf("expected ErrRebuildConflict, got %v", err)
+	}
+	if root == tree || root.Summary().Points != 3000 {
+		t.Fatal("tree was not expanded")
+	}
+	if got := len(tree.Leaves()); got != leaves {
+		t.Fatalf("expanded subtree has %d leaves instead of %d", got, leaves)
+	}
+	checkLeafCount(t, root)
+	checkTiling(t, root)
+
+	// A higher threshold makes the rebuilt structure coarser, so deep nodes have no counterpart.
+	tree, gate = gatedTree(t)
+	tree.SetMaxPoints(1500, false)
+	deep := tree.ChildTopLeft.ChildTopLeft
+	deepPoints := deep.Summary().Points
+	err = mutateDuringRebuild(tree, gate, func() {
+		deep.Clear()
+	})
+	if err != ErrRebuildConflict {
+		t.Fatalf("expected ErrRebuildConflict, got %v", err)
+	}
+	if tree.ChildTopLeft.ChildTopLeft != deep || deep.Summary().Points != 0 {
+		t.Fatal("tree was not left as mutated")
+	}
+	if got := tree.Summary().Points; got != 3000-deepPoints {
+		t.Fatalf("expected %d points, got %d", 3000-deepPoints, got)
+	}
+	checkLeafCount(t, tree)
+	checkTiling(t, tree)
+}
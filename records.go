@@ -0,0 +1,74 @@
+package convtree
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// LeafRecord is a flat description of a leaf for loading into tabular stores.
+type LeafRecord struct {
+	ID           string    `json:"id"`
+	Depth        int       `json:"depth"`
+	TopLeft      Point     `json:"top_left"`
+	BottomRight  Point     `json:"bottom_right"`
+	PointCount   int       `json:"point_count"`
+	TotalWeight  int       `json:"total_weight"`
+	BaselineTags []string  `json:"baseline_tags"`
+	LastInsertAt time.Time `json:"last_insert_at"`
+}
+
+// LeafRecords returns a record for every leaf in depth-first order, the same order as Leaves.
+func (tree *ConvTree) LeafRecords() []LeafRecord {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := []LeafRecord{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		result = append(result, leaf.leafRecord())
+	})
+	return result
+}
+
+// WriteLeafJSON writes the leaf records as a JSON array, one record at a time.
+func (tree *ConvTree) WriteLeafJSON(w io.Writer) error {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	writer := bufio.NewWriter(w)
+	writer.WriteByte('[')
+	var err error
+	first := true
+	tree.walkLeaves(func(leaf *ConvTree) {
+		if err != nil {
+			return
+		}
+		var data []byte
+		if data, err = json.Marshal(leaf.leafRecord()); err != nil {
+			return
+		}
+		if !first {
+			writer.WriteByte(',')
+		}
+		first = false
+		writer.Write(data)
+	})
+	if err != nil {
+		return err
+	}
+	writer.WriteByte(']')
+	return writer.Flush()
+}
+
+func (tree *ConvTree) leafRecord() LeafRecord {
+	tags := append([]string{}, tree.BaselineTags...)
+	return LeafRecord{
+		ID:           tree.ID,
+		Depth:        tree.Depth,
+		TopLeft:      Point{X: tree.TopLeft.X, Y: tree.TopLeft.Y},
+		BottomRight:  Point{X: tree.BottomRight.X, Y: tree.BottomRight.Y},
+		PointCount:   len(tree.Points),
+		TotalWeight:  tree.totalWeight(),
+		BaselineTags: tags,
+		LastInsertAt: tree.LastInsertAt,
+	}
+}
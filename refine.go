@@ -0,0 +1,86 @@
+package convtree
+
+import (
+	"math"
+	"sort"
+)
+
+// WithSplitRefinement enables a refinement pass after the grid-based split position is chosen.
+// The pass sweeps the exact point coordinates within one grid cell around the chosen position
+// and moves the split to the candidate that minimizes the weight of the heavier side, preferring
+// wider gaps between points when several candidates are equally good.
+func WithSplitRefinement() Option {
+	return func(config *treeConfig) {
+		config.refine = true
+	}
+}
+
+func (config *treeConfig) refineSplits() bool {
+	return config != nil && config.refine
+}
+
+func pointX(point Point) float64 {
+	return point.X
+}
+
+func pointY(point Point) float64 {
+	return point.Y
+}
+
+// refineSplit returns the refined split position on one axis. low and high are the node
+// extent on the axis and coordinate extracts the axis value of a point.
+func (tree *ConvTree) refineSplit(position, step, low, high, minLength float64, coordinate func(Point) float64) float64 {
+	bandLow := math.Max(position-step, low+minLength)
+	bandHigh := math.Min(position+step, high-minLength)
+	if bandLow >= bandHigh {
+		return position
+	}
+	below, total, current := 0, 0, 0
+	band := []Point{}
+	for _, point := range tree.Points {
+		value := coordinate(point)
+		total += point.Weight
+		if value < position {
+			current += point.Weight
+		}
+		if value < bandLow {
+			below += point.Weight
+		} else if value <= bandHigh {
+			band = append(band, point)
+		}
+	}
+	sort.Slice(band, func(i, j int) bool {
+		return coordinate(band[i]) < coordinate(band[j])
+	})
+	best := position
+	bestScore := maxInt(current, total-current)
+	bestGap := 0.0
+	previous := bandLow
+	for i := 0; i <= len(band); i++ {
+		next := bandHigh
+		if i < len(band) {
+			next = coordinate(band[i])
+		}
+		if next > previous {
+			score := maxInt(below, total-below)
+			gap := next - previous
+			if score < bestScore || (score == bestScore && gap > bestGap) {
+				best = (previous + next) / 2
+				bestScore = score
+				bestGap = gap
+			}
+		}
+		if i < len(band) {
+			below += band[i].Weight
+			previous = next
+		}
+	}
+	return best
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,44 @@
+package convtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSplitRefinementFindsGap(t *testing.T) {
+	// Two clusters on the diagonal separated by a gap between 48 and 53 on both axes, which does
+	// not contain any grid line of the 8x8 split grid.
+	r := rand.New(rand.NewSource(14))
+	points := []Point{}
+	for i := 0; i < 300; i++ {
+		points = append(points,
+			Point{X: 34 + r.Float64()*14, Y: 34 + r.Float64()*14, Weight: 1},
+			Point{X: 53 + r.Float64()*14, Y: 53 + r.Float64()*14, Weight: 1})
+	}
+	topLeft, bottomRight := Point{X: 0, Y: 100}, Point{X: 100, Y: 0}
+	plain, err := NewConvTree(topLeft, bottomRight, 1, 1, 500, 1, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refined, err := NewConvTree(topLeft, bottomRight, 1, 1, 500, 1, 2, 8, nil, points, WithSplitRefinement())
+	if err != nil {
+		t.Fatal(err)
+	}
+	inGap := func(value float64) bool {
+		return value > 48 && value < 53
+	}
+	split := plain.ChildTopLeft.BottomRight
+	if inGap(split.X) || inGap(split.Y) {
+		t.Fatalf("expected the grid split %v to miss the gap", split)
+	}
+	split = refined.ChildTopLeft.BottomRight
+	if !inGap(split.X) || !inGap(split.Y) {
+		t.Fatalf("refined split %v is not in the gap between the clusters", split)
+	}
+	if got := len(refined.ChildBottomLeft.Points); got != 300 {
+		t.Fatalf("bottom left child has %d points instead of the 300 of the lower cluster", got)
+	}
+	if got := len(refined.ChildTopRight.Points); got != 300 {
+		t.Fatalf("top right child has %d points instead of the 300 of the upper cluster", got)
+	}
+}
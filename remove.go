@@ -0,0 +1,93 @@
+package convtree
+
+// Remove deletes the first point whose coordinates differ from p by at most epsilon on each axis,
+// with the same Content matching rules as Contains. It reports whether a point was removed.
+func (tree *ConvTree) Remove(p Point, epsilon float64) bool {
+	tree.config.lock()
+	defer tree.config.unlock()
+	if !tree.remove(p, epsilon) {
+		return false
+	}
+	tree.config.recordMutation(tree, func(node *ConvTree) error {
+		node.remove(p, epsilon)
+		return nil
+	})
+	return true
+}
+
+func (tree *ConvTree) remove(p Point, epsilon float64) bool {
+	leaf, index, ok := tree.locate(p, epsilon)
+	if !ok {
+		return false
+	}
+	removed := leaf.Points[index]
+	points := make([]Point, 0, len(leaf.Points)-1)
+	points = append(points, leaf.Points[:index]...)
+	leaf.Points = append(points, leaf.Points[index+1:]...)
+	leaf.RemoveCount++
+	leaf.removeValue(removed)
+	return true
+}
+
+// RemoveFunc removes every point for which fn returns true and returns the number of removed
+// points. Leaves losing points get new point slices and their statistics and baseline tags
+// recomputed.
+func (tree *ConvTree) RemoveFunc(fn func(Point) bool) int {
+	tree.config.lock()
+	defer tree.config.unlock()
+	removed := tree.removeFunc(fn)
+	if removed > 0 {
+		tree.config.recordMutation(tree, func(node *ConvTree) error {
+			node.removeFunc(fn)
+			return nil
+		})
+	}
+	return removed
+}
+
+func (tree *ConvTree) removeFunc(fn func(Point) bool) int {
+	total := 0
+	tree.walkLeaves(func(leaf *ConvTree) {
+		kept := filterPoints(leaf.Points, fn)
+		if removed := len(leaf.Points) - len(kept); removed > 0 {
+			leaf.Points = kept
+			leaf.RemoveCount += int64(removed)
+			leaf.recomputeValues()
+			leaf.BaselineTags = leaf.getBaseline()
+			total += removed
+		}
+	})
+	return total
+}
+
+// RemoveFunc removes every point for which fn returns true and returns the number of removed points.
+func (tree *QuadTree) RemoveFunc(fn func(Point) bool) int {
+	total := 0
+	tree.walkLeaves(func(leaf *QuadTree) {
+		kept := filterPoints(leaf.Points, fn)
+		total += len(leaf.Points) - len(kept)
+		leaf.Points = kept
+	})
+	return total
+}
+
+// filterPoints drops the points for which remove returns true. If any point is dropped, the kept
+// points are copied to a new slice, so slices sharing the backing array are never modified.
+func filterPoints(points []Point, remove func(Point) bool) []Point {
+	var kept []Point
+	for i, point := range points {
+		switch {
+		case remove(point):
+			if kept == nil {
+				kept = make([]Point, i, len(points)-1)
+				copy(kept, points[:i])
+			}
+		case kept != nil:
+			kept = append(kept, point)
+		}
+	}
+	if kept == nil {
+		return points
+	}
+	return kept
+}
@@ -0,0 +1,120 @@
+package convtree
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func taggedLeaf(t *testing.T) ConvTree {
+	t.Helper()
+	points := []Point{}
+	for i := 0; i < 6; i++ {
+		points = append(points, Point{X: float64(10 + i), Y: 10, Weight: 2, Content: []string{"a"}})
+	}
+	for i := 0; i < 3; i++ {
+		points = append(points, Point{X: float64(10 + i), Y: 20, Weight: 1, Content: []string{"b"}})
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 100, 4, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tree.IsLeaf {
+		t.Fatal("tree was split")
+	}
+	return tree
+}
+
+func TestRemoveUpdatesLeaf(t *testing.T) {
+	tree := taggedLeaf(t)
+	if got := tree.BaselineTags; !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("expected baseline [a], got %v", got)
+	}
+	if !tree.Remove(Point{X: 10, Y: 10}, 0) {
+		t.Fatal("point was not removed")
+	}
+	if tree.Remove(Point{X: 10, Y: 10}, 0) {
+		t.Fatal("removed point was removed again")
+	}
+	if got := tree.Summary().TotalWeight; got != 13 {
+		t.Fatalf("expected total weight 13, got %d", got)
+	}
+	if tree.RemoveCount != 1 || len(tree.Points) != 8 {
+		t.Fatalf("expected 8 points and 1 removal, got %d points and %d removals", len(tree.Points), tree.RemoveCount)
+	}
+	removed := tree.RemoveFunc(func(p Point) bool {
+		tags, _ := p.Content.([]string)
+		return len(tags) > 0 && tags[0] == "a"
+	})
+	if removed != 5 {
+		t.Fatalf("expected 5 removed points, got %d", removed)
+	}
+	if got := tree.Summary().TotalWeight; got != 3 {
+		t.Fatalf("expected total weight 3, got %d", got)
+	}
+	if tree.RemoveCount != 6 {
+		t.Fatalf("expected 6 removals, got %d", tree.RemoveCount)
+	}
+	if got := tree.BaselineTags; !reflect.DeepEqual(got, []string{"b"}) {
+		t.Fatalf("expected baseline [b] after removal, got %v", got)
+	}
+}
+
+func TestRemoveKeepsSharedSlices(t *testing.T) {
+	tree := taggedLeaf(t)
+	shared := tree.Points
+	original := append([]Point{}, shared...)
+	tree.Remove(Point{X: 11, Y: 10}, 0)
+	tree.RemoveFunc(func(p Point) bool { return p.Y == 20 })
+	if !reflect.DeepEqual(shared, original) {
+		t.Fatal("removal modified a slice sharing the points of the leaf")
+	}
+}
+
+func TestRemoveRoutesLaterPoints(t *testing.T) {
+	points := uniformPoints(rand.New(rand.NewSource(11)), 2000, 100)
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 100, 6, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := len(tree.Leaves())
+	for _, p := range points[:500] {
+		if !tree.Remove(p, 0) {
+			t.Fatalf("point %v was not removed", p)
+		}
+	}
+	if got := tree.RemoveFunc(func(p Point) bool { return p.X < 10 }); got == 0 {
+		t.Fatal("no points were removed")
+	}
+	if got := len(tree.Leaves()); got != leaves {
+		t.Fatalf("removal changed the structure from %d to %d leaves", leaves, got)
+	}
+	for _, p := range points[:500] {
+		if tree.Contains(p, 0) {
+			t.Fatalf("removed point %v is still found", p)
+		}
+		if p.X < 10 {
+			continue
+		}
+		if err := tree.Insert(p, false); err != nil {
+			t.Fatal(err)
+		}
+		leaf, _ := tree.FindLeaf(p.X, p.Y)
+		if !leaf.Contains(p, 0) {
+			t.Fatalf("point %v was not routed to leaf %s", p, leaf.ID)
+		}
+	}
+	total := 0
+	for _, leaf := range tree.Leaves() {
+		total += len(leaf.Points)
+		for _, p := range leaf.Points {
+			if !leaf.contains(p.X, p.Y) {
+				t.Fatalf("point %v is outside of leaf %s", p, leaf.ID)
+			}
+		}
+	}
+	if got := len(tree.QueryRange(tree.TopLeft, tree.BottomRight)); got != total {
+		t.Fatalf("query returned %d of %d points", got, total)
+	}
+	checkLeafCount(t, &tree)
+}
@@ -0,0 +1,77 @@
+package convtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestResetCollapsesToSingleLeaf(t *testing.T) {
+	r := rand.New(rand.NewSource(95))
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil,
+		uniformPoints(r, 1000, 100), WithMaxLeaves(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := tree.FindLeaf(15, 85)
+	width, height := leaf.BottomRight.X-leaf.TopLeft.X, leaf.TopLeft.Y-leaf.BottomRight.Y
+	if err := tree.PinRegion(Point{X: leaf.TopLeft.X + width/4, Y: leaf.TopLeft.Y - height/4},
+		Point{X: leaf.BottomRight.X - width/4, Y: leaf.BottomRight.Y + height/4}); err != nil {
+		t.Fatal(err)
+	}
+	if stats := tree.Summary(); !stats.LeafCapHit {
+		t.Fatalf("expected the leaf cap to be hit, got %d leaves", stats.Leaves)
+	}
+	id := tree.ID
+
+	// Resetting a subtree only collapses that subtree.
+	var sub *ConvTree
+	for _, child := range []*ConvTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight} {
+		if !child.IsLeaf && !child.hasPinnedLeaf() {
+			sub = child
+		}
+	}
+	if sub == nil {
+		t.Fatal("expected a split subtree without pins")
+	}
+	sub.Reset()
+	if !sub.IsLeaf || len(sub.Points) != 0 || !tree.hasPinnedLeaf() {
+		t.Fatal("subtree reset did not collapse only the subtree")
+	}
+	checkLeafCount(t, &tree)
+
+	tree.Reset()
+	stats := tree.Summary()
+	if !tree.IsLeaf || tree.ID != id || tree.Pinned || stats.Points != 0 || stats.Leaves != 1 ||
+		stats.TotalLeaves != 1 {
+		t.Fatalf("reset tree is not a single empty leaf: %+v", stats)
+	}
+	if tree.TopLeft != (Point{X: 0, Y: 100}) || tree.BottomRight != (Point{X: 100, Y: 0}) {
+		t.Fatalf("reset changed the bounds to %v %v", tree.TopLeft, tree.BottomRight)
+	}
+
+	// The tree splits up to the leaf cap again and the dropped pin does not come back.
+	if _, err := tree.InsertBatch(uniformPoints(r, 1000, 100)); err != nil {
+		t.Fatal(err)
+	}
+	stats = tree.Summary()
+	if stats.Leaves < 30 || stats.Leaves > 40 {
+		t.Fatalf("expected the tree to split up to the cap again, got %d leaves", stats.Leaves)
+	}
+	checkLeafCount(t, &tree)
+	if tree.hasPinnedLeaf() {
+		t.Fatal("a pinned region survived the reset")
+	}
+}
+
+func TestClearKeepsStructure(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(96)), 1000, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := tree.Summary().Leaves
+	tree.Clear()
+	if stats := tree.Summary(); stats.Points != 0 || stats.Leaves != leaves || stats.TotalLeaves != leaves {
+		t.Fatalf("expected %d empty leaves after Clear, got %+v", leaves, stats)
+	}
+}
@@ -0,0 +1,154 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+const routerMagic = "CTR1"
+
+// Router maps coordinates to leaf IDs using only the split geometry of a tree. It holds no points
+// and no per-node allocations, so it is much smaller than the tree it was extracted from.
+// A Router is immutable and safe for concurrent use.
+type Router struct {
+	bounds    [4]float64
+	root      int32
+	splits    []float64
+	children  []int32
+	ids       string
+	idOffsets []uint32
+}
+
+// RoutingIndex extracts the split geometry and leaf IDs of the tree into a Router. The router
+// does not follow later changes of the tree.
+func (tree *ConvTree) RoutingIndex() *Router {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	router := &Router{
+		bounds: [4]float64{tree.TopLeft.X, tree.TopLeft.Y, tree.BottomRight.X, tree.BottomRight.Y},
+	}
+	ids := &bytes.Buffer{}
+	router.idOffsets = append(router.idOffsets, 0)
+	router.root = router.add(tree, ids)
+	router.ids = ids.String()
+	return router
+}
+
+// add appends the node and returns its reference: an internal node index or the bitwise
+// complement of a leaf index.
+func (router *Router) add(node *ConvTree, ids *bytes.Buffer) int32 {
+	if node.IsLeaf {
+		ids.WriteString(node.ID)
+		router.idOffsets = append(router.idOffsets, uint32(ids.Len()))
+		return ^int32(len(router.idOffsets) - 2)
+	}
+	index := int32(len(router.splits) / 2)
+	router.splits = append(router.splits, node.ChildTopLeft.BottomRight.X, node.ChildTopLeft.BottomRight.Y)
+	router.children = append(router.children, 0, 0, 0, 0)
+	for i, child := range []*ConvTree{node.ChildTopLeft, node.ChildTopRight, node.ChildBottomLeft, node.ChildBottomRight} {
+		reference := router.add(child, ids)
+		router.children[4*int(index)+i] = reference
+	}
+	return index
+}
+
+// Route returns the ID of the leaf containing the coordinate, resolving points on split lines
+// as FindLeaf does. The second return value is false when the coordinate is outside the bounds.
+func (router *Router) Route(x, y float64) (string, bool) {
+	if !(x >= router.bounds[0] && x <= router.bounds[2] && y <= router.bounds[1] && y >= router.bounds[3]) {
+		return "", false
+	}
+	reference := router.root
+	for reference >= 0 {
+		child := 4 * reference
+		if x > router.splits[2*reference] {
+			child++
+		}
+		if y < router.splits[2*reference+1] {
+			child += 2
+		}
+		reference = router.children[child]
+	}
+	leaf := ^reference
+	return router.ids[router.idOffsets[leaf]:router.idOffsets[leaf+1]], true
+}
+
+// MarshalBinary encodes the router in a compact little-endian format.
+func (router *Router) MarshalBinary() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(routerMagic)
+	header := []uint32{uint32(len(router.children) / 4), uint32(len(router.idOffsets)), uint32(len(router.ids))}
+	for _, data := range []interface{}{router.bounds, router.root, header, router.splits, router.children, router.idOffsets} {
+		if err := binary.Write(buf, binary.LittleEndian, data); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteString(router.ids)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a router encoded by MarshalBinary.
+func (router *Router) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(routerMagic)) {
+		return errors.New("data is not an encoded router")
+	}
+	reader := bytes.NewReader(data[len(routerMagic):])
+	decoded := Router{}
+	header := make([]uint32, 3)
+	if err := binary.Read(reader, binary.LittleEndian, &decoded.bounds); err != nil {
+		return err
+	}
+	if err := binary.Read(reader, binary.LittleEndian, &decoded.root); err != nil {
+		return err
+	}
+	if err := binary.Read(reader, binary.LittleEndian, header); err != nil {
+		return err
+	}
+	nodes, offsets, idsLength := int(header[0]), int(header[1]), int(header[2])
+	if nodes*32+offsets*4+idsLength != reader.Len() || offsets < 2 {
+		return errors.New("encoded router is corrupted")
+	}
+	decoded.splits = make([]float64, 2*nodes)
+	decoded.children = make([]int32, 4*nodes)
+	decoded.idOffsets = make([]uint32, offsets)
+	for _, slice := range []interface{}{decoded.splits, decoded.children, decoded.idOffsets} {
+		if err := binary.Read(reader, binary.LittleEndian, slice); err != nil {
+			return err
+		}
+	}
+	ids := make([]byte, idsLength)
+	if _, err := reader.Read(ids); err != nil && idsLength > 0 {
+		return err
+	}
+	decoded.ids = string(ids)
+	if err := decoded.validate(); err != nil {
+		return err
+	}
+	*router = decoded
+	return nil
+}
+
+func (router *Router) validate() error {
+	leaves := int32(len(router.idOffsets) - 1)
+	valid := func(reference int32) bool {
+		if reference >= 0 {
+			return int(reference) < len(router.children)/4
+		}
+		return ^reference < leaves
+	}
+	if !valid(router.root) {
+		return errors.New("encoded router is corrupted")
+	}
+	for i, reference := range router.children {
+		if !valid(reference) || (reference >= 0 && int(reference) <= i/4) {
+			return errors.New("encoded router is corrupted")
+		}
+	}
+	for i := 1; i < len(router.idOffsets); i++ {
+		if router.idOffsets[i] < router.idOffsets[i-1] || int(router.idOffsets[i]) > len(router.ids) {
+			return errors.New("encoded router is corrupted")
+		}
+	}
+	return nil
+}
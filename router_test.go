@@ -0,0 +1,152 @@
+package convtree
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// routingProbes returns random points inside the tree together with points on every split line,
+// on the split crossings and on the tree corners.
+func routingProbes(r *rand.Rand, tree *ConvTree) []Point {
+	probes := uniformPoints(r, 2000, 100)
+	probes = append(probes, tree.TopLeft, tree.BottomRight, Point{X: tree.TopLeft.X, Y: tree.BottomRight.Y},
+		Point{X: tree.BottomRight.X, Y: tree.TopLeft.Y})
+	tree.Walk(func(node *ConvTree, depth int) bool {
+		if node.IsLeaf {
+			return true
+		}
+		x, y := node.ChildTopLeft.BottomRight.X, node.ChildTopLeft.BottomRight.Y
+		probes = append(probes, Point{X: x, Y: y}, Point{X: x, Y: node.TopLeft.Y}, Point{X: x, Y: node.BottomRight.Y},
+			Point{X: node.TopLeft.X, Y: y}, Point{X: node.BottomRight.X, Y: y},
+			Point{X: x, Y: (y + node.TopLeft.Y) / 2}, Point{X: (x + node.BottomRight.X) / 2, Y: y})
+		return true
+	})
+	return probes
+}
+
+func checkRoutes(t *testing.T, tree *ConvTree, router *Router, probes []Point) {
+	t.Helper()
+	for _, probe := range probes {
+		id, ok := router.Route(probe.X, probe.Y)
+		leaf, found := tree.FindLeaf(probe.X, probe.Y)
+		if !ok || !found || id != leaf.ID {
+			t.Fatalf("point %v is routed to %q, but FindLeaf returns %s", probe, id, leaf.ID)
+		}
+	}
+	for _, outside := range []Point{{X: -1, Y: 50}, {X: 50, Y: 100.5}, {X: 101, Y: -1}} {
+		if id, ok := router.Route(outside.X, outside.Y); ok || id != "" {
+			t.Fatalf("point %v outside of the bounds is routed to %q", outside, id)
+		}
+	}
+}
+
+func TestRouterMatchesFindLeaf(t *testing.T) {
+	r := rand.New(rand.NewSource(61))
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 30, 8, 1, 8, nil,
+		uniformPoints(r, 4000, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := tree.RoutingIndex()
+	probes := routingProbes(r, &tree)
+	checkRoutes(t, &tree, router, probes)
+
+	data, err := router.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := &Router{}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, router) {
+		t.Fatal("decoded router differs from the encoded one")
+	}
+	checkRoutes(t, &tree, decoded, probes)
+
+	single, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 30, 8, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = single.RoutingIndex().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := decoded.Route(50, 50); !ok || id != single.ID {
+		t.Fatalf("single leaf router returned %q", id)
+	}
+}
+
+func TestRouterRejectsCorruptedData(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 30, 8, 1, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(62)), 1000, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := tree.RoutingIndex()
+	data, err := router.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := &Router{}
+	if err := original.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	nodes := len(router.children) / 4
+	childrenOffset := len(routerMagic) + 32 + 4 + 12 + 16*nodes
+	offsetsOffset := childrenOffset + 16*nodes
+	corrupt := func(name string, change func(data []byte) []byte) {
+		t.Helper()
+		decoded := *original
+		if err := decoded.UnmarshalBinary(change(append([]byte{}, data...))); err == nil {
+			t.Fatalf("%s: corrupted data was accepted", name)
+		}
+		if !reflect.DeepEqual(&decoded, original) {
+			t.Fatalf("%s: rejected data changed the router", name)
+		}
+	}
+	corrupt("magic", func(data []byte) []byte {
+		data[0] = 'X'
+		return data
+	})
+	corrupt("trailing byte", func(data []byte) []byte {
+		return append(data, 0)
+	})
+	for _, length := range []int{0, 3, 10, 40, 52, childrenOffset, len(data) - 1} {
+		corrupt("truncated", func(data []byte) []byte {
+			return data[:length]
+		})
+	}
+	corrupt("child cycle", func(data []byte) []byte {
+		binary.LittleEndian.PutUint32(data[childrenOffset:], 0)
+		return data
+	})
+	corrupt("missing child", func(data []byte) []byte {
+		binary.LittleEndian.PutUint32(data[childrenOffset:], uint32(nodes))
+		return data
+	})
+	corrupt("missing leaf", func(data []byte) []byte {
+		binary.LittleEndian.PutUint32(data[childrenOffset+4:], uint32(^int32(len(router.idOffsets))))
+		return data
+	})
+	corrupt("ID offset", func(data []byte) []byte {
+		binary.LittleEndian.PutUint32(data[offsetsOffset+4:], uint32(len(router.ids)+1))
+		return data
+	})
+
+	// Random byte flips must never make decoding or routing panic.
+	r := rand.New(rand.NewSource(63))
+	for i := 0; i < 500; i++ {
+		flipped := append([]byte{}, data...)
+		flipped[r.Intn(len(flipped))] ^= byte(1 + r.Intn(255))
+		decoded := &Router{}
+		if err := decoded.UnmarshalBinary(flipped); err == nil {
+			decoded.Route(50, 50)
+		}
+	}
+}
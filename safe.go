@@ -0,0 +1,54 @@
+package convtree
+
+import "context"
+
+// SafeConvTree restricts a tree to methods that are safe for concurrent use. Every ConvTree method
+// takes the lock shared by all nodes of the tree, so reads run concurrently while writes, including
+// the splits they trigger, are exclusive. The exported fields of ConvTree are not protected by the
+// lock, and SafeConvTree hides them.
+type SafeConvTree struct {
+	tree *ConvTree
+}
+
+// NewSafeConvTree wraps the tree, which must not be used directly afterwards. Trees that were not
+// built by a constructor get a lock of their own.
+func NewSafeConvTree(tree *ConvTree) *SafeConvTree {
+	if tree.config == nil {
+		config := newTreeConfig(nil)
+		config.rootDepth = tree.Depth
+		tree.walkNodes(func(node *ConvTree) bool {
+			node.config = config
+			return true
+		})
+		tree.resetLeafCount()
+	}
+	return &SafeConvTree{tree: tree}
+}
+
+func (safe *SafeConvTree) Insert(point Point, allowSplit bool) error {
+	return safe.tree.Insert(point, allowSplit)
+}
+
+func (safe *SafeConvTree) InsertBatch(points []Point) (StructureChange, error) {
+	return safe.tree.InsertBatch(points)
+}
+
+func (safe *SafeConvTree) QueryRange(topLeft, bottomRight Point) []Point {
+	return safe.tree.QueryRange(topLeft, bottomRight)
+}
+
+func (safe *SafeConvTree) Stats() map[string]CellStats {
+	return safe.tree.Stats()
+}
+
+func (safe *SafeConvTree) Summary() TreeStats {
+	return safe.tree.Summary()
+}
+
+func (safe *SafeConvTree) Clear() {
+	safe.tree.Clear()
+}
+
+func (safe *SafeConvTree) RebuildAsync(ctx context.Context) <-chan error {
+	return safe.tree.RebuildAsync(ctx)
+}
@@ -0,0 +1,61 @@
+package convtree
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// TestSafeConvTreeConcurrentUse is meant to be run with -race.
+func TestSafeConvTreeConcurrentUse(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 20, 8, 1, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(1)), 500, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	safe := NewSafeConvTree(&tree)
+	const writers, inserts, iterations = 4, 300, 50
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			for _, point := range uniformPoints(rand.New(rand.NewSource(seed)), inserts, 100) {
+				if err := safe.Insert(point, true); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(int64(w + 2))
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < iterations; n++ {
+				safe.QueryRange(Point{X: 20, Y: 80}, Point{X: 80, Y: 20})
+				safe.Stats()
+				safe.Summary()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for n := 0; n < iterations; n++ {
+			if err := <-safe.RebuildAsync(context.Background()); err != nil && err != ErrRebuildInProgress {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	if got, want := safe.Summary().Points, 500+writers*inserts; got != want {
+		t.Fatalf("expected %d points, got %d", want, got)
+	}
+	if got := len(safe.QueryRange(Point{X: 0, Y: 100}, Point{X: 100, Y: 0})); got != 500+writers*inserts {
+		t.Fatalf("query returned %d points", got)
+	}
+	checkLeafCount(t, &tree)
+}
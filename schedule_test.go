@@ -0,0 +1,70 @@
+package convtree
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestScheduleThresholds(t *testing.T) {
+	for _, test := range []struct {
+		schedule MaxPointsSchedule
+		name     string
+		want     []int
+	}{
+		{ConstantSchedule(50), "constant(50)", []int{50, 50, 50, 50, 50, 50, 50}},
+		{LinearSchedule(50, 10), "linear(50,10)", []int{50, 60, 70, 80, 90, 100, 110}},
+		{ExponentialSchedule(50, 2), "exponential(50,2)", []int{50, 50, 100, 100, 200, 200, 400}},
+		{ExponentialSchedule(50, 0), "exponential(50,1)", []int{50, 100, 200, 400, 800, 1600, 3200}},
+	} {
+		if test.schedule.Name != test.name {
+			t.Fatalf("expected schedule %s, got %s", test.name, test.schedule.Name)
+		}
+		for depth, want := range test.want {
+			if got := test.schedule.Threshold(depth); got != want {
+				t.Fatalf("%s: threshold at depth %d is %d instead of %d", test.name, depth, got, want)
+			}
+		}
+	}
+}
+
+// TestExponentialScheduleByDepth fills a node at depth 0 and a node at depth 6 with the same
+// points scaled to their bounds. Only the node at depth 0 exceeds its threshold.
+func TestExponentialScheduleByDepth(t *testing.T) {
+	unit := uniformPoints(rand.New(rand.NewSource(91)), 200, 1)
+	for _, test := range []struct {
+		name      string
+		opts      []Option
+		deepSplit bool
+	}{
+		{name: "exponential", opts: []Option{WithMaxPointsSchedule(ExponentialSchedule(50, 2))}},
+		{name: "constant", deepSplit: true},
+	} {
+		tree, err := NewConvTree(Point{X: 0, Y: 64}, Point{X: 64, Y: 0}, 0.01, 0.01, 50, 10, 1, 8, nil, nil, test.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node := &tree
+		for node.Depth < 6 {
+			node.divide((node.TopLeft.X+node.BottomRight.X)/2, (node.TopLeft.Y+node.BottomRight.Y)/2)
+			node = node.ChildTopLeft
+		}
+		for _, point := range unit {
+			scaled := Point{X: node.TopLeft.X + point.X, Y: node.BottomRight.Y + point.Y, Weight: 1}
+			if err := tree.Insert(scaled, true); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if node.IsLeaf == test.deepSplit {
+			t.Fatalf("%s: node at depth 6 with %d points split: %v", test.name, len(unit), !node.IsLeaf)
+		}
+
+		shallow, err := NewConvTree(Point{X: 0, Y: 1}, Point{X: 1, Y: 0}, 0.01, 0.01, 50, 10, 1, 8, nil, unit,
+			test.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shallow.IsLeaf {
+			t.Fatalf("%s: root with %d points was not split", test.name, len(unit))
+		}
+	}
+}
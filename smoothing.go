@@ -0,0 +1,81 @@
+package convtree
+
+import "math"
+
+// SmoothedTags aggregates tag frequencies of the leaf and its neighbors up to radius adjacency
+// hops away and returns the frequent tags of the aggregate. Frequencies are normalized by the
+// number of tagged points in every leaf, so large neighbors do not outweigh small ones. A neighbor
+// contributes in proportion to the border it shares with the leaf it was reached from, relative
+// to that leaf perimeter, and the contribution halves with every hop. Together, neighbors weigh
+// less than the leaf itself.
+func (tree *ConvTree) SmoothedTags(leafID string, radius int) []string {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	var start *ConvTree
+	tree.walkLeaves(func(leaf *ConvTree) {
+		if leaf.ID == leafID {
+			start = leaf
+		}
+	})
+	if start == nil {
+		return []string{}
+	}
+	scores := map[string]float64{}
+	weights := map[*ConvTree]float64{start: 1}
+	frontier := []*ConvTree{start}
+	start.addTagFrequencies(scores, 1)
+	for hop := 1; hop <= radius && len(frontier) > 0; hop++ {
+		next := []*ConvTree{}
+		for _, leaf := range frontier {
+			perimeter := 2 * ((leaf.BottomRight.X - leaf.TopLeft.X) + (leaf.TopLeft.Y - leaf.BottomRight.Y))
+			for _, neighbor := range tree.neighbors(leaf) {
+				if _, ok := weights[neighbor]; ok {
+					continue
+				}
+				weight := weights[leaf] * 0.5 * sharedBorder(leaf, neighbor) / perimeter
+				weights[neighbor] = weight
+				neighbor.addTagFrequencies(scores, weight)
+				next = append(next, neighbor)
+			}
+		}
+		frontier = next
+	}
+	return filterTags(scores)
+}
+
+func (tree *ConvTree) addTagFrequencies(scores map[string]float64, weight float64) {
+	counts := tree.tagCounts()
+	tagged := 0
+	for _, point := range tree.Points {
+		if _, ok := pointTags(point); ok {
+			tagged++
+		}
+	}
+	if tagged == 0 {
+		return
+	}
+	for tag, count := range counts {
+		scores[tag] += weight * count / float64(tagged)
+	}
+}
+
+// neighbors returns the leaves sharing a border of positive length with the leaf.
+func (tree *ConvTree) neighbors(leaf *ConvTree) []*ConvTree {
+	result := []*ConvTree{}
+	for _, candidate := range tree.leavesInRegion(leaf.TopLeft, leaf.BottomRight) {
+		if candidate != leaf && sharedBorder(leaf, candidate) > 0 {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+func sharedBorder(a, b *ConvTree) float64 {
+	if a.BottomRight.X == b.TopLeft.X || b.BottomRight.X == a.TopLeft.X {
+		return math.Max(0, math.Min(a.TopLeft.Y, b.TopLeft.Y)-math.Max(a.BottomRight.Y, b.BottomRight.Y))
+	}
+	if a.BottomRight.Y == b.TopLeft.Y || b.BottomRight.Y == a.TopLeft.Y {
+		return math.Max(0, math.Min(a.BottomRight.X, b.BottomRight.X)-math.Max(a.TopLeft.X, b.TopLeft.X))
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+package convtree
+
+import (
+	"reflect"
+	"testing"
+)
+
+// smoothingTree returns a 100x100 tree divided into quarters, with the top right quarter divided
+// into four 25x25 leaves. The top left leaf holds two points tagged "a" and two tagged "b".
+func smoothingTree(t *testing.T) *ConvTree {
+	t.Helper()
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.divide(50, 50)
+	tree.ChildTopRight.divide(75, 75)
+	addTagged(t, &tree, Point{X: 25, Y: 75}, "a", 2)
+	addTagged(t, &tree, Point{X: 25, Y: 75}, "b", 2)
+	return &tree
+}
+
+func addTagged(t *testing.T, tree *ConvTree, at Point, tag string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := tree.Insert(Point{X: at.X, Y: at.Y, Weight: 1, Content: []string{tag}}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSmoothedTagsRadiusZero(t *testing.T) {
+	tree := smoothingTree(t)
+	addTagged(t, tree, Point{X: 25, Y: 75}, "a", 1)
+	addTagged(t, tree, Point{X: 25, Y: 25}, "c", 10)
+	addTagged(t, tree, Point{X: 60, Y: 90}, "c", 10)
+	id := tree.ChildTopLeft.ID
+	if got := tree.SmoothedTags(id, 0); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("expected only the tags of the leaf, got %v", got)
+	}
+	// The neighbors add 0.5 * 50 / 200 + 0.5 * 25 / 200 = 0.1875 to "c", which stays far below the
+	// 0.6 of "a".
+	if got := tree.SmoothedTags(id, 1); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Fatalf("neighbors must weigh less than the leaf itself, got %v", got)
+	}
+	if got := tree.SmoothedTags("unknown", 1); len(got) != 0 {
+		t.Fatalf("unknown leaf returned %v", got)
+	}
+}
+
+func TestSmoothedTagsDecay(t *testing.T) {
+	tree := smoothingTree(t)
+	// The bottom right quarter only touches the top left leaf at a corner. It is reached in two
+	// hops with a small weight, which is still enough to break the tie between "a" and "b".
+	addTagged(t, tree, Point{X: 75, Y: 25}, "b", 5)
+	id := tree.ChildTopLeft.ID
+	if got := tree.SmoothedTags(id, 1); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("expected a tie within one hop, got %v", got)
+	}
+	if got := tree.SmoothedTags(id, 2); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Fatalf("expected the second hop to favor b, got %v", got)
+	}
+}
+
+func TestSmoothedTagsSharedBorder(t *testing.T) {
+	// The bottom left quarter shares a border of 50 with the top left leaf, the upper left leaf of
+	// the top right quarter only 25, so the tag of the bottom left quarter wins.
+	for _, c := range []struct{ below, right, want string }{{"a", "b", "a"}, {"b", "a", "b"}} {
+		tree := smoothingTree(t)
+		addTagged(t, tree, Point{X: 25, Y: 25}, c.below, 3)
+		addTagged(t, tree, Point{X: 60, Y: 90}, c.right, 3)
+		if got := tree.SmoothedTags(tree.ChildTopLeft.ID, 1); !reflect.DeepEqual(got, []string{c.want}) {
+			t.Fatalf("%s below and %s to the right: expected [%s], got %v", c.below, c.right, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package convtree
+
+import "sort"
+
+// CellDelta compares the contents of one leaf region of the first tree in two snapshots.
+type CellDelta struct {
+	ID          string
+	TopLeft     Point
+	BottomRight Point
+	PointsA     int
+	PointsB     int
+	WeightA     int
+	WeightB     int
+	NewTags     []string
+}
+
+// CompareTrees compares two snapshots on the leaf geometry of a. Every leaf of a is matched with
+// the points of b inside its bounds, and NewTags lists the tags of these points missing from the
+// leaf BaselineTags. Points of b lying on a shared leaf edge are attributed to a single leaf by
+// the routing rule of Insert, so the counts of b add up to the points of b within the bounds of a.
+func CompareTrees(a, b *ConvTree) []CellDelta {
+	defer rLockPair(a.config, b.config)()
+	result := []CellDelta{}
+	a.walkLeaves(func(leaf *ConvTree) {
+		delta := CellDelta{
+			ID:          leaf.ID,
+			TopLeft:     leaf.TopLeft,
+			BottomRight: leaf.BottomRight,
+			PointsA:     len(leaf.Points),
+			WeightA:     leaf.totalWeight(),
+			NewTags:     []string{},
+		}
+		baseline := make(map[string]bool, len(leaf.BaselineTags))
+		for _, tag := range leaf.BaselineTags {
+			baseline[tag] = true
+		}
+		points := []Point{}
+		b.queryRange(leaf.TopLeft, leaf.BottomRight, func(point Point) bool {
+			return a.findLeaf(point.X, point.Y) == leaf
+		}, &points, nil)
+		newTags := map[string]bool{}
+		for _, point := range points {
+			delta.PointsB++
+			delta.WeightB += point.Weight
+			tags, _ := pointTags(point)
+			for _, tag := range tags {
+				if !baseline[tag] && !newTags[tag] {
+					newTags[tag] = true
+					delta.NewTags = append(delta.NewTags, tag)
+				}
+			}
+		}
+		sort.Strings(delta.NewTags)
+		result = append(result, delta)
+	})
+	return result
+}
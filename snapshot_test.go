@@ -0,0 +1,85 @@
+package convtree
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompareTreesCounts(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	a, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil, uniformPoints(r, 500, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := uniformPoints(r, 300, 100)
+	points[0].Content = []string{"new"}
+	b, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deltas := CompareTrees(&a, &b)
+	if len(deltas) != len(a.Leaves()) {
+		t.Fatalf("expected %d deltas, got %d", len(a.Leaves()), len(deltas))
+	}
+	totalA, totalB, tagged := 0, 0, 0
+	for _, delta := range deltas {
+		totalA += delta.PointsA
+		totalB += delta.PointsB
+		if len(delta.NewTags) > 0 {
+			tagged++
+			leaf := a.findLeaf(points[0].X, points[0].Y)
+			if delta.ID != leaf.ID || delta.NewTags[0] != "new" {
+				t.Fatalf("unexpected new tags %v in leaf %s", delta.NewTags, delta.ID)
+			}
+		}
+	}
+	if totalA != 500 || totalB != 300 || tagged != 1 {
+		t.Fatalf("expected 500 and 300 points and 1 tagged leaf, got %d, %d and %d", totalA, totalB, tagged)
+	}
+}
+
+// TestCompareTreesOppositeOrder compares two trees in both argument orders while both are being
+// written to. Locking them in argument order deadlocks once a writer waits on each tree.
+func TestCompareTreesOppositeOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	a, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil, uniformPoints(r, 500, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil, uniformPoints(r, 500, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const iterations = 200
+	var wg sync.WaitGroup
+	for _, pair := range [][2]*ConvTree{{&a, &b}, {&b, &a}} {
+		wg.Add(1)
+		go func(first, second *ConvTree) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				CompareTrees(first, second)
+			}
+		}(pair[0], pair[1])
+	}
+	for i, tree := range []*ConvTree{&a, &b} {
+		wg.Add(1)
+		go func(tree *ConvTree, seed int64) {
+			defer wg.Done()
+			for _, point := range uniformPoints(rand.New(rand.NewSource(seed)), iterations, 100) {
+				tree.Insert(point, true)
+			}
+		}(tree, int64(i+10))
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Minute):
+		t.Fatal("CompareTrees deadlocked")
+	}
+}
@@ -0,0 +1,232 @@
+package convtree
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// CellStats describes the points of a leaf. Statistics computed for an arbitrary region have an
+// empty CellID and a Depth of 0.
+type CellStats struct {
+	PointsNumber   int       `json:"points_number"`
+	CenterPoint    Point     `json:"center_point"`
+	AvgDistance    float64   `json:"avg_distance"`
+	StdDistance    float64   `json:"std_distance"`
+	MedianDistance float64   `json:"median_distance"`
+	BaselineTags   []string  `json:"baseline_tags"`
+	TotalWeight    int       `json:"total_weight"`
+	LastInsertAt   time.Time `json:"last_insert_at"`
+	ValueMin       float64   `json:"value_min"`
+	ValueMax       float64   `json:"value_max"`
+	ValueMean      float64   `json:"value_mean"`
+	ValueCount     int       `json:"value_count"`
+	Area           float64   `json:"area"`
+	Density        float64   `json:"density"`
+	CellID         string    `json:"cell_id"`
+	TopLeft        Point     `json:"top_left"`
+	BottomRight    Point     `json:"bottom_right"`
+	Depth          int       `json:"depth"`
+}
+
+// TopCells returns statistics of the n leaves with the largest total point weight, in descending
+// order of weight. Leaves with equal weight are ordered by ID.
+func (tree *ConvTree) TopCells(n int) []CellStats {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	return tree.topCells(n, func(a, b CellStats) bool {
+		return a.TotalWeight > b.TotalWeight
+	})
+}
+
+// TopCellsByDensity returns statistics of the n leaves with the largest weight per unit area,
+// in descending order of density. Leaves with equal density are ordered by ID.
+func (tree *ConvTree) TopCellsByDensity(n int) []CellStats {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	return tree.topCells(n, func(a, b CellStats) bool {
+		return a.Density > b.Density
+	})
+}
+
+// topCells sorts leaf statistics with greater and returns the first n of them. Leaves that are
+// not ordered by greater in either direction are ordered by ID.
+func (tree *ConvTree) topCells(n int, greater func(a, b CellStats) bool) []CellStats {
+	ids := []string{}
+	stats := []CellStats{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		ids = append(ids, leaf.ID)
+		stats = append(stats, leaf.cellStats())
+	})
+	order := make([]int, len(stats))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := stats[order[i]], stats[order[j]]
+		if greater(a, b) {
+			return true
+		}
+		if greater(b, a) {
+			return false
+		}
+		return ids[order[i]] < ids[order[j]]
+	})
+	if n < len(order) {
+		order = order[:n]
+	}
+	result := make([]CellStats, 0, len(order))
+	for _, i := range order {
+		result = append(result, stats[i])
+	}
+	return result
+}
+
+// Stats returns statistics of every leaf keyed by leaf ID.
+func (tree *ConvTree) Stats() map[string]CellStats {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := map[string]CellStats{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		result[leaf.ID] = leaf.cellStats()
+	})
+	return result
+}
+
+// RegionStats computes statistics of the points inside the rectangle as if they formed a single
+// cell. BaselineTags is the union of the baseline tags of the leaves intersecting the rectangle and
+// LastInsertAt is the latest insert into these leaves. The second return value is false, with
+// zero statistics, when no points lie inside the rectangle.
+func (tree *ConvTree) RegionStats(topLeft, bottomRight Point) (CellStats, bool) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	region := ConvTree{TopLeft: topLeft, BottomRight: bottomRight, Points: []Point{}, config: tree.config}
+	seen := map[string]bool{}
+	tree.walk(func(node *ConvTree, _ int) bool {
+		if !node.intersects(topLeft, bottomRight) {
+			return false
+		}
+		if !node.IsLeaf {
+			return true
+		}
+		for _, point := range node.Points {
+			if point.X >= topLeft.X && point.X <= bottomRight.X && point.Y >= bottomRight.Y && point.Y <= topLeft.Y {
+				region.Points = append(region.Points, point)
+			}
+		}
+		for _, tag := range node.BaselineTags {
+			if !seen[tag] {
+				seen[tag] = true
+				region.BaselineTags = append(region.BaselineTags, tag)
+			}
+		}
+		if node.LastInsertAt.After(region.LastInsertAt) {
+			region.LastInsertAt = node.LastInsertAt
+		}
+		return true
+	}, 0)
+	if len(region.Points) == 0 {
+		return CellStats{}, false
+	}
+	region.recomputeValues()
+	return region.cellStats(), true
+}
+
+// WeightQuantiles returns the total leaf weight at every requested quantile, interpolating
+// linearly between the sorted leaf weights. Quantiles must lie in [0, 1].
+func (tree *ConvTree) WeightQuantiles(qs []float64) ([]float64, error) {
+	for _, q := range qs {
+		if q < 0 || q > 1 || math.IsNaN(q) {
+			return nil, fmt.Errorf("quantile %v is outside [0, 1]", q)
+		}
+	}
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	weights := []float64{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		weights = append(weights, float64(leaf.totalWeight()))
+	})
+	sort.Float64s(weights)
+	result := make([]float64, len(qs))
+	for i, q := range qs {
+		position := q * float64(len(weights)-1)
+		lower := int(math.Floor(position))
+		upper := int(math.Ceil(position))
+		result[i] = weights[lower] + (weights[upper]-weights[lower])*(position-float64(lower))
+	}
+	return result, nil
+}
+
+func (tree *ConvTree) totalWeight() int {
+	total := 0
+	for _, point := range tree.Points {
+		total += point.Weight
+	}
+	return total
+}
+
+// cellStats computes statistics of the leaf points. CenterPoint is the weighted centroid of the
+// points or the geometric center of the leaf when it has no weight. Density is 0 for leaves
+// without area. Distance statistics describe the distances of the points to CenterPoint, with
+// StdDistance being the population standard deviation.
+func (tree *ConvTree) cellStats() CellStats {
+	stats := CellStats{
+		PointsNumber: len(tree.Points),
+		BaselineTags: append([]string{}, tree.BaselineTags...),
+		TotalWeight:  tree.totalWeight(),
+		LastInsertAt: tree.LastInsertAt,
+		ValueMin:     tree.Values.Min,
+		ValueMax:     tree.Values.Max,
+		ValueCount:   tree.Values.Count,
+		Area:         cellArea(tree.TopLeft, tree.BottomRight),
+		CellID:       tree.ID,
+		TopLeft:      tree.TopLeft,
+		BottomRight:  tree.BottomRight,
+		Depth:        tree.Depth,
+	}
+	if stats.Area > 0 {
+		stats.Density = float64(stats.TotalWeight) / stats.Area
+	}
+	if tree.Values.Count > 0 {
+		stats.ValueMean = tree.Values.Sum / float64(tree.Values.Count)
+	}
+	stats.CenterPoint = Point{
+		X: (tree.TopLeft.X + tree.BottomRight.X) / 2,
+		Y: (tree.TopLeft.Y + tree.BottomRight.Y) / 2,
+	}
+	if stats.TotalWeight <= 0 {
+		return stats
+	}
+	distance := euclidean
+	if tree.config != nil && tree.config.geodesic {
+		stats.CenterPoint = sphericalCentroid(tree.Points)
+		distance = haversine
+	} else {
+		x, y := 0.0, 0.0
+		for _, point := range tree.Points {
+			x += point.X * float64(point.Weight)
+			y += point.Y * float64(point.Weight)
+		}
+		stats.CenterPoint = Point{X: x / float64(stats.TotalWeight), Y: y / float64(stats.TotalWeight)}
+	}
+	distances := make([]float64, len(tree.Points))
+	total := 0.0
+	for i, point := range tree.Points {
+		distances[i] = distance(stats.CenterPoint, point)
+		total += distances[i]
+	}
+	stats.AvgDistance = total / float64(len(distances))
+	variance := 0.0
+	for _, distance := range distances {
+		variance += (distance - stats.AvgDistance) * (distance - stats.AvgDistance)
+	}
+	stats.StdDistance = math.Sqrt(variance / float64(len(distances)))
+	sort.Float64s(distances)
+	middle := len(distances) / 2
+	stats.MedianDistance = distances[middle]
+	if len(distances)%2 == 0 {
+		stats.MedianDistance = (distances[middle-1] + distances[middle]) / 2
+	}
+	return stats
+}
@@ -0,0 +1,139 @@
+package convtree
+
+import (
+	"math"
+	"testing"
+)
+
+func statsTree(t *testing.T, points []Point, opts ...Option) *ConvTree {
+	t.Helper()
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 1000, 4, 1, 8, nil, nil, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.divide(50, 50)
+	for _, point := range points {
+		if err := tree.Insert(point, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &tree
+}
+
+func TestStatsPerLeaf(t *testing.T) {
+	tree := statsTree(t, []Point{{X: 10, Y: 90, Weight: 1}, {X: 30, Y: 70, Weight: 3}, {X: 25, Y: 25, Weight: 2}})
+	stats := tree.Stats()
+	if len(stats) != 4 {
+		t.Fatalf("expected statistics of 4 leaves, got %d", len(stats))
+	}
+	want := []struct {
+		leaf           *ConvTree
+		points, weight int
+		center         Point
+	}{
+		{tree.ChildTopLeft, 2, 4, Point{X: 25, Y: 75}},
+		{tree.ChildTopRight, 0, 0, Point{X: 75, Y: 75}},
+		{tree.ChildBottomLeft, 1, 2, Point{X: 25, Y: 25}},
+		{tree.ChildBottomRight, 0, 0, Point{X: 75, Y: 25}},
+	}
+	for _, w := range want {
+		got, ok := stats[w.leaf.ID]
+		if !ok {
+			t.Fatalf("leaf %s is missing", w.leaf.ID)
+		}
+		if got.CellID != w.leaf.ID || got.PointsNumber != w.points || got.TotalWeight != w.weight ||
+			got.TopLeft != w.leaf.TopLeft || got.BottomRight != w.leaf.BottomRight || got.Depth != 1 {
+			t.Fatalf("leaf %s has statistics %+v", w.leaf.ID, got)
+		}
+		if got.CenterPoint.X != w.center.X || got.CenterPoint.Y != w.center.Y {
+			t.Fatalf("leaf %s has center %v instead of %v", w.leaf.ID, got.CenterPoint, w.center)
+		}
+		if got.Area != 2500 || got.Density != float64(w.weight)/2500 {
+			t.Fatalf("leaf %s has area %v and density %v", w.leaf.ID, got.Area, got.Density)
+		}
+	}
+	if _, ok := stats[tree.ID]; ok {
+		t.Fatal("statistics include the internal root")
+	}
+	if sub := tree.ChildTopLeft.Stats(); len(sub) != 1 || sub[tree.ChildTopLeft.ID].TotalWeight != 4 {
+		t.Fatalf("leaf statistics are %+v", sub)
+	}
+	if math.IsNaN(stats[tree.ChildTopRight.ID].AvgDistance) {
+		t.Fatal("empty leaf has a NaN average distance")
+	}
+}
+
+func TestDistanceDispersion(t *testing.T) {
+	// Three points around (20, 20) at distances 0, 5 and 5: the mean is 10/3, the population
+	// variance 50/9 and the median 5.
+	odd := statsTree(t, []Point{{X: 20, Y: 20, Weight: 1}, {X: 23, Y: 24, Weight: 1}, {X: 17, Y: 16, Weight: 1}})
+	got := odd.Stats()[odd.ChildBottomLeft.ID]
+	if math.Abs(got.AvgDistance-10.0/3) > 1e-12 || math.Abs(got.StdDistance-math.Sqrt(50.0/9)) > 1e-12 ||
+		got.MedianDistance != 5 {
+		t.Fatalf("expected 10/3, sqrt(50/9) and 5, got %v, %v and %v", got.AvgDistance, got.StdDistance,
+			got.MedianDistance)
+	}
+
+	// Four points at distances 5, 5, 10 and 10: the median is the mean of the middle two.
+	even := statsTree(t, []Point{{X: 23, Y: 24, Weight: 1}, {X: 17, Y: 16, Weight: 1}, {X: 26, Y: 28, Weight: 1},
+		{X: 14, Y: 12, Weight: 1}})
+	got = even.Stats()[even.ChildBottomLeft.ID]
+	if got.AvgDistance != 7.5 || got.StdDistance != 2.5 || got.MedianDistance != 7.5 {
+		t.Fatalf("expected 7.5, 2.5 and 7.5, got %v, %v and %v", got.AvgDistance, got.StdDistance, got.MedianDistance)
+	}
+
+	// Weights move the centroid to (21, 21) but every point counts once in the dispersion.
+	weighted := statsTree(t, []Point{{X: 20, Y: 20, Weight: 1}, {X: 22, Y: 22, Weight: 1}, {X: 21, Y: 21, Weight: 2}})
+	got = weighted.Stats()[weighted.ChildBottomLeft.ID]
+	if got.CenterPoint.X != 21 || got.CenterPoint.Y != 21 || math.Abs(got.AvgDistance-2*math.Sqrt2/3) > 1e-12 ||
+		math.Abs(got.MedianDistance-math.Sqrt2) > 1e-12 {
+		t.Fatalf("unexpected weighted statistics %+v", got)
+	}
+
+	single := statsTree(t, []Point{{X: 20, Y: 20, Weight: 1}})
+	got = single.Stats()[single.ChildBottomLeft.ID]
+	if got.AvgDistance != 0 || got.StdDistance != 0 || got.MedianDistance != 0 {
+		t.Fatalf("a single point has dispersion %v, %v and %v", got.AvgDistance, got.StdDistance, got.MedianDistance)
+	}
+}
+
+func TestGeodesicDistances(t *testing.T) {
+	newTree := func(points []Point) *ConvTree {
+		tree, err := NewConvTree(Point{X: -180, Y: 90}, Point{X: 180, Y: -90}, 0.1, 0.1, 1000, 4, 1, 8, nil, points,
+			WithGeodesicStats())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &tree
+	}
+	// Two points half a degree north and south of (10, 60) are centered on it, and half a degree of
+	// a meridian is pi R / 360 = 55597.5 m.
+	halfDegree := math.Pi * earthRadius / 360
+	tree := newTree([]Point{{X: 10, Y: 59.5, Weight: 1}, {X: 10, Y: 60.5, Weight: 1}})
+	got := tree.Stats()[tree.ID]
+	if math.Abs(got.CenterPoint.X-10) > 1e-9 || math.Abs(got.CenterPoint.Y-60) > 1e-9 {
+		t.Fatalf("expected the centroid at (10, 60), got %v", got.CenterPoint)
+	}
+	if math.Abs(got.AvgDistance-halfDegree) > 1e-6 || math.Abs(halfDegree-55597.5) > 0.1 || got.StdDistance > 1e-6 {
+		t.Fatalf("expected an average distance of %v m, got %v with deviation %v", halfDegree, got.AvgDistance,
+			got.StdDistance)
+	}
+
+	// On the equator a degree of longitude is as long as a degree of latitude, so the weighted points
+	// a degree away from (0, 0) are all at the same distance from it.
+	tree = newTree([]Point{{X: -1, Y: 0, Weight: 1}, {X: 1, Y: 0, Weight: 1}, {X: 0, Y: 1, Weight: 2},
+		{X: 0, Y: -1, Weight: 2}})
+	got = tree.Stats()[tree.ID]
+	if math.Abs(got.CenterPoint.X) > 1e-9 || math.Abs(got.CenterPoint.Y) > 1e-9 ||
+		math.Abs(got.AvgDistance-2*halfDegree) > 1e-6 || math.Abs(got.MedianDistance-2*halfDegree) > 1e-6 {
+		t.Fatalf("expected all points %v m from (0, 0), got %+v", 2*halfDegree, got)
+	}
+	tree = newTree([]Point{{X: 9, Y: 60, Weight: 1}, {X: 11, Y: 60, Weight: 1}})
+	got = tree.Stats()[tree.ID]
+	// The centroid lies on the great circle through both points, north of latitude 60, and half of
+	// that arc is R asin(cos 60° sin 1°).
+	expected := earthRadius * math.Asin(math.Cos(math.Pi/3)*math.Sin(math.Pi/180))
+	if got.CenterPoint.Y <= 60 || math.Abs(got.AvgDistance-expected) > 1e-6 {
+		t.Fatalf("expected both points %v m from the centroid, got %+v", expected, got)
+	}
+}
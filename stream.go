@@ -0,0 +1,207 @@
+package convtree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var childKeys = [4]string{"child_top_left", "child_top_right", "child_bottom_left", "child_bottom_right"}
+
+// EncodeJSON writes the document of MarshalJSON to w node by node and point by point, so only
+// the encoded fields of a single node are held in memory at a time.
+func (tree *ConvTree) EncodeJSON(w io.Writer) error {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	header, err := json.Marshal(tree.documentHeader())
+	if err != nil {
+		return err
+	}
+	// The root is the last field of the document and is encoded as null in the header.
+	header = bytes.TrimSuffix(header, []byte("null}"))
+	writer := bufio.NewWriter(w)
+	writer.Write(header)
+	if err := tree.encodeJSON(writer); err != nil {
+		return err
+	}
+	writer.WriteByte('}')
+	return writer.Flush()
+}
+
+// encodeJSON writes the node to the writer. Write errors are kept by the writer and reported by Flush.
+func (tree *ConvTree) encodeJSON(writer *bufio.Writer) error {
+	fields, err := json.Marshal(tree.toFields())
+	if err != nil {
+		return err
+	}
+	writer.Write(fields[:len(fields)-1])
+	if tree.IsLeaf {
+		if len(tree.Points) > 0 {
+			writer.WriteString(`,"points":[`)
+			for i, point := range tree.Points {
+				data, err := json.Marshal(tree.pointRecord(point))
+				if err != nil {
+					return err
+				}
+				if i > 0 {
+					writer.WriteByte(',')
+				}
+				writer.Write(data)
+			}
+			writer.WriteByte(']')
+		}
+		writer.WriteByte('}')
+		return nil
+	}
+	for i, child := range []*ConvTree{tree.ChildTopLeft, tree.ChildTopRight, tree.ChildBottomLeft, tree.ChildBottomRight} {
+		fmt.Fprintf(writer, `,"%s":`, childKeys[i])
+		if err := child.encodeJSON(writer); err != nil {
+			return err
+		}
+	}
+	writer.WriteByte('}')
+	return nil
+}
+
+// DecodeConvTree reads a tree written by EncodeJSON or MarshalJSON from r without buffering the
+// whole document. The options are applied as in UnmarshalConvTree.
+func DecodeConvTree(r io.Reader, opts ...Option) (*ConvTree, error) {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+	config := newTreeConfig(opts)
+	doc := treeDocument{}
+	var root *ConvTree
+	for decoder.More() {
+		key, err := decodeKey(decoder)
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "root":
+			root, err = decodeNode(decoder, config)
+		case "version":
+			err = decoder.Decode(&doc.Version)
+		case "schedule":
+			err = decoder.Decode(&doc.Schedule)
+		case "frozen":
+			err = decoder.Decode(&doc.Frozen)
+		case "samples":
+			err = decoder.Decode(&doc.Samples)
+		case "pins":
+			err = decoder.Decode(&doc.Pins)
+		case "params":
+			err = decoder.Decode(&doc.Params)
+		default:
+			err = decoder.Decode(&json.RawMessage{})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, err
+	}
+	if root == nil {
+		return nil, errors.New("encoded tree has no root")
+	}
+	if err := doc.restore(root, config); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func decodeNode(decoder *json.Decoder, config *treeConfig) (*ConvTree, error) {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	var points []Point
+	var children [4]*ConvTree
+	for decoder.More() {
+		key, err := decodeKey(decoder)
+		if err != nil {
+			return nil, err
+		}
+		child := -1
+		for i, childKey := range childKeys {
+			if key == childKey {
+				child = i
+			}
+		}
+		switch {
+		case child >= 0:
+			children[child], err = decodeNode(decoder, config)
+		case key == "points":
+			points, err = decodePoints(decoder)
+		default:
+			var raw json.RawMessage
+			err = decoder.Decode(&raw)
+			fields[key] = raw
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	record := nodeRecord{}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	tree, err := record.toNode(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := tree.setDecoded(points, children); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+func decodePoints(decoder *json.Decoder) ([]Point, error) {
+	if err := expectDelim(decoder, '['); err != nil {
+		return nil, err
+	}
+	points := []Point{}
+	for decoder.More() {
+		record := pointRecord{}
+		if err := decoder.Decode(&record); err != nil {
+			return nil, err
+		}
+		points = append(points, record.point())
+	}
+	return points, expectDelim(decoder, ']')
+}
+
+func decodeKey(decoder *json.Decoder) (string, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("expected object key, got %v", token)
+	}
+	return key, nil
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package convtree
+
+import "sync"
+
+// StructureChange lists the nodes affected by splits performed during a single operation.
+// RemovedLeaves are leaves that existed before the operation and became internal nodes, and
+// RepointedParents are the same nodes, which keep their IDs but now point to new children.
+// AddedLeaves are leaves created by the operation. Nodes created and split again within the
+// operation appear in neither list.
+type StructureChange struct {
+	RemovedLeaves    []string
+	AddedLeaves      []string
+	RepointedParents []string
+}
+
+type structureRecorder struct {
+	mu      sync.Mutex
+	created map[*ConvTree]bool
+	added   []*ConvTree
+	change  StructureChange
+}
+
+// recordChanges makes the following splits be recorded until finishChanges is called.
+// It must be called with the write lock held.
+func (config *treeConfig) recordChanges() {
+	if config != nil {
+		config.changes = &structureRecorder{created: map[*ConvTree]bool{}}
+	}
+}
+
+func (config *treeConfig) finishChanges() StructureChange {
+	if config == nil || config.changes == nil {
+		return StructureChange{}
+	}
+	recorder := config.changes
+	config.changes = nil
+	for _, node := range recorder.added {
+		if node.IsLeaf {
+			recorder.change.AddedLeaves = append(recorder.change.AddedLeaves, node.ID)
+		}
+	}
+	return recorder.change
+}
+
+func (config *treeConfig) recordDivide(node *ConvTree, children [4]*ConvTree) {
+	if config == nil || config.changes == nil {
+		return
+	}
+	recorder := config.changes
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if !recorder.created[node] {
+		recorder.change.RemovedLeaves = append(recorder.change.RemovedLeaves, node.ID)
+		recorder.change.RepointedParents = append(recorder.change.RepointedParents, node.ID)
+	}
+	for _, child := range children {
+		recorder.created[child] = true
+		recorder.added = append(recorder.added, child)
+	}
+}
@@ -0,0 +1,93 @@
+package convtree
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func leafIDs(tree *ConvTree) map[string]bool {
+	result := map[string]bool{}
+	for _, leaf := range tree.Leaves() {
+		result[leaf.ID] = true
+	}
+	return result
+}
+
+// checkStructureChange fails the test unless the change lists exactly the leaves that disappeared
+// and appeared between the two sets of leaf IDs.
+func checkStructureChange(t *testing.T, change StructureChange, before, after map[string]bool) {
+	t.Helper()
+	removed, added := []string{}, []string{}
+	for id := range before {
+		if !after[id] {
+			removed = append(removed, id)
+		}
+	}
+	for id := range after {
+		if !before[id] {
+			added = append(added, id)
+		}
+	}
+	if len(removed) == 0 || len(added) == 0 {
+		t.Fatal("the operation did not split any leaf")
+	}
+	for _, ids := range [][]string{removed, added, change.RemovedLeaves, change.AddedLeaves, change.RepointedParents} {
+		sort.Strings(ids)
+	}
+	if !reflect.DeepEqual(change.RemovedLeaves, removed) || !reflect.DeepEqual(change.RepointedParents, removed) {
+		t.Fatalf("expected removed leaves and repointed parents %v, got %v and %v", removed,
+			change.RemovedLeaves, change.RepointedParents)
+	}
+	if !reflect.DeepEqual(change.AddedLeaves, added) {
+		t.Fatalf("expected added leaves %v, got %v", added, change.AddedLeaves)
+	}
+}
+
+func TestInsertBatchStructureChange(t *testing.T) {
+	r := rand.New(rand.NewSource(31))
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 8, 1, 8, nil,
+		uniformPoints(r, 400, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := leafIDs(&tree)
+	// Crowd a small area, so that some leaves are split more than once within the batch.
+	points := uniformPoints(r, 300, 10)
+	change, err := tree.InsertBatch(points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStructureChange(t, change, before, leafIDs(&tree))
+	if change, err := tree.InsertBatch(uniformPoints(r, 1, 100)); err != nil || len(change.RemovedLeaves) != 0 ||
+		len(change.AddedLeaves) != 0 || len(change.RepointedParents) != 0 {
+		t.Fatalf("a batch without splits returned %+v, %v", change, err)
+	}
+}
+
+func TestCheckStructureChange(t *testing.T) {
+	r := rand.New(rand.NewSource(32))
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 8, 1, 8, nil,
+		uniformPoints(r, 40, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change := tree.Check(); len(change.RemovedLeaves) != 0 || len(change.AddedLeaves) != 0 {
+		t.Fatalf("checking a leaf below its capacity returned %+v", change)
+	}
+	for _, point := range uniformPoints(r, 200, 100) {
+		if err := tree.Insert(point, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := leafIDs(&tree)
+	change := tree.Check()
+	checkStructureChange(t, change, before, leafIDs(&tree))
+	if len(change.RemovedLeaves) != 1 || change.RemovedLeaves[0] != tree.ID {
+		t.Fatalf("expected only the root to be removed, got %v", change.RemovedLeaves)
+	}
+	if change := tree.Check(); len(change.RemovedLeaves) != 0 || len(change.AddedLeaves) != 0 {
+		t.Fatalf("checking an internal node returned %+v", change)
+	}
+}
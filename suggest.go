@@ -0,0 +1,122 @@
+package convtree
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+const suggestSampleSize = 10000
+
+// WithTargetLeaves makes NewConvTree choose MaxPoints so that the tree built from the initial
+// points has approximately n leaves. The maxPoints argument of the constructor is ignored.
+func WithTargetLeaves(n int) Option {
+	return func(config *treeConfig) {
+		config.targetLeaves = n
+	}
+}
+
+// SuggestMaxPoints estimates the MaxPoints value that produces approximately targetLeaves leaves
+// for the points. The estimate is found by a binary search over trees built from a sample of
+// the points within their bounding box, using a grid of 16 cells, two convolutions with the
+// default kernel and the maximum depth of 16.
+func SuggestMaxPoints(points []Point, targetLeaves int, cfg ...Option) (int, error) {
+	if targetLeaves < 1 {
+		return 0, errors.New("target number of leaves must be positive")
+	}
+	if len(points) == 0 {
+		return 0, errors.New("no points to estimate from")
+	}
+	topLeft := Point{X: math.Inf(1), Y: math.Inf(-1)}
+	bottomRight := Point{X: math.Inf(-1), Y: math.Inf(1)}
+	for _, point := range points {
+		topLeft.X = math.Min(topLeft.X, point.X)
+		topLeft.Y = math.Max(topLeft.Y, point.Y)
+		bottomRight.X = math.Max(bottomRight.X, point.X)
+		bottomRight.Y = math.Min(bottomRight.Y, point.Y)
+	}
+	if topLeft.X == bottomRight.X {
+		bottomRight.X = topLeft.X + 1
+	}
+	if topLeft.Y == bottomRight.Y {
+		bottomRight.Y = topLeft.Y - 1
+	}
+	return suggestMaxPoints(points, targetLeaves, topLeft, bottomRight, Config{
+		MaxDepth: 16,
+		ConvNum:  2,
+		GridSize: 16,
+		Kernel:   defaultKernel(),
+	}, cfg), nil
+}
+
+func suggestMaxPoints(points []Point, targetLeaves int, topLeft, bottomRight Point, params Config, opts []Option) int {
+	sample := points
+	if len(points) > suggestSampleSize {
+		// A random sample with a fixed seed keeps the estimate deterministic without aliasing with
+		// periodic patterns in the order of the points, which a sample taken with a fixed stride does.
+		sample = make([]Point, 0, suggestSampleSize)
+		r := rand.New(rand.NewSource(1))
+		for _, index := range r.Perm(len(points))[:suggestSampleSize] {
+			sample = append(sample, points[index])
+		}
+	}
+	totalWeight, sampleWeight := 0, 0
+	for _, point := range points {
+		totalWeight += point.Weight
+	}
+	for _, point := range sample {
+		sampleWeight += point.Weight
+	}
+	if sampleWeight < 1 {
+		return 1
+	}
+	leaves := func(maxPoints int) int {
+		config := newTreeConfig(opts)
+		config.targetLeaves = 0
+		config.maxLeaves = 0
+		config.idGenerator = func() string {
+			return ""
+		}
+		config.params = params
+		config.useKernel(params.Kernel)
+		config.params.MaxPoints = maxPoints
+		tree := &ConvTree{
+			IsLeaf:      true,
+			TopLeft:     topLeft,
+			BottomRight: bottomRight,
+			Points:      append([]Point{}, sample...),
+			config:      config,
+		}
+		if tree.checkSplit() {
+			tree.split()
+		}
+		count := 0
+		tree.walkLeaves(func(leaf *ConvTree) {
+			count++
+		})
+		return count
+	}
+	low, high := 1, sampleWeight
+	best, bestDiff := high, math.MaxInt32
+	for low <= high {
+		middle := (low + high) / 2
+		count := leaves(middle)
+		diff := count - targetLeaves
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff < bestDiff {
+			best, bestDiff = middle, diff
+		}
+		if count > targetLeaves {
+			low = middle + 1
+		} else {
+			high = middle - 1
+		}
+	}
+	scaled := int(math.Round(float64(best) * float64(totalWeight) / float64(sampleWeight)))
+	if scaled < 1 {
+		return 1
+	}
+	return scaled
+}
@@ -0,0 +1,49 @@
+package convtree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSuggestMaxPointsHitsTarget(t *testing.T) {
+	r := rand.New(rand.NewSource(22))
+	clustered := make([]Point, 0, 10000)
+	centers := []Point{{X: 20, Y: 25}, {X: 75, Y: 70}, {X: 30, Y: 80}, {X: 65, Y: 20}}
+	for i := 0; i < cap(clustered); i++ {
+		center := centers[i%len(centers)]
+		clustered = append(clustered, Point{
+			X:      math.Max(0, math.Min(100, center.X+r.NormFloat64()*6)),
+			Y:      math.Max(0, math.Min(100, center.Y+r.NormFloat64()*6)),
+			Weight: 1,
+		})
+	}
+	datasets := map[string][]Point{
+		"uniform":   uniformPoints(r, 10000, 100),
+		"clustered": clustered,
+	}
+	topLeft, bottomRight := Point{X: 0, Y: 100}, Point{X: 100, Y: 0}
+	for name, points := range datasets {
+		for _, target := range []int{100, 300} {
+			maxPoints, err := SuggestMaxPoints(points, target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			suggested, err := NewConvTree(topLeft, bottomRight, 0.01, 0.01, maxPoints, 16, 2, 16, nil, points)
+			if err != nil {
+				t.Fatal(err)
+			}
+			built, err := NewConvTree(topLeft, bottomRight, 0.01, 0.01, 0, 16, 2, 16, nil, points,
+				WithTargetLeaves(target))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for kind, tree := range map[string]*ConvTree{"SuggestMaxPoints": &suggested, "WithTargetLeaves": &built} {
+				leaves := tree.Summary().Leaves
+				if math.Abs(float64(leaves-target)) > 0.25*float64(target) {
+					t.Errorf("%s data with %s: %d leaves for a target of %d", name, kind, leaves, target)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package convtree
+
+import "sync/atomic"
+
+// TreeStats summarizes the structure and contents of a tree.
+type TreeStats struct {
+	Points            int
+	TotalWeight       int
+	Leaves            int
+	InternalNodes     int
+	MaxDepth          int
+	MinLeafPoints     int
+	AvgLeafPoints     float64
+	MaxLeafPoints     int
+	EmptyLeafFraction float64
+	Inserts           int64
+	Removes           int64
+	Boundary          BoundaryStats
+	// TotalLeaves is the number of leaves of the whole tree, which differs from Leaves when the
+	// method is not called on the root. LeafCapHit reports whether a split was skipped because
+	// of WithMaxLeaves.
+	TotalLeaves int
+	LeafCapHit  bool
+}
+
+// Summary computes tree statistics in a single traversal. Depth is counted from the node the
+// method is called on.
+func (tree *ConvTree) Summary() TreeStats {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	stats := TreeStats{Boundary: BoundaryStats{Leaves: []BoundaryLeaf{}}}
+	emptyLeaves := 0
+	tree.walk(func(node *ConvTree, depth int) bool {
+		stats.Inserts += node.InsertCount
+		stats.Removes += node.RemoveCount
+		if !node.IsLeaf {
+			stats.InternalNodes++
+			return true
+		}
+		points, weight := len(node.Points), node.totalWeight()
+		if stats.Leaves == 0 || points < stats.MinLeafPoints {
+			stats.MinLeafPoints = points
+		}
+		if points > stats.MaxLeafPoints {
+			stats.MaxLeafPoints = points
+		}
+		if points == 0 {
+			emptyLeaves++
+		}
+		if depth > stats.MaxDepth {
+			stats.MaxDepth = depth
+		}
+		stats.Leaves++
+		stats.Points += points
+		stats.TotalWeight += weight
+		edges := tree.boundaryEdges(node)
+		if edges.Top || edges.Right || edges.Bottom || edges.Left {
+			edges.ID = node.ID
+			edges.Weight = weight
+			stats.Boundary.Leaves = append(stats.Boundary.Leaves, edges)
+			stats.Boundary.Weight += weight
+		}
+		return true
+	}, 0)
+	stats.AvgLeafPoints = float64(stats.Points) / float64(stats.Leaves)
+	stats.EmptyLeafFraction = float64(emptyLeaves) / float64(stats.Leaves)
+	stats.Boundary.TotalWeight = stats.TotalWeight
+	if tree.config != nil {
+		stats.TotalLeaves = int(atomic.LoadInt64(&tree.config.leaves))
+		stats.LeafCapHit = atomic.LoadInt64(tree.config.diagnostics[DiagLeafCapSkipped]) > 0
+	} else {
+		stats.TotalLeaves = stats.Leaves
+	}
+	if stats.TotalWeight > 0 {
+		stats.Boundary.WeightFraction = float64(stats.Boundary.Weight) / float64(stats.TotalWeight)
+	}
+	return stats
+}
@@ -0,0 +1,54 @@
+package convtree
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RenderSVG draws the leaves of the node scaled to width x height pixels. Leaves are filled with
+// an opacity proportional to their weight relative to the heaviest leaf, and points are drawn as
+// small circles when drawPoints is set. The Y axis points up, so the top edge of the node is the
+// top of the image.
+func (tree *ConvTree) RenderSVG(w io.Writer, width, height int, drawPoints bool) error {
+	if width < 1 || height < 1 {
+		return errors.New("image width and height must be positive")
+	}
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	scaleX := float64(width) / (tree.BottomRight.X - tree.TopLeft.X)
+	scaleY := float64(height) / (tree.TopLeft.Y - tree.BottomRight.Y)
+	project := func(point Point) (float64, float64) {
+		return (point.X - tree.TopLeft.X) * scaleX, (tree.TopLeft.Y - point.Y) * scaleY
+	}
+	maxWeight := 0
+	tree.walkLeaves(func(leaf *ConvTree) {
+		if weight := leaf.totalWeight(); weight > maxWeight {
+			maxWeight = weight
+		}
+	})
+	writer := bufio.NewWriter(w)
+	fmt.Fprintf(writer, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width, height, width, height)
+	tree.walkLeaves(func(leaf *ConvTree) {
+		opacity := 0.0
+		if maxWeight > 0 {
+			opacity = float64(leaf.totalWeight()) / float64(maxWeight)
+		}
+		left, top := project(leaf.TopLeft)
+		right, bottom := project(leaf.BottomRight)
+		fmt.Fprintf(writer, `<rect x="%.3f" y="%.3f" width="%.3f" height="%.3f" fill="steelblue" fill-opacity="%.3f" stroke="black" stroke-width="0.5"/>`+"\n",
+			left, top, right-left, bottom-top, opacity)
+	})
+	if drawPoints {
+		tree.walkLeaves(func(leaf *ConvTree) {
+			for _, point := range leaf.Points {
+				x, y := project(point)
+				fmt.Fprintf(writer, `<circle cx="%.3f" cy="%.3f" r="1" fill="red"/>`+"\n", x, y)
+			}
+		})
+	}
+	fmt.Fprintln(writer, "</svg>")
+	return writer.Flush()
+}
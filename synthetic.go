@@ -0,0 +1,50 @@
+package convtree
+
+import (
+	"math"
+	"math/rand"
+)
+
+type SyntheticConfig struct {
+	Count    int
+	Clusters int
+	Spread   float64
+	Seed     int64
+}
+
+// GeneratePoints returns Count points of weight 1 inside the bounds. Without clusters the points
+// are distributed uniformly; otherwise they are normally distributed around Clusters random centers
+// with the standard deviation equal to Spread times the extent of the bounds. The same seed
+// always produces the same points.
+func GeneratePoints(bounds Bounds, cfg SyntheticConfig) []Point {
+	random := rand.New(rand.NewSource(cfg.Seed))
+	width := bounds.BottomRight.X - bounds.TopLeft.X
+	height := bounds.TopLeft.Y - bounds.BottomRight.Y
+	centers := make([]Point, cfg.Clusters)
+	for i := range centers {
+		centers[i] = Point{
+			X: bounds.TopLeft.X + random.Float64()*width,
+			Y: bounds.BottomRight.Y + random.Float64()*height,
+		}
+	}
+	spread := cfg.Spread
+	if spread <= 0 {
+		spread = 0.05
+	}
+	points := make([]Point, cfg.Count)
+	for i := range points {
+		point := Point{Weight: 1}
+		if len(centers) == 0 {
+			point.X = bounds.TopLeft.X + random.Float64()*width
+			point.Y = bounds.BottomRight.Y + random.Float64()*height
+		} else {
+			center := centers[random.Intn(len(centers))]
+			point.X = center.X + random.NormFloat64()*spread*width
+			point.Y = center.Y + random.NormFloat64()*spread*height
+		}
+		point.X = math.Max(bounds.TopLeft.X, math.Min(bounds.BottomRight.X, point.X))
+		point.Y = math.Max(bounds.BottomRight.Y, math.Min(bounds.TopLeft.Y, point.Y))
+		points[i] = point
+	}
+	return points
+}
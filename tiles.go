@@ -0,0 +1,104 @@
+package convtree
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// LonLatToTile returns the Web Mercator tile containing the coordinate at the given zoom.
+// Latitudes beyond the Web Mercator limits are clamped to the first or last tile row.
+func LonLatToTile(lon, lat float64, zoom int) (x, y int) {
+	n := math.Exp2(float64(zoom))
+	latRad := lat * math.Pi / 180
+	x = int(math.Floor((lon + 180) / 360 * n))
+	y = int(math.Floor((1 - math.Log(math.Tan(latRad)+1/math.Cos(latRad))/math.Pi) / 2 * n))
+	if lat >= 90 {
+		y = 0
+	}
+	if lat <= -90 {
+		y = int(n) - 1
+	}
+	return clampTile(x, int(n)), clampTile(y, int(n))
+}
+
+// TileBounds returns the longitude/latitude rectangle covered by the tile.
+func TileBounds(z, x, y int) (topLeft, bottomRight Point) {
+	n := math.Exp2(float64(z))
+	lon := func(x int) float64 {
+		return float64(x)/n*360 - 180
+	}
+	lat := func(y int) float64 {
+		return math.Atan(math.Sinh(math.Pi*(1-2*float64(y)/n))) * 180 / math.Pi
+	}
+	return Point{X: lon(x), Y: lat(y)}, Point{X: lon(x + 1), Y: lat(y + 1)}
+}
+
+func clampTile(index, n int) int {
+	if index < 0 {
+		return 0
+	}
+	if index >= n {
+		return n - 1
+	}
+	return index
+}
+
+type tileKey struct {
+	x int
+	y int
+}
+
+// ExportTiles calls fn for every Web Mercator tile at the given zoom intersected by a leaf, with X
+// as longitude and Y as latitude. The cells passed to fn are the intersecting leaves clipped to the
+// tile: their bounds are the intersection with the tile and their statistics cover only the points
+// of the leaf inside the tile. Tiles are visited in order of x and then y, and an error returned by
+// fn stops the export. fn is called after the tree is unlocked, so it may use the tree.
+func (tree *ConvTree) ExportTiles(zoom int, fn func(z, x, y int, cells []CellStats) error) error {
+	if zoom < 0 || zoom > 30 {
+		return errors.New("zoom must be between 0 and 30")
+	}
+	tree.config.rLock()
+	tiles := map[tileKey][]CellStats{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		minX, minY := LonLatToTile(leaf.TopLeft.X, leaf.TopLeft.Y, zoom)
+		maxX, maxY := LonLatToTile(leaf.BottomRight.X, leaf.BottomRight.Y, zoom)
+		for x := minX; x <= maxX; x++ {
+			for y := minY; y <= maxY; y++ {
+				tileTopLeft, tileBottomRight := TileBounds(zoom, x, y)
+				clipped := *leaf
+				clipped.TopLeft = Point{X: math.Max(leaf.TopLeft.X, tileTopLeft.X), Y: math.Min(leaf.TopLeft.Y, tileTopLeft.Y)}
+				clipped.BottomRight = Point{X: math.Min(leaf.BottomRight.X, tileBottomRight.X), Y: math.Max(leaf.BottomRight.Y, tileBottomRight.Y)}
+				if clipped.TopLeft.X >= clipped.BottomRight.X || clipped.TopLeft.Y <= clipped.BottomRight.Y {
+					continue
+				}
+				clipped.Points = []Point{}
+				for _, point := range leaf.Points {
+					if pointX, pointY := LonLatToTile(point.X, point.Y, zoom); pointX == x && pointY == y {
+						clipped.Points = append(clipped.Points, point)
+					}
+				}
+				clipped.recomputeValues()
+				key := tileKey{x: x, y: y}
+				tiles[key] = append(tiles[key], clipped.cellStats())
+			}
+		}
+	})
+	tree.config.rUnlock()
+	keys := make([]tileKey, 0, len(tiles))
+	for key := range tiles {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].x != keys[j].x {
+			return keys[i].x < keys[j].x
+		}
+		return keys[i].y < keys[j].y
+	})
+	for _, key := range keys {
+		if err := fn(zoom, key.x, key.y, tiles[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
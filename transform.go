@@ -0,0 +1,44 @@
+package convtree
+
+import "math"
+
+type WeightTransform struct {
+	Name  string
+	Apply func(weight float64) float64
+}
+
+var (
+	Log1pTransform = WeightTransform{Name: "log1p", Apply: math.Log1p}
+	SqrtTransform  = WeightTransform{Name: "sqrt", Apply: math.Sqrt}
+)
+
+func CustomTransform(name string, fn func(weight float64) float64) WeightTransform {
+	return WeightTransform{Name: name, Apply: fn}
+}
+
+// SplitTrace describes how the split position of a node was chosen.
+type SplitTrace struct {
+	Transform      string
+	Refined        bool
+	ConvIterations int
+	GridX          int
+	GridY          int
+	X              float64
+	Y              float64
+}
+
+func (config *treeConfig) transformGrid(values *grid) {
+	if config == nil || config.transform.Apply == nil {
+		return
+	}
+	for i, value := range values.data {
+		values.data[i] = config.transform.Apply(value)
+	}
+}
+
+func (config *treeConfig) transformName() string {
+	if config == nil || config.transform.Apply == nil {
+		return "none"
+	}
+	return config.transform.Name
+}
@@ -0,0 +1,50 @@
+package convtree
+
+import "testing"
+
+// TestLogTransformMovesSplit builds a node with a 2x2 block of heavy cells and a 4x4 block of
+// light cells. On raw weights the convolved heavy block dominates and the split is placed next to
+// it. log1p compresses the heavy cells from 1000 to 6.9 and the light ones from 50 to 3.9, so the
+// larger block dominates after convolution and the split moves next to it.
+func TestLogTransformMovesSplit(t *testing.T) {
+	points := []Point{}
+	for x := 15.0; x < 30; x += 10 {
+		for y := 15.0; y < 30; y += 10 {
+			points = append(points, Point{X: x, Y: y, Weight: 1000})
+		}
+	}
+	for x := 45.0; x < 80; x += 10 {
+		for y := 45.0; y < 80; y += 10 {
+			points = append(points, Point{X: x, Y: y, Weight: 50})
+		}
+	}
+	cases := []struct {
+		name      string
+		opts      []Option
+		transform string
+		grid      int
+	}{
+		{name: "identity", transform: "none", grid: 3},
+		{name: "log1p", opts: []Option{WithWeightTransform(Log1pTransform)}, transform: "log1p", grid: 7},
+	}
+	for _, c := range cases {
+		tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 5, 1, 1, 10, nil, points, c.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tree.IsLeaf || tree.Trace == nil {
+			t.Fatalf("%s: tree was not split", c.name)
+		}
+		trace := tree.Trace
+		if trace.Transform != c.transform || trace.GridX != c.grid || trace.GridY != c.grid {
+			t.Fatalf("%s: expected transform %s and split cell (%d, %d), got %s and (%d, %d)", c.name,
+				c.transform, c.grid, c.grid, trace.Transform, trace.GridX, trace.GridY)
+		}
+		if want := float64(10 * c.grid); trace.X != want || trace.Y != want {
+			t.Fatalf("%s: expected split at (%v, %v), got (%v, %v)", c.name, want, want, trace.X, trace.Y)
+		}
+		if got := tree.Summary().TotalWeight; got != 4*1000+16*50 {
+			t.Fatalf("%s: transform changed the stored weights to %d", c.name, got)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package convtree
+
+// Leaves returns all leaves of the tree in depth-first order: top left, top right, bottom left, bottom right.
+func (tree *ConvTree) Leaves() []*ConvTree {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := []*ConvTree{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		result = append(result, leaf)
+	})
+	return result
+}
+
+// WalkLeaves calls fn for every leaf in the same order as Leaves until fn returns false.
+// The tree is read-locked during the walk, so fn must not modify it.
+func (tree *ConvTree) WalkLeaves(fn func(leaf *ConvTree) bool) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	tree.walkNodes(func(node *ConvTree) bool {
+		if node.IsLeaf {
+			return fn(node)
+		}
+		return true
+	})
+}
+
+func (tree *ConvTree) walkLeaves(fn func(leaf *ConvTree)) {
+	if tree.IsLeaf {
+		fn(tree)
+		return
+	}
+	tree.ChildTopLeft.walkLeaves(fn)
+	tree.ChildTopRight.walkLeaves(fn)
+	tree.ChildBottomLeft.walkLeaves(fn)
+	tree.ChildBottomRight.walkLeaves(fn)
+}
+
+func (tree *ConvTree) walkNodes(fn func(node *ConvTree) bool) bool {
+	if !fn(tree) {
+		return false
+	}
+	if tree.IsLeaf {
+		return true
+	}
+	return tree.ChildTopLeft.walkNodes(fn) && tree.ChildTopRight.walkNodes(fn) &&
+		tree.ChildBottomLeft.walkNodes(fn) && tree.ChildBottomRight.walkNodes(fn)
+}
+
+// Walk visits the node and its descendants in pre-order. depth is counted from the node Walk is
+// called on. When fn returns false, the children of the current node are skipped.
+func (tree *ConvTree) Walk(fn func(node *ConvTree, depth int) bool) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	tree.walk(fn, 0)
+}
+
+func (tree *ConvTree) walk(fn func(node *ConvTree, depth int) bool, depth int) {
+	if !fn(tree, depth) || tree.IsLeaf {
+		return
+	}
+	tree.ChildTopLeft.walk(fn, depth+1)
+	tree.ChildTopRight.walk(fn, depth+1)
+	tree.ChildBottomLeft.walk(fn, depth+1)
+	tree.ChildBottomRight.walk(fn, depth+1)
+}
+
+// Walk visits the node and its descendants in pre-order. depth is counted from the node Walk is
+// called on. When fn returns false, the children of the current node are skipped.
+func (tree *QuadTree) Walk(fn func(node *QuadTree, depth int) bool) {
+	tree.walk(fn, 0)
+}
+
+func (tree *QuadTree) walk(fn func(node *QuadTree, depth int) bool, depth int) {
+	if !fn(tree, depth) || tree.IsLeaf {
+		return
+	}
+	tree.ChildTopLeft.walk(fn, depth+1)
+	tree.ChildTopRight.walk(fn, depth+1)
+	tree.ChildBottomLeft.walk(fn, depth+1)
+	tree.ChildBottomRight.walk(fn, depth+1)
+}
+
+// splitLeaves splits every leaf that exceeds the split threshold.
+func (tree *ConvTree) splitLeaves() {
+	leaves := []*ConvTree{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		leaves = append(leaves, leaf)
+	})
+	for _, leaf := range leaves {
+		if leaf.checkSplit() {
+			leaf.split()
+		}
+	}
+}
+
+// LevelOrder returns the nodes grouped by depth, with the node the method is called on at index 0.
+func (tree *ConvTree) LevelOrder() [][]*ConvTree {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := [][]*ConvTree{}
+	level := []*ConvTree{tree}
+	for len(level) > 0 {
+		result = append(result, level)
+		next := []*ConvTree{}
+		for _, node := range level {
+			if !node.IsLeaf {
+				next = append(next, node.ChildTopLeft, node.ChildTopRight, node.ChildBottomLeft, node.ChildBottomRight)
+			}
+		}
+		level = next
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+package convtree
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLeavesTileRoot(t *testing.T) {
+	r := rand.New(rand.NewSource(12))
+	points := uniformPoints(r, 1000, 100)
+	for i := 0; i < 1000; i++ {
+		points = append(points, Point{
+			X:      math.Max(0, math.Min(100, 30+r.NormFloat64()*5)),
+			Y:      math.Max(0, math.Min(100, 70+r.NormFloat64()*5)),
+			Weight: 1,
+		})
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 0.5, 0.5, 40, 8, 2, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := tree.Leaves()
+	if len(leaves) < 20 {
+		t.Fatalf("expected a deep tree, got %d leaves", len(leaves))
+	}
+	checkTiling(t, &tree)
+	total := 0
+	for _, leaf := range leaves {
+		total += len(leaf.Points)
+	}
+	if total != len(points) {
+		t.Fatalf("leaves hold %d points instead of %d", total, len(points))
+	}
+	checkTiling(t, tree.ChildTopRight)
+}
+
+func TestLeavesOrder(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(13)), 500, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []*ConvTree
+	var collect func(node *ConvTree)
+	collect = func(node *ConvTree) {
+		if node.IsLeaf {
+			expected = append(expected, node)
+			return
+		}
+		for _, child := range []*ConvTree{node.ChildTopLeft, node.ChildTopRight, node.ChildBottomLeft,
+			node.ChildBottomRight} {
+			collect(child)
+		}
+	}
+	collect(&tree)
+	leaves := tree.Leaves()
+	if len(leaves) != len(expected) {
+		t.Fatalf("got %d leaves instead of %d", len(leaves), len(expected))
+	}
+	for i := range leaves {
+		if leaves[i] != expected[i] {
+			t.Fatalf("leaf %d is %s instead of %s", i, leaves[i].ID, expected[i].ID)
+		}
+	}
+	visited := 0
+	tree.WalkLeaves(func(leaf *ConvTree) bool {
+		if leaf != expected[visited] {
+			t.Fatalf("WalkLeaves visited %s instead of %s", leaf.ID, expected[visited].ID)
+		}
+		visited++
+		return visited < 3
+	})
+	if visited != 3 {
+		t.Fatalf("WalkLeaves visited %d leaves after being stopped at 3", visited)
+	}
+}
+
+func TestLevelOrder(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 40, 8, 2, 8, nil,
+		uniformPoints(rand.New(rand.NewSource(13)), 500, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Breadth-first order visits the children of every node in the order of their parents,
+	// top left, top right, bottom left, bottom right.
+	expected := [][]*ConvTree{}
+	queue := []*ConvTree{&tree}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		depth := node.Depth - tree.Depth
+		if depth == len(expected) {
+			expected = append(expected, []*ConvTree{})
+		}
+		expected[depth] = append(expected[depth], node)
+		if !node.IsLeaf {
+			queue = append(queue, node.ChildTopLeft, node.ChildTopRight, node.ChildBottomLeft, node.ChildBottomRight)
+		}
+	}
+	levels := tree.LevelOrder()
+	if len(levels) != len(expected) || len(levels) < 3 {
+		t.Fatalf("got %d levels instead of %d", len(levels), len(expected))
+	}
+	for depth := range levels {
+		if len(levels[depth]) != len(expected[depth]) {
+			t.Fatalf("level %d has %d nodes instead of %d", depth, len(levels[depth]), len(expected[depth]))
+		}
+		for i, node := range levels[depth] {
+			if node != expected[depth][i] {
+				t.Fatalf("node %d of level %d is %s instead of %s", i, depth, node.ID, expected[depth][i].ID)
+			}
+		}
+	}
+	sub := tree.ChildTopLeft.LevelOrder()
+	if len(sub[0]) != 1 || sub[0][0] != tree.ChildTopLeft {
+		t.Fatal("LevelOrder of a subtree must start at the subtree")
+	}
+}
@@ -0,0 +1,84 @@
+package convtree
+
+import "math"
+
+// ValueSummary holds running statistics of the numeric values extracted from the Content of leaf points.
+type ValueSummary struct {
+	Min   float64
+	Max   float64
+	Sum   float64
+	Count int
+}
+
+// WithValueExtractor sets the function used to extract a numeric value from point Content.
+// Leaves keep min, max and sum of the extracted values, available via ValueStats.
+func WithValueExtractor(extractor func(content interface{}) (float64, bool)) Option {
+	return func(config *treeConfig) {
+		config.extractor = extractor
+	}
+}
+
+func (config *treeConfig) value(point Point) (float64, bool) {
+	if config == nil || config.extractor == nil {
+		return 0, false
+	}
+	return config.extractor(point.Content)
+}
+
+// ValueStats returns min, max and mean of the extracted values in the leaf with the given ID
+// together with the number of values. All values are zero for empty or unknown leaves.
+func (tree *ConvTree) ValueStats(leafID string) (min, max, mean float64, n int) {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	tree.walkNodes(func(node *ConvTree) bool {
+		if node.ID != leafID {
+			return true
+		}
+		if node.IsLeaf && node.Values.Count > 0 {
+			min, max, n = node.Values.Min, node.Values.Max, node.Values.Count
+			mean = node.Values.Sum / float64(n)
+		}
+		return false
+	})
+	return min, max, mean, n
+}
+
+func (tree *ConvTree) addValue(point Point) {
+	value, ok := tree.config.value(point)
+	if !ok {
+		return
+	}
+	if tree.Values.Count == 0 {
+		tree.Values.Min, tree.Values.Max = value, value
+	} else {
+		tree.Values.Min = math.Min(tree.Values.Min, value)
+		tree.Values.Max = math.Max(tree.Values.Max, value)
+	}
+	tree.Values.Sum += value
+	tree.Values.Count++
+}
+
+// removeValue updates the statistics after the point was removed from the leaf.
+// Removing the current minimum or maximum recomputes the statistics from the remaining points.
+func (tree *ConvTree) removeValue(point Point) {
+	value, ok := tree.config.value(point)
+	if !ok {
+		return
+	}
+	if value <= tree.Values.Min || value >= tree.Values.Max {
+		tree.recomputeValues()
+		return
+	}
+	tree.Values.Sum -= value
+	tree.Values.Count--
+}
+
+func (tree *ConvTree) recomputeValues() {
+	tree.Values = ValueSummary{}
+	if tree.config == nil || tree.config.extractor == nil {
+		return
+	}
+	for _, point := range tree.Points {
+		tree.addValue(point)
+	}
+}
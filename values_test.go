@@ -0,0 +1,126 @@
+package convtree
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+func floatContent(content interface{}) (float64, bool) {
+	value, ok := content.(float64)
+	return value, ok
+}
+
+func TestValueStatsExtremumRemoval(t *testing.T) {
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 100, 4, 1, 8, nil, nil,
+		WithValueExtractor(floatContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min, max, mean, n := tree.ValueStats(tree.ID); min != 0 || max != 0 || mean != 0 || n != 0 {
+		t.Fatalf("empty leaf has stats %v %v %v %d", min, max, mean, n)
+	}
+	for i, value := range []float64{3, 1, 5, 2, 4} {
+		if err := tree.Insert(Point{X: float64(10 * (i + 1)), Y: 50, Weight: 1, Content: value}, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tree.Insert(Point{X: 60, Y: 50, Weight: 1, Content: "no value"}, false)
+	steps := []struct {
+		remove         Point
+		min, max, mean float64
+		n              int
+	}{
+		{remove: Point{X: 30, Y: 50}, min: 1, max: 4, mean: 2.5, n: 4},
+		{remove: Point{X: 20, Y: 50}, min: 2, max: 4, mean: 3, n: 3},
+		{remove: Point{X: 10, Y: 50}, min: 2, max: 4, mean: 3, n: 2},
+		{remove: Point{X: 60, Y: 50}, min: 2, max: 4, mean: 3, n: 2},
+		{remove: Point{X: 40, Y: 50}, min: 4, max: 4, mean: 4, n: 1},
+		{remove: Point{X: 50, Y: 50}},
+	}
+	for _, step := range steps {
+		if !tree.Remove(step.remove, 0) {
+			t.Fatalf("point %v was not removed", step.remove)
+		}
+		min, max, mean, n := tree.ValueStats(tree.ID)
+		if min != step.min || max != step.max || mean != step.mean || n != step.n {
+			t.Fatalf("after removing %v expected %v %v %v %d, got %v %v %v %d", step.remove,
+				step.min, step.max, step.mean, step.n, min, max, mean, n)
+		}
+	}
+}
+
+func TestValueStatsExports(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	points := uniformPoints(r, 800, 50)
+	for i := range points {
+		points[i].Content = float64(r.Intn(1000))
+	}
+	tree, err := NewConvTree(Point{X: 0, Y: 100}, Point{X: 100, Y: 0}, 1, 1, 50, 6, 1, 8, nil, points,
+		WithValueExtractor(floatContent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := tree.WriteCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records[1:] {
+		x, _ := strconv.ParseFloat(record[0], 64)
+		y, _ := strconv.ParseFloat(record[1], 64)
+		leaf := tree.findLeaf(x, y)
+		min, max, mean, _ := tree.ValueStats(leaf.ID)
+		for i, value := range []float64{min, max, mean} {
+			if record[6+i] != strconv.FormatFloat(value, 'g', -1, 64) {
+				t.Fatalf("row %v has value column %d %s, expected %v", record, 6+i, record[6+i], value)
+			}
+		}
+	}
+
+	data, err := tree.ToGeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	document := struct {
+		Features []struct {
+			Properties struct {
+				ID    string   `json:"id"`
+				Count int      `json:"value_count"`
+				Min   *float64 `json:"value_min"`
+				Max   *float64 `json:"value_max"`
+				Mean  *float64 `json:"value_mean"`
+			} `json:"properties"`
+		} `json:"features"`
+	}{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		t.Fatal(err)
+	}
+	empty := 0
+	for _, feature := range document.Features {
+		properties := feature.Properties
+		min, max, mean, n := tree.ValueStats(properties.ID)
+		if properties.Count != n {
+			t.Fatalf("leaf %s has value_count %d, expected %d", properties.ID, properties.Count, n)
+		}
+		if n == 0 {
+			empty++
+			if properties.Min != nil || properties.Max != nil || properties.Mean != nil {
+				t.Fatalf("empty leaf %s has value statistics", properties.ID)
+			}
+			continue
+		}
+		if properties.Min == nil || *properties.Min != min || *properties.Max != max || *properties.Mean != mean {
+			t.Fatalf("leaf %s has wrong value statistics", properties.ID)
+		}
+	}
+	if empty == 0 {
+		t.Fatal("expected empty leaves outside of the populated quarter")
+	}
+}
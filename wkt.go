@@ -0,0 +1,46 @@
+package convtree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LeafWKT returns the bounds of every leaf as a WKT polygon with X before Y.
+func (tree *ConvTree) LeafWKT() []string {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	result := []string{}
+	tree.walkLeaves(func(leaf *ConvTree) {
+		result = append(result, leaf.wkt())
+	})
+	return result
+}
+
+// WriteLeafWKT writes an id;wkt;weight line for every leaf, suitable for COPY into a table.
+func (tree *ConvTree) WriteLeafWKT(w io.Writer) error {
+	tree.config.rLock()
+	defer tree.config.rUnlock()
+	writer := bufio.NewWriter(w)
+	var err error
+	tree.walkLeaves(func(leaf *ConvTree) {
+		if err == nil {
+			_, err = fmt.Fprintf(writer, "%s;%s;%d\n", leaf.ID, leaf.wkt(), leaf.totalWeight())
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func (tree *ConvTree) wkt() string {
+	coordinates := []string{}
+	for _, vertex := range cellRing(tree.TopLeft, tree.BottomRight) {
+		coordinates = append(coordinates, strconv.FormatFloat(vertex[0], 'g', -1, 64)+" "+
+			strconv.FormatFloat(vertex[1], 'g', -1, 64))
+	}
+	return "POLYGON((" + strings.Join(coordinates, ", ") + "))"
+}
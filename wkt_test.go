@@ -0,0 +1,110 @@
+package convtree
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// parseWKTPolygon parses a WKT polygon with a single ring of "x y" coordinates.
+func parseWKTPolygon(text string) ([][2]float64, error) {
+	const prefix, suffix = "POLYGON((", "))"
+	if !strings.HasPrefix(text, prefix) || !strings.HasSuffix(text, suffix) {
+		return nil, fmt.Errorf("%q is not a single-ring polygon", text)
+	}
+	ring := [][2]float64{}
+	for _, vertex := range strings.Split(text[len(prefix):len(text)-len(suffix)], ",") {
+		fields := strings.Fields(vertex)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("vertex %q does not have two coordinates", vertex)
+		}
+		x, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		y, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		ring = append(ring, [2]float64{x, y})
+	}
+	if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+		return nil, fmt.Errorf("ring of %q is not closed", text)
+	}
+	return ring, nil
+}
+
+// checkWKTBounds fails the test unless the polygon is the counter-clockwise rectangle with the
+// exact bounds of the leaf.
+func checkWKTBounds(t *testing.T, leaf *ConvTree, text string) {
+	t.Helper()
+	ring, err := parseWKTPolygon(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ring) != 5 {
+		t.Fatalf("leaf %s: expected 5 vertices, got %v", leaf.ID, ring)
+	}
+	left, top := math.Inf(1), math.Inf(-1)
+	right, bottom := math.Inf(-1), math.Inf(1)
+	area := 0.0
+	for i, vertex := range ring[:4] {
+		left, right = math.Min(left, vertex[0]), math.Max(right, vertex[0])
+		bottom, top = math.Min(bottom, vertex[1]), math.Max(top, vertex[1])
+		area += vertex[0]*ring[i+1][1] - ring[i+1][0]*vertex[1]
+	}
+	if left != leaf.TopLeft.X || top != leaf.TopLeft.Y || right != leaf.BottomRight.X || bottom != leaf.BottomRight.Y {
+		t.Fatalf("leaf %s: polygon %s does not match the bounds %v %v", leaf.ID, text, leaf.TopLeft, leaf.BottomRight)
+	}
+	if area <= 0 {
+		t.Fatalf("leaf %s: ring %v is not counter-clockwise", leaf.ID, ring)
+	}
+}
+
+func TestLeafWKTRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(101))
+	points := uniformPoints(r, 2000, 1)
+	for i := range points {
+		points[i].X = points[i].X/3 + 1.0/7
+		points[i].Y = points[i].Y/3 - 1.0/7
+	}
+	tree, err := NewConvTree(Point{X: 1.0 / 7, Y: 1.0/3 - 1.0/7}, Point{X: 1.0/3 + 1.0/7, Y: -1.0 / 7}, 0.001, 0.001,
+		50, 8, 1, 8, nil, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := tree.Leaves()
+	polygons := tree.LeafWKT()
+	if len(polygons) != len(leaves) {
+		t.Fatalf("expected %d polygons, got %d", len(leaves), len(polygons))
+	}
+	for i, polygon := range polygons {
+		checkWKTBounds(t, leaves[i], polygon)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tree.WriteLeafWKT(buf); err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(buf)
+	i := 0
+	for ; scanner.Scan(); i++ {
+		fields := strings.Split(scanner.Text(), ";")
+		if len(fields) != 3 || i >= len(leaves) {
+			t.Fatalf("unexpected line %q", scanner.Text())
+		}
+		leaf := leaves[i]
+		if fields[0] != leaf.ID || fields[1] != polygons[i] || fields[2] != strconv.Itoa(leaf.totalWeight()) {
+			t.Fatalf("line %q does not describe leaf %s", scanner.Text(), leaf.ID)
+		}
+		checkWKTBounds(t, leaf, fields[1])
+	}
+	if i != len(leaves) {
+		t.Fatalf("expected %d lines, got %d", len(leaves), i)
+	}
+}